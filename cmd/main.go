@@ -10,17 +10,40 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/renderview-inc/backend/internal/app/application/middleware"
 	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/app/infrastructure/blobstore"
 	"github.com/renderview-inc/backend/internal/app/infrastructure/cache"
+	"github.com/renderview-inc/backend/internal/app/infrastructure/push"
 	"github.com/renderview-inc/backend/internal/app/infrastructure/repositories"
+	"github.com/renderview-inc/backend/internal/app/infrastructure/search"
+	"github.com/renderview-inc/backend/internal/app/infrastructure/webhook"
 	v1 "github.com/renderview-inc/backend/internal/app/presentation/api/handlers/v1"
+	"github.com/renderview-inc/backend/internal/pkg/imaging"
 	"github.com/renderview-inc/backend/internal/pkg/txhelper"
 	postgres "github.com/renderview-inc/backend/pkg/connections"
 )
 
+const (
+	defaultMaxPinnedMessages = 50
+	defaultAttachmentMaxMB   = 25
+	defaultThumbnailSize     = 320
+	thumbnailPollInterval    = 30 * time.Second
+	attachmentCleanupPeriod  = time.Hour
+	unsentAttachmentTTL      = 24 * time.Hour
+	presenceSweepInterval    = 15 * time.Second
+	searchIndexInterval      = 2 * time.Second
+	pushPollInterval         = 2 * time.Second
+	webhookPollInterval      = 5 * time.Second
+	webhookTimeout           = 10 * time.Second
+	defaultMessageIndex      = "messages"
+	bytesInMegabyte          = 1 << 20
+)
+
 func main() {
 	ctx := context.Background()
 
@@ -34,6 +57,7 @@ func main() {
 			log.Fatalf("failed to sync log service: %v", err)
 		}
 	}(logService)
+	defer logService.RedirectStdLog()()
 
 	dbPool, err := connectPostgres()
 	if err != nil {
@@ -46,14 +70,65 @@ func main() {
 	redisAddr := os.Getenv("REDIS_HOST") + ":" + os.Getenv("REDIS_PORT")
 	redisPassword := os.Getenv("REDIS_PASSWORD")
 	httpServerAddr := os.Getenv("HTTP_ADDR")
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	maxPinnedMessages := envInt("MAX_PINNED_MESSAGES", defaultMaxPinnedMessages)
+	attachmentMaxSize := int64(envInt("ATTACHMENT_MAX_SIZE_MB", defaultAttachmentMaxMB)) * bytesInMegabyte
+
+	loggers := make(map[string]*logSystem.LogService)
+	for _, name := range []string{"auth", "chat", "message", "presence", "http"} {
+		loggers[name], err = logService.Named(name)
+		if err != nil {
+			logService.Error(ctx, "unable to create named logger", option.Any("logger", name), option.Error(err))
 
-	userAccountRepo := repositories.NewUserAccountRepository(dbPool)
+			return
+		}
+	}
+
+	userAccountRepo := repositories.NewUserAccountRepository(dbPool, loggers["auth"])
 	userSessionRepo := repositories.NewUserSessionRepository(dbPool)
 	loginHistoryRepo := repositories.NewLoginHistoryRepository(dbPool)
-	chatRepo := repositories.NewChatRepository(dbPool)
+	chatRepo := repositories.NewChatRepository(dbPool, loggers["chat"])
 	messageRepo := repositories.NewMessageRepository(dbPool)
+	readReceiptRepo := repositories.NewReadReceiptRepository(dbPool)
+	reactionRepo := repositories.NewReactionRepository(dbPool)
+	pinnedMessageRepo := repositories.NewPinnedMessageRepository(dbPool)
+	attachmentRepo := repositories.NewAttachmentRepository(dbPool)
+	messageSearchRepo := repositories.NewMessageSearchRepository(dbPool)
+	messageSearchQueueRepo := repositories.NewMessageSearchQueueRepository(dbPool)
+	chatInviteRepo := repositories.NewChatInviteRepository(dbPool)
+	presenceRepo := repositories.NewPresenceRepository(dbPool)
+	userDirectoryRepo := repositories.NewUserDirectoryRepository(dbPool)
+	contactRepo := repositories.NewContactRepository(dbPool)
+	mentionRepo := repositories.NewMentionRepository(dbPool)
+	deviceTokenRepo := repositories.NewDeviceTokenRepository(dbPool)
+	pushQueueRepo := repositories.NewPushQueueRepository(dbPool)
+	webhookRepo := repositories.NewWebhookRepository(dbPool)
+	botRepo := repositories.NewBotRepository(dbPool)
+	commandRepo := repositories.NewCommandRepository(dbPool)
 
 	sessionCache := cache.NewUserSessionCache(redisAddr, redisPassword, 0)
+	presenceCache := cache.NewPresenceCache(redisAddr, redisPassword, 0)
+
+	blobStore, err := newBlobStore(ctx)
+	if err != nil {
+		logService.Error(ctx, "unable to set up blob store", option.Error(err))
+
+		return
+	}
+
+	searchIndex, externalSearch, err := newMessageSearchIndex(ctx, messageSearchRepo, chatRepo)
+	if err != nil {
+		logService.Error(ctx, "unable to set up message search index", option.Error(err))
+
+		return
+	}
+
+	pushProvider, err := newPushProvider()
+	if err != nil {
+		logService.Error(ctx, "unable to set up push provider", option.Error(err))
+
+		return
+	}
 
 	passwordHasher := services.NewBcryptPasswordHasher()
 	txHelper := txhelper.NewTxHelper(dbPool)
@@ -69,19 +144,73 @@ func main() {
 		txHelper,
 		tokenIssuer,
 		tokenHasher,
+		loggers["auth"],
 	)
-	chatService := services.NewChatService(chatRepo)
-	messageService := services.NewMessageService(messageRepo)
+	botService := services.NewBotService(botRepo, userAccountRepo, tokenHasher, txHelper, loggers["auth"])
+	attachmentJanitor := services.NewAttachmentJanitor(attachmentRepo, blobStore, unsentAttachmentTTL,
+		attachmentCleanupPeriod, loggers["message"])
+	chatService := services.NewChatService(chatRepo, userAccountRepo, pinnedMessageRepo, messageRepo, contactRepo,
+		attachmentJanitor, txHelper, maxPinnedMessages, loggers["chat"])
+	messageService := services.NewMessageService(messageRepo, readReceiptRepo, reactionRepo, attachmentRepo,
+		mentionRepo, searchIndex, chatService, chatService, txHelper, loggers["message"])
+	commandService := services.NewCommandService(commandRepo, chatRepo, botRepo, chatService, chatService,
+		messageService, loggers["message"])
+	messageService.RouteCommands(commandService)
+	searchIndexer := services.NewSearchIndexer(messageSearchQueueRepo, messageSearchRepo, searchIndex,
+		searchIndexInterval, loggers["message"])
+	if externalSearch {
+		messageService.Subscribe(searchIndexer)
+	}
+	pushDispatcher := services.NewPushDispatcher(pushQueueRepo, deviceTokenRepo, messageRepo, chatRepo,
+		userAccountRepo, mentionRepo, chatService, presenceCache, pushProvider, pushPollInterval, loggers["message"])
+	if pushProvider != nil {
+		messageService.Subscribe(pushDispatcher)
+	}
+	webhookDispatcher := services.NewWebhookDispatcher(webhookRepo, chatRepo, webhook.NewHTTPSender(webhookTimeout),
+		txHelper, webhookPollInterval, loggers["chat"])
+	messageService.Subscribe(webhookDispatcher)
+	chatService.Subscribe(webhookDispatcher)
+	imageProcessor := imaging.NewProcessor(envInt("THUMBNAIL_SIZE", defaultThumbnailSize))
+	thumbnailWorker := services.NewThumbnailWorker(attachmentRepo, blobStore, imageProcessor,
+		thumbnailPollInterval, loggers["message"])
+	attachmentService := services.NewAttachmentService(attachmentRepo, blobStore, messageRepo, chatService,
+		imageProcessor, thumbnailWorker, attachmentMaxSize, loggers["message"])
+	chatInviteService := services.NewChatInviteService(chatInviteRepo, chatRepo, chatService, chatService, txHelper,
+		loggers["chat"])
+	webhookService := services.NewWebhookService(webhookRepo, chatRepo, chatService, loggers["chat"])
+	userDirectoryService := services.NewUserDirectoryService(userDirectoryRepo, loggers["auth"])
+	deviceService := services.NewDeviceService(deviceTokenRepo, loggers["auth"])
+	contactService := services.NewContactService(contactRepo, userAccountRepo, loggers["auth"])
+	presenceService := services.NewPresenceService(presenceCache, presenceRepo, chatService, contactRepo,
+		loggers["presence"])
+	presenceSweeper := services.NewPresenceSweeper(presenceCache, presenceRepo, presenceSweepInterval,
+		loggers["presence"])
 
-	userAccountHandler := v1.NewUserAccountHandler(&userAccountService, passwordHasher)
-	authHandler := v1.NewAuthHandler(authService)
-	chatHandler := v1.NewChatHandler(chatService)
-	messageHandler := v1.NewMessageHandler(messageService)
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		reindexMessages(ctx, searchIndexer, externalSearch, logService)
+
+		return
+	}
+
+	userAccountHandler := v1.NewUserAccountHandler(&userAccountService, passwordHasher, loggers["auth"])
+	authHandler := v1.NewAuthHandler(authService, loggers["auth"])
+	chatHandler := v1.NewChatHandler(chatService, loggers["chat"])
+	messageHandler := v1.NewMessageHandler(messageService, loggers["message"])
+	chatInviteHandler := v1.NewChatInviteHandler(chatInviteService, loggers["chat"])
+	webhookHandler := v1.NewWebhookHandler(webhookService, loggers["chat"])
+	botHandler := v1.NewBotHandler(botService, loggers["auth"])
+	commandHandler := v1.NewCommandHandler(commandService, loggers["message"])
+	userDirectoryHandler := v1.NewUserDirectoryHandler(userDirectoryService, loggers["auth"])
+	deviceHandler := v1.NewDeviceHandler(deviceService, loggers["auth"])
+	contactHandler := v1.NewContactHandler(contactService, loggers["auth"])
+	presenceHandler := v1.NewPresenceHandler(presenceService, loggers["presence"])
+	attachmentHandler := v1.NewAttachmentHandler(attachmentService, loggers["message"])
+	logLevelHandler := v1.NewLogLevelHandler(logService.Levels())
 
 	r := mux.NewRouter()
 	r.Use(middleware.CorrelationMiddleware)
 	r.Use(func(next http.Handler) http.Handler {
-		return middleware.LoggingMiddleware(next, logService)
+		return middleware.LoggingMiddleware(next, loggers["http"])
 	})
 
 	public := r.NewRoute().Subrouter()
@@ -90,27 +219,130 @@ func main() {
 	public.HandleFunc("/api/v1/user/register", userAccountHandler.HandleRegister).Methods(http.MethodPost)
 	public.HandleFunc("/api/v1/auth/login", authHandler.HandleLogin).Methods(http.MethodPost)
 
-	protected.Use(func(next http.Handler) http.Handler {
-		return middleware.AuthMiddleware(next, authService)
-	})
+	authenticate := func(next http.Handler) http.Handler {
+		return middleware.AuthMiddleware(next, authService, botService)
+	}
+	protected.Use(authenticate)
+	protected.Use(middleware.UsersOnlyMiddleware)
 
 	protected.HandleFunc("/api/v1/auth/logout", authHandler.HandleLogout).Methods(http.MethodPost)
 	protected.HandleFunc("/api/v1/auth/refresh", authHandler.HandleRefresh).Methods(http.MethodPost)
 
+	protected.HandleFunc("/api/v1/user/search", userDirectoryHandler.HandleSearch).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/user/discoverability", userDirectoryHandler.HandleSetDiscoverability).
+		Methods(http.MethodPut)
+	protected.HandleFunc("/api/v1/contact", contactHandler.HandleAddContact).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/contact", contactHandler.HandleRemoveContact).Methods(http.MethodDelete)
+	protected.HandleFunc("/api/v1/contact", contactHandler.HandleListContacts).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/block", contactHandler.HandleBlock).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/block", contactHandler.HandleUnblock).Methods(http.MethodDelete)
+	protected.HandleFunc("/api/v1/block", contactHandler.HandleListBlocked).Methods(http.MethodGet)
+
 	protected.HandleFunc("/api/v1/chat", chatHandler.HandleCreateChat).Methods(http.MethodPost)
 	protected.HandleFunc("/api/v1/chat/participant", chatHandler.HandleAddParticipant).Methods(http.MethodPost)
-	protected.HandleFunc("/api/v1/chat/tag", chatHandler.HandleGetChatInfoByTag).Methods(http.MethodGet)
-	protected.HandleFunc("/api/v1/chat/id", chatHandler.HandleGetChatInfoByID).Methods(http.MethodGet)
-	protected.HandleFunc("/api/v1/chat", chatHandler.HandleGetChatsWithLastMessages).Methods(http.MethodGet)
 	protected.HandleFunc("/api/v1/chat", chatHandler.HandleUpdateChat).Methods(http.MethodPut)
 	protected.HandleFunc("/api/v1/chat", chatHandler.HandleDeleteChat).Methods(http.MethodDelete)
 	protected.HandleFunc("/api/v1/chat/participant", chatHandler.HandleRemoveParticipant).Methods(http.MethodDelete)
+	protected.HandleFunc("/api/v1/chat/participant/role", chatHandler.HandleChangeParticipantRole).Methods(http.MethodPut)
+	protected.HandleFunc("/api/v1/chat/direct", chatHandler.HandleOpenDirectChat).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/chat/owner", chatHandler.HandleTransferOwnership).Methods(http.MethodPut)
+	protected.HandleFunc("/api/v1/chat/invite", chatInviteHandler.HandleCreateInvite).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/chat/invite", chatInviteHandler.HandleListInvites).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/chat/invite", chatInviteHandler.HandleRevokeInvite).Methods(http.MethodDelete)
+	protected.HandleFunc("/api/v1/chat/join", chatInviteHandler.HandleJoinChat).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/chat/pin", chatHandler.HandlePinMessage).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/chat/pin", chatHandler.HandleUnpinMessage).Methods(http.MethodDelete)
+	protected.HandleFunc("/api/v1/chat/preferences", chatHandler.HandleGetPreferences).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/chat/preferences", chatHandler.HandleUpdatePreferences).Methods(http.MethodPut)
+	protected.HandleFunc("/api/v1/chat/webhook", webhookHandler.HandleCreateWebhook).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/chat/webhook", webhookHandler.HandleListWebhooks).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/chat/webhook", webhookHandler.HandleUpdateWebhook).Methods(http.MethodPut)
+	protected.HandleFunc("/api/v1/chat/webhook", webhookHandler.HandleDeleteWebhook).Methods(http.MethodDelete)
+	protected.HandleFunc("/api/v1/chat/webhook/deliveries", webhookHandler.HandleGetDeliveries).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/chat/typing", presenceHandler.HandleSetTyping).Methods(http.MethodPut)
+	protected.HandleFunc("/api/v1/chat/typing", presenceHandler.HandleGetTyping).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/chat/command", commandHandler.HandleRegisterCommand).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/chat/command", commandHandler.HandleListCommands).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/chat/command", commandHandler.HandleUnregisterCommand).Methods(http.MethodDelete)
+	protected.HandleFunc("/api/v1/chat/command/reply", commandHandler.HandleGetReplies).Methods(http.MethodGet)
+
+	protected.HandleFunc("/api/v1/bot", botHandler.HandleCreateBot).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/bot", botHandler.HandleListBots).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/bot/key", botHandler.HandleCreateKey).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/bot/key", botHandler.HandleListKeys).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/bot/key", botHandler.HandleRevokeKey).Methods(http.MethodDelete)
+
+	protected.HandleFunc("/api/v1/push/device", deviceHandler.HandleRegister).Methods(http.MethodPost)
+	protected.HandleFunc("/api/v1/push/device", deviceHandler.HandleUnregister).Methods(http.MethodDelete)
+
+	protected.HandleFunc("/api/v1/presence", presenceHandler.HandleHeartbeat).Methods(http.MethodPut)
+	protected.HandleFunc("/api/v1/presence", presenceHandler.HandleGoOffline).Methods(http.MethodDelete)
+	protected.HandleFunc("/api/v1/presence", presenceHandler.HandleGetPresence).Methods(http.MethodGet)
+	protected.HandleFunc("/api/v1/presence/privacy", presenceHandler.HandleSetPrivacy).Methods(http.MethodPut)
+
+	// Routes bots can use as well, as far as their API key's scopes allow.
+	shared := r.NewRoute().Subrouter()
+	shared.Use(authenticate)
+	readChats := func(handler http.HandlerFunc) http.Handler {
+		return middleware.BotScopeMiddleware(handler, entities.BotScopeReadChats)
+	}
+	readMessages := func(handler http.HandlerFunc) http.Handler {
+		return middleware.BotScopeMiddleware(handler, entities.BotScopeReadMessages)
+	}
+	writeMessages := func(handler http.HandlerFunc) http.Handler {
+		return middleware.BotScopeMiddleware(handler, entities.BotScopeWriteMessages)
+	}
+	// Command invocations are for bots to answer, so people are turned away.
+	commands := func(handler http.HandlerFunc) http.Handler {
+		return middleware.BotsOnlyMiddleware(middleware.BotScopeMiddleware(handler, entities.BotScopeCommands))
+	}
+
+	shared.Handle("/api/v1/chat/tag", readChats(chatHandler.HandleGetChatInfoByTag)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/chat/id", readChats(chatHandler.HandleGetChatInfoByID)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/chat", readChats(chatHandler.HandleGetChatsWithLastMessages)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/chat/pin", readChats(chatHandler.HandleGetPinnedMessages)).Methods(http.MethodGet)
+
+	shared.Handle("/api/v1/message", writeMessages(messageHandler.HandleCreateMessage)).Methods(http.MethodPost)
+	shared.Handle("/api/v1/message", readMessages(messageHandler.HandleGetMessage)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/message/last", readMessages(messageHandler.HandleGetLastMessageByChatTag)).
+		Methods(http.MethodGet)
+	shared.Handle("/api/v1/message", writeMessages(messageHandler.HandleUpdateMessage)).Methods(http.MethodPut)
+	shared.Handle("/api/v1/message", writeMessages(messageHandler.HandleDeleteMessage)).Methods(http.MethodDelete)
+	shared.Handle("/api/v1/message/search", readMessages(messageHandler.HandleSearch)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/message/mentions", readMessages(messageHandler.HandleGetMentions)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/message/thread", readMessages(messageHandler.HandleGetThread)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/message/revisions", readMessages(messageHandler.HandleGetRevisions)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/message/reaction", writeMessages(messageHandler.HandleAddReaction)).Methods(http.MethodPost)
+	shared.Handle("/api/v1/message/reaction", writeMessages(messageHandler.HandleRemoveReaction)).
+		Methods(http.MethodDelete)
+	shared.Handle("/api/v1/message/read", readMessages(messageHandler.HandleMarkRead)).Methods(http.MethodPut)
+
+	shared.Handle("/api/v1/attachment", writeMessages(attachmentHandler.HandleUpload)).Methods(http.MethodPost)
+	shared.Handle("/api/v1/attachment", readMessages(attachmentHandler.HandleDownload)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/attachment/thumbnail", readMessages(attachmentHandler.HandleThumbnail)).
+		Methods(http.MethodGet)
 
-	protected.HandleFunc("/api/v1/message", messageHandler.HandleCreateMessage).Methods(http.MethodPost)
-	protected.HandleFunc("/api/v1/message", messageHandler.HandleGetMessage).Methods(http.MethodGet)
-	protected.HandleFunc("/api/v1/message/last", messageHandler.HandleGetLastMessageByChatTag).Methods(http.MethodGet)
-	protected.HandleFunc("/api/v1/message", messageHandler.HandleUpdateMessage).Methods(http.MethodPut)
-	protected.HandleFunc("/api/v1/message", messageHandler.HandleDeleteMessage).Methods(http.MethodDelete)
+	shared.Handle("/api/v1/bot/command", commands(commandHandler.HandleClaimInvocations)).Methods(http.MethodGet)
+	shared.Handle("/api/v1/bot/command/response", commands(commandHandler.HandleRespond)).Methods(http.MethodPost)
+
+	admin := protected.PathPrefix("/api/v1/admin").Subrouter()
+	admin.Use(func(next http.Handler) http.Handler {
+		return middleware.AdminMiddleware(next, adminToken)
+	})
+
+	admin.HandleFunc("/log/level", logLevelHandler.HandleGetLevels).Methods(http.MethodGet)
+	admin.HandleFunc("/log/level", logLevelHandler.HandleSetLevel).Methods(http.MethodPut)
+
+	go thumbnailWorker.Run(ctx)
+	go attachmentJanitor.Run(ctx)
+	go presenceSweeper.Run(ctx)
+	go webhookDispatcher.Run(ctx)
+	if externalSearch {
+		go searchIndexer.Run(ctx)
+	}
+	if pushProvider != nil {
+		go pushDispatcher.Run(ctx)
+	}
 
 	logService.Info(ctx, "starting server", option.Any("httpAddr", httpServerAddr))
 	if err = http.ListenAndServe(httpServerAddr, r); err != nil {
@@ -128,6 +360,93 @@ func connectPostgres() (*pgxpool.Pool, error) {
 	return postgres.NewPsqlPool(dbURL)
 }
 
+// newBlobStore picks the attachment storage by BLOB_STORE: "s3" for an
+// S3-compatible service, anything else for the local filesystem.
+func newBlobStore(ctx context.Context) (services.BlobStore, error) {
+	if os.Getenv("BLOB_STORE") != "s3" {
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/blobs"
+		}
+
+		return blobstore.NewLocalBlobStore(dir)
+	}
+
+	store := blobstore.NewS3BlobStore(blobstore.S3Options{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    os.Getenv("S3_REGION"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+	})
+	if err := store.EnsureBucket(ctx); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// newMessageSearchIndex picks the message search backend by SEARCH_BACKEND:
+// "elasticsearch" for an Elasticsearch index kept in sync in the background,
+// anything else for Postgres full-text search. The flag tells whether the
+// index is external and needs feeding.
+func newMessageSearchIndex(ctx context.Context, pg *repositories.MessageSearchRepository,
+	chats search.ChatMembership) (services.MessageSearchIndex, bool, error) {
+	if os.Getenv("SEARCH_BACKEND") != "elasticsearch" {
+		return pg, false, nil
+	}
+
+	name := os.Getenv("ELASTICSEARCH_MESSAGE_INDEX")
+	if name == "" {
+		name = defaultMessageIndex
+	}
+
+	index := search.NewElasticMessageIndex(os.Getenv("ELASTICSEARCH_URL"), name, chats)
+	if err := index.EnsureIndex(ctx); err != nil {
+		return nil, false, err
+	}
+
+	return index, true, nil
+}
+
+// newPushProvider picks how push notifications are delivered by
+// PUSH_PROVIDER: "http" posts them to the gateway at PUSH_GATEWAY_URL, "file"
+// appends them to PUSH_FILE_PATH. Anything else turns push notifications off
+// and yields a nil provider.
+func newPushProvider() (services.PushProvider, error) {
+	switch os.Getenv("PUSH_PROVIDER") {
+	case "http":
+		return push.NewHTTPProvider(os.Getenv("PUSH_GATEWAY_URL")), nil
+	case "file":
+		path := os.Getenv("PUSH_FILE_PATH")
+		if path == "" {
+			path = "./data/push.jsonl"
+		}
+
+		return push.NewFileProvider(path)
+	default:
+		return nil, nil
+	}
+}
+
+// reindexMessages rebuilds an external search index from the messages
+// table. It is run as "accs_http reindex".
+func reindexMessages(ctx context.Context, indexer *services.SearchIndexer, externalSearch bool,
+	logService *logSystem.LogService) {
+	if !externalSearch {
+		logService.Info(ctx, "postgres search reads messages directly; nothing to reindex")
+		return
+	}
+
+	total, err := indexer.Reindex(ctx)
+	if err != nil {
+		logService.Error(ctx, "reindex failed", option.Any("indexed", total), option.Error(err))
+		return
+	}
+
+	logService.Info(ctx, "reindex finished", option.Any("indexed", total))
+}
+
 func registerLogService() (*logSystem.LogService, error) {
 	cfg, err := config.LoadLogConfig()
 	if err != nil {
@@ -141,3 +460,14 @@ func registerLogService() (*logSystem.LogService, error) {
 
 	return loggerService, nil
 }
+
+// envInt reads a positive integer from the environment, falling back to def
+// when the variable is unset or malformed.
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return def
+	}
+
+	return value
+}
@@ -0,0 +1,24 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Attachment struct {
+	ID        uuid.UUID  `json:"id"`
+	ChatID    uuid.UUID  `json:"chat_id"`
+	MessageID *uuid.UUID `json:"message_id,omitempty"`
+	FileName  string     `json:"file_name"`
+	MimeType  string     `json:"mime_type"`
+	Size      int64      `json:"size"`
+	Checksum  string     `json:"checksum"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Image previews. Placeholder is a BlurHash string.
+	Width           *int    `json:"width,omitempty"`
+	Height          *int    `json:"height,omitempty"`
+	Placeholder     *string `json:"placeholder,omitempty"`
+	ThumbnailStatus string  `json:"thumbnail_status,omitempty"`
+}
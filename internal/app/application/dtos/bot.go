@@ -0,0 +1,57 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BotRequest struct {
+	Tag  string `json:"tag"`
+	Name string `json:"name"`
+	Desc string `json:"description"`
+}
+
+type Bot struct {
+	ID        uuid.UUID `json:"id"`
+	Tag       string    `json:"tag"`
+	Name      string    `json:"name"`
+	Desc      string    `json:"description"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BotsResponse struct {
+	Bots []Bot `json:"bots"`
+}
+
+// BotKeyRequest issues an API key for the bot. Scopes are any of
+// "chats:read", "messages:read", "messages:write" and "commands"; a key
+// without ExpiresAt never expires.
+type BotKeyRequest struct {
+	BotID     uuid.UUID  `json:"bot_id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// BotKey describes an API key. Key itself is only returned when the key is
+// issued; it is sent as "Authorization: Bot <key>".
+type BotKey struct {
+	ID         uuid.UUID  `json:"id"`
+	BotID      uuid.UUID  `json:"bot_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type BotKeysResponse struct {
+	Keys []BotKey `json:"keys"`
+}
+
+type BotKeyID struct {
+	ID uuid.UUID `json:"id"`
+}
@@ -6,9 +6,21 @@ type ChatRequest struct {
 	Tag     string    `json:"tag,omitempty"`
 	OwnerId uuid.UUID `json:"owner_id"`
 	Title   string    `json:"title"`
+	Kind    string    `json:"kind,omitempty"`
 }
 
 type ChatResponse struct {
 	Id  string `json:"id"`
 	Tag string `json:"tag"`
 }
+
+type DirectChatRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type DirectChatResponse struct {
+	Id     string    `json:"id"`
+	Tag    string    `json:"tag"`
+	Title  string    `json:"title"`
+	UserID uuid.UUID `json:"user_id"`
+}
@@ -0,0 +1,31 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChatInviteRequest struct {
+	ChatID    uuid.UUID  `json:"chat_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+}
+
+type ChatInvite struct {
+	Code      string     `json:"code"`
+	ChatID    uuid.UUID  `json:"chat_id"`
+	CreatedBy uuid.UUID  `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	Uses      int        `json:"uses"`
+}
+
+type ChatInvitesResponse struct {
+	Invites []ChatInvite `json:"invites"`
+}
+
+type JoinChat struct {
+	Code string `json:"code"`
+}
@@ -1,17 +1,31 @@
 package dtos
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type ChatLastMessages struct {
-	ChatID   uuid.UUID `json:"chat_id"`
-	ID       uuid.UUID `json:"id"`
-	UserID   uuid.UUID `json:"user_id"`
-	Message  string    `json:"message"`
-	Timestamp string    `json:"timestamp"`
+	ChatID      uuid.UUID  `json:"chat_id"`
+	ChatTag     string     `json:"chat_tag"`
+	Title       string     `json:"title"`
+	Kind        string     `json:"kind"`
+	Role        string     `json:"role"`
+	UnreadCount int        `json:"unread_count"`
+	LastReadAt  *time.Time `json:"last_read_at,omitempty"`
+	Muted       bool       `json:"muted"`
+	MutedUntil  *time.Time `json:"muted_until,omitempty"`
+	NotifyLevel string     `json:"notify_level"`
+	Archived    bool       `json:"archived"`
+	ID          *uuid.UUID `json:"id,omitempty"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	Timestamp   time.Time  `json:"timestamp"`
 }
 
 type ChatLastMessagesResponse struct {
-	Info []ChatLastMessages `json:"info"`
-}
\ No newline at end of file
+	Info   []ChatLastMessages `json:"info"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
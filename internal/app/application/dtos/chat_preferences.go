@@ -0,0 +1,16 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChatPreferences are the caller's settings for a chat. A null muted_until
+// means the chat isn't muted; notify_level is "all" or "mentions".
+type ChatPreferences struct {
+	ChatID      uuid.UUID  `json:"chat_id"`
+	MutedUntil  *time.Time `json:"muted_until"`
+	NotifyLevel string     `json:"notify_level"`
+	Archived    bool       `json:"archived"`
+}
@@ -0,0 +1,81 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChatCommandRequest registers "/<name>" in the chat for the bot. The bot
+// has to be a participant of the chat.
+type ChatCommandRequest struct {
+	ChatID uuid.UUID `json:"chat_id"`
+	Name   string    `json:"name"`
+	BotID  uuid.UUID `json:"bot_id"`
+	Desc   string    `json:"description"`
+}
+
+// ChatCommand is a command available in a chat. Built-in commands have no
+// bot.
+type ChatCommand struct {
+	Name    string     `json:"name"`
+	Desc    string     `json:"description"`
+	BotID   *uuid.UUID `json:"bot_id,omitempty"`
+	Builtin bool       `json:"builtin,omitempty"`
+}
+
+type ChatCommandsResponse struct {
+	Commands []ChatCommand `json:"commands"`
+}
+
+type ChatCommandID struct {
+	ChatID uuid.UUID `json:"chat_id"`
+	Name   string    `json:"name"`
+}
+
+// CommandResult is returned instead of a message when the posted text is a
+// command. InvocationID is set when the command went to a bot, which answers
+// later; Replies holds ephemeral answers available right away.
+type CommandResult struct {
+	Command      string         `json:"command"`
+	InvocationID *uuid.UUID     `json:"invocation_id,omitempty"`
+	BotID        *uuid.UUID     `json:"bot_id,omitempty"`
+	Replies      []CommandReply `json:"replies"`
+}
+
+// CommandReply is an ephemeral answer to a command, seen only by the user
+// who invoked it.
+type CommandReply struct {
+	InvocationID *uuid.UUID `json:"invocation_id,omitempty"`
+	Command      string     `json:"command"`
+	Content      string     `json:"content"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type CommandRepliesResponse struct {
+	Replies []CommandReply `json:"replies"`
+}
+
+// CommandInvocation is a command waiting for the bot to answer it.
+type CommandInvocation struct {
+	ID        uuid.UUID `json:"id"`
+	ChatID    uuid.UUID `json:"chat_id"`
+	ChatTag   string    `json:"chat_tag"`
+	UserID    uuid.UUID `json:"user_id"`
+	Command   string    `json:"command"`
+	Args      string    `json:"args"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CommandInvocationsResponse struct {
+	Invocations []CommandInvocation `json:"invocations"`
+}
+
+// CommandResponseRequest answers an invocation, either with a message posted
+// to the chat by the bot or, if Ephemeral, with a reply only the invoker
+// sees.
+type CommandResponseRequest struct {
+	InvocationID uuid.UUID `json:"invocation_id"`
+	Content      string    `json:"content"`
+	Ephemeral    bool      `json:"ephemeral"`
+}
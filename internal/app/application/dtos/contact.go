@@ -0,0 +1,35 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContactRequest names the user to add to or remove from contacts or the
+// block list.
+type ContactRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type Contact struct {
+	User    UserProfile `json:"user"`
+	AddedAt time.Time   `json:"added_at"`
+}
+
+type ContactsResponse struct {
+	Contacts []Contact `json:"contacts"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
+
+type BlockedUser struct {
+	User      UserProfile `json:"user"`
+	BlockedAt time.Time   `json:"blocked_at"`
+}
+
+type BlockedUsersResponse struct {
+	Users  []BlockedUser `json:"users"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
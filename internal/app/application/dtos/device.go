@@ -0,0 +1,8 @@
+package dtos
+
+// DeviceRegistration names a device to send push notifications to. Platform
+// is "fcm" or "apns"; it may be left out when unregistering.
+type DeviceRegistration struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform,omitempty"`
+}
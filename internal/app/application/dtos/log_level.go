@@ -0,0 +1,10 @@
+package dtos
+
+type LogLevel struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+}
+
+type LogLevelsResponse struct {
+	Levels map[string]string `json:"levels"`
+}
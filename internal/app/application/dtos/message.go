@@ -1,11 +1,120 @@
 package dtos
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Message struct {
-	ID        uuid.UUID `json:"id,omitempty"`
-	ReplyToID uuid.UUID `json:"reply_to,omitempty"`
-	UserID    uuid.UUID `json:"user_id"`
-	ChatTag   string    `json:"chat_tag"`
-	Content   string    `json:"content"`
+	ID         uuid.UUID     `json:"id,omitempty"`
+	ReplyToID  uuid.UUID     `json:"reply_to,omitempty"`
+	Quote      *MessageQuote `json:"quote,omitempty"`
+	ReplyCount int           `json:"reply_count"`
+	UserID     uuid.UUID     `json:"user_id"`
+	ChatTag    string        `json:"chat_tag"`
+	Content    string        `json:"content"`
+	// AttachmentIDs references uploads to send with a new message.
+	AttachmentIDs []uuid.UUID   `json:"attachment_ids,omitempty"`
+	Attachments   []Attachment  `json:"attachments,omitempty"`
+	Mentions      []Mention     `json:"mentions,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	EditedAt      *time.Time    `json:"edited_at,omitempty"`
+	Deleted       bool          `json:"deleted,omitempty"`
+	Reactions     []Reaction    `json:"reactions,omitempty"`
+	ReadBy        *ReadReceipts `json:"read_by,omitempty"`
+}
+
+// Mention marks an @tag in a message's content. Offset and Length count
+// characters (Unicode code points) and cover the leading '@'.
+type Mention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Offset int       `json:"offset"`
+	Length int       `json:"length"`
+}
+
+type MentionFeedEntry struct {
+	Message Message   `json:"message"`
+	ChatID  uuid.UUID `json:"chat_id"`
+}
+
+type MentionsResponse struct {
+	Mentions []MentionFeedEntry `json:"mentions"`
+	Limit    int                `json:"limit"`
+	Offset   int                `json:"offset"`
+}
+
+type Reaction struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
+
+type ReactionRequest struct {
+	MessageID uuid.UUID `json:"message_id"`
+	Emoji     string    `json:"emoji"`
+}
+
+// MessageQuote is a short excerpt of the message being replied to.
+type MessageQuote struct {
+	ID      uuid.UUID `json:"id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Snippet string    `json:"snippet"`
+	Deleted bool      `json:"deleted,omitempty"`
+}
+
+type ThreadResponse struct {
+	Root    Message   `json:"root"`
+	Replies []Message `json:"replies"`
+	Limit   int       `json:"limit"`
+	Offset  int       `json:"offset"`
+}
+
+// ReadReceipts summarizes who of the message's recipients has read it.
+// Readers is only filled in for group chats.
+type ReadReceipts struct {
+	ReadCount      int         `json:"read_count"`
+	RecipientCount int         `json:"recipient_count"`
+	Readers        []uuid.UUID `json:"readers,omitempty"`
+}
+
+type ReadMarker struct {
+	MessageID uuid.UUID `json:"message_id"`
+}
+
+type MessageRevision struct {
+	Content  string    `json:"content"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+type MessageRevisionsResponse struct {
+	MessageID uuid.UUID         `json:"message_id"`
+	Revisions []MessageRevision `json:"revisions"`
+}
+
+// MessageSearchRequest filters a message search. From is inclusive and To
+// exclusive.
+type MessageSearchRequest struct {
+	Query  string
+	ChatID *uuid.UUID
+	UserID *uuid.UUID
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// MessageSearchResult carries a matching message and an HTML-escaped excerpt
+// of it with the matched words wrapped in <mark>.
+type MessageSearchResult struct {
+	Message   Message   `json:"message"`
+	ChatID    uuid.UUID `json:"chat_id"`
+	Rank      float64   `json:"rank"`
+	Highlight string    `json:"highlight"`
+}
+
+type MessageSearchResponse struct {
+	Results []MessageSearchResult `json:"results"`
+	Limit   int                   `json:"limit"`
+	Offset  int                   `json:"offset"`
 }
@@ -0,0 +1,23 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PinRequest struct {
+	ChatID    uuid.UUID `json:"chat_id"`
+	MessageID uuid.UUID `json:"message_id"`
+}
+
+type PinnedMessage struct {
+	Message  Message   `json:"message"`
+	PinnedBy uuid.UUID `json:"pinned_by"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+type PinnedMessagesResponse struct {
+	ChatID uuid.UUID       `json:"chat_id"`
+	Pins   []PinnedMessage `json:"pins"`
+}
@@ -0,0 +1,31 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Presence struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+type PresenceResponse struct {
+	Users []Presence `json:"users"`
+}
+
+type PresencePrivacy struct {
+	LastSeen string `json:"last_seen"`
+}
+
+type Typing struct {
+	ChatID uuid.UUID `json:"chat_id"`
+	Typing bool      `json:"typing"`
+}
+
+type TypingResponse struct {
+	ChatID  uuid.UUID   `json:"chat_id"`
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
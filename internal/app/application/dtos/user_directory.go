@@ -0,0 +1,21 @@
+package dtos
+
+import "github.com/google/uuid"
+
+// UserProfile carries the public fields of an account.
+type UserProfile struct {
+	ID   uuid.UUID `json:"id"`
+	Tag  string    `json:"tag"`
+	Name string    `json:"name"`
+	Desc string    `json:"description"`
+}
+
+type UserSearchResponse struct {
+	Users  []UserProfile `json:"users"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+type Discoverability struct {
+	Discoverable bool `json:"discoverable"`
+}
@@ -0,0 +1,99 @@
+package dtos
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRequest creates a webhook for ChatID, or changes the webhook ID.
+// When changing, fields left out keep their values; setting Enabled to true
+// re-enables a webhook that was disabled after repeated failures.
+type WebhookRequest struct {
+	ID      uuid.UUID `json:"id,omitempty"`
+	ChatID  uuid.UUID `json:"chat_id,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Events  []string  `json:"events,omitempty"`
+	Enabled *bool     `json:"enabled,omitempty"`
+}
+
+// Webhook describes a subscription. Secret, the key deliveries are signed
+// with, is only returned when the webhook is created.
+type Webhook struct {
+	ID                  uuid.UUID  `json:"id"`
+	ChatID              uuid.UUID  `json:"chat_id"`
+	URL                 string     `json:"url"`
+	Events              []string   `json:"events"`
+	Secret              string     `json:"secret,omitempty"`
+	CreatedBy           uuid.UUID  `json:"created_by"`
+	CreatedAt           time.Time  `json:"created_at"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+}
+
+type WebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type WebhookID struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+}
+
+// WebhookPayload is the body posted to a webhook. ID is the delivery's and
+// stays the same across retries. Data is one of the Webhook*Data types,
+// depending on Event.
+type WebhookPayload struct {
+	ID         uuid.UUID `json:"id"`
+	Event      string    `json:"event"`
+	ChatID     uuid.UUID `json:"chat_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+type WebhookMessageData struct {
+	Message WebhookMessage `json:"message"`
+	ActorID uuid.UUID      `json:"actor_id"`
+}
+
+type WebhookMessage struct {
+	ID        uuid.UUID  `json:"id"`
+	ReplyToID uuid.UUID  `json:"reply_to,omitempty"`
+	UserID    uuid.UUID  `json:"user_id"`
+	ChatTag   string     `json:"chat_tag"`
+	Content   string     `json:"content"`
+	Mentions  []Mention  `json:"mentions,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+}
+
+type WebhookMemberData struct {
+	UserID  uuid.UUID `json:"user_id"`
+	ActorID uuid.UUID `json:"actor_id"`
+}
+
+type WebhookRenameData struct {
+	Title   string    `json:"title"`
+	ActorID uuid.UUID `json:"actor_id"`
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// AdminMiddleware lets the request through only when it carries the admin
+// token. An empty adminToken disables admin endpoints altogether.
+func AdminMiddleware(next http.Handler, adminToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Admin-Token")
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -6,23 +6,64 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
 )
 
-func AuthMiddleware(next http.Handler, authService *services.AuthService) http.Handler {
+type ctxKey string
+
+const (
+	UserID ctxKey = "user_id"
+	Bot    ctxKey = "bot"
+)
+
+// UserIDFromContext returns the ID of the user authenticated by AuthMiddleware.
+// For a bot that is the ID of its account.
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(UserID).(uuid.UUID)
+	return userID, ok
+}
+
+// BotFromContext returns the bot authenticated by AuthMiddleware with an API
+// key. It reports false for requests made by people.
+func BotFromContext(ctx context.Context) (entities.BotIdentity, bool) {
+	bot, ok := ctx.Value(Bot).(entities.BotIdentity)
+	return bot, ok
+}
+
+// AuthMiddleware authenticates a user by "Authorization: Bearer <access
+// token>" or a bot by "Authorization: Bot <API key>". Routes only people may
+// use are guarded further by UsersOnlyMiddleware.
+func AuthMiddleware(next http.Handler, authService *services.AuthService, botService *services.BotService) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		bearerToken := r.Header.Get("Authorization")
-		if bearerToken == "" || !strings.HasPrefix(bearerToken, "Bearer ") {
+		authorization := r.Header.Get("Authorization")
+		scheme, credentials, _ := strings.Cut(authorization, " ")
+		if credentials == "" || (scheme != "Bearer" && scheme != "Bot") {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
-		token := strings.TrimPrefix(bearerToken, "Bearer ")
-
 		ctx, cancel := context.WithTimeout(r.Context(), 300*time.Millisecond)
 		defer cancel()
 
-		err := authService.Authorize(ctx, token)
+		if scheme == "Bot" {
+			bot, err := botService.Authorize(ctx, credentials)
+			if err != nil {
+				if err == services.ErrBotKeyInvalid {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			reqCtx := context.WithValue(context.WithValue(r.Context(), UserID, bot.BotID), Bot, bot)
+			next.ServeHTTP(w, r.WithContext(reqCtx))
+			return
+		}
+
+		userID, err := authService.Authorize(ctx, credentials)
 		if err != nil {
 			if err == services.ErrAccessTokenInvalid {
 				w.WriteHeader(http.StatusUnauthorized)
@@ -32,6 +73,44 @@ func AuthMiddleware(next http.Handler, authService *services.AuthService) http.H
 			return
 		}
 
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), UserID, userID)))
+	})
+}
+
+// UsersOnlyMiddleware turns bots away from routes meant for people.
+func UsersOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := BotFromContext(r.Context()); ok {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BotsOnlyMiddleware turns people away from routes meant for bots.
+func BotsOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := BotFromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BotScopeMiddleware lets bots through only if their API key grants the
+// scope. People are always let through; routes only bots may use are
+// guarded further by BotsOnlyMiddleware.
+func BotScopeMiddleware(next http.Handler, scope entities.BotScope) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bot, ok := BotFromContext(r.Context()); ok && !bot.Allows(scope) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -6,7 +6,7 @@ import (
 	"net/http"
 )
 
-func LoggingMiddleware(next http.Handler, logService *service.LogService) http.Handler {
+func LoggingMiddleware(next http.Handler, logService service.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		correlationID := r.Context().Value(service.CorrelationID)
 		logService.Info(r.Context(), "incoming request",
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const attachmentCleanupBatchSize = 100
+
+type OrphanedAttachmentRepository interface {
+	DeleteOrphaned(ctx context.Context, unsentBefore time.Time, limit uint64) ([]entities.Attachment, error)
+	DeleteByChat(ctx context.Context, tx pgx.Tx, chatID uuid.UUID) ([]entities.Attachment, error)
+}
+
+// AttachmentJanitor deletes attachments nobody can open any more: uploads
+// that weren't sent with a message within unsentTTL and attachments of
+// deleted messages. The rows go first, so an upload can't be sent while its
+// blob is being removed; a blob that fails to delete is only logged.
+type AttachmentJanitor struct {
+	repo      OrphanedAttachmentRepository
+	store     BlobStore
+	unsentTTL time.Duration
+	interval  time.Duration
+	logger    logger.Logger
+}
+
+func NewAttachmentJanitor(repo OrphanedAttachmentRepository, store BlobStore, unsentTTL, interval time.Duration,
+	logger logger.Logger) *AttachmentJanitor {
+	return &AttachmentJanitor{
+		repo:      repo,
+		store:     store,
+		unsentTTL: unsentTTL,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Run cleans up every interval until ctx is done.
+func (aj *AttachmentJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(aj.interval)
+	defer ticker.Stop()
+
+	for {
+		aj.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep keeps deleting batches until none are left.
+func (aj *AttachmentJanitor) sweep(ctx context.Context) {
+	removed := 0
+	for ctx.Err() == nil {
+		attachments, err := aj.repo.DeleteOrphaned(ctx, time.Now().Add(-aj.unsentTTL), attachmentCleanupBatchSize)
+		if err != nil {
+			aj.logger.Error(ctx, "failed to delete orphaned attachments", option.Error(err))
+			break
+		}
+
+		aj.RemoveBlobs(ctx, attachments)
+		removed += len(attachments)
+
+		if len(attachments) < attachmentCleanupBatchSize {
+			break
+		}
+	}
+
+	if removed > 0 {
+		aj.logger.Info(ctx, "orphaned attachments deleted", option.Any("count", removed))
+	}
+}
+
+// DeleteChatAttachments deletes the attachments of a chat deleted in tx.
+// Their blobs are left for RemoveBlobs once tx commits.
+func (aj *AttachmentJanitor) DeleteChatAttachments(ctx context.Context, tx pgx.Tx,
+	chatID uuid.UUID) ([]entities.Attachment, error) {
+	return aj.repo.DeleteByChat(ctx, tx, chatID)
+}
+
+// RemoveBlobs deletes the blobs and thumbnails of deleted attachments.
+func (aj *AttachmentJanitor) RemoveBlobs(ctx context.Context, attachments []entities.Attachment) {
+	for _, a := range attachments {
+		aj.deleteBlob(ctx, a.StorageKey)
+		if a.ThumbnailKey != nil {
+			aj.deleteBlob(ctx, *a.ThumbnailKey)
+		}
+	}
+}
+
+func (aj *AttachmentJanitor) deleteBlob(ctx context.Context, key string) {
+	if err := aj.store.Delete(ctx, key); err != nil {
+		aj.logger.Warn(ctx, "failed to delete orphaned blob", option.Any("key", key), option.Error(err))
+	}
+}
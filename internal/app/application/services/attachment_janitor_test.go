@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+func TestAttachmentJanitorDeletesBlobs(t *testing.T) {
+	thumbKey := "attachments/chat/image.thumb"
+	orphaned := make([]entities.Attachment, 0, attachmentCleanupBatchSize+1)
+	for i := 0; i < attachmentCleanupBatchSize+1; i++ {
+		orphaned = append(orphaned, entities.Attachment{StorageKey: fmt.Sprintf("attachments/chat/%d", i)})
+	}
+	orphaned[0].ThumbnailKey = &thumbKey
+
+	store := &fakeBlobStore{blobs: map[string][]byte{"attachments/chat/kept": []byte("kept"), thumbKey: nil}}
+	for _, a := range orphaned {
+		store.blobs[a.StorageKey] = []byte("orphaned")
+	}
+	repo := &fakeOrphanedAttachmentRepo{pending: orphaned}
+
+	janitor := NewAttachmentJanitor(repo, store, 24*time.Hour, time.Hour, nopLogger{})
+	janitor.sweep(context.Background())
+
+	if len(store.blobs) != 1 || store.blobs["attachments/chat/kept"] == nil {
+		t.Fatalf("blobs left = %d, want only the kept one", len(store.blobs))
+	}
+	if repo.calls != 2 {
+		t.Fatalf("DeleteOrphaned() called %d times, want 2", repo.calls)
+	}
+	if d := time.Since(repo.unsentBefore); d < 24*time.Hour || d > 25*time.Hour {
+		t.Fatalf("unsentBefore is %s ago, want 24h", d)
+	}
+}
+
+type fakeOrphanedAttachmentRepo struct {
+	pending      []entities.Attachment
+	calls        int
+	unsentBefore time.Time
+}
+
+func (r *fakeOrphanedAttachmentRepo) DeleteOrphaned(_ context.Context, unsentBefore time.Time,
+	limit uint64) ([]entities.Attachment, error) {
+	r.calls++
+	r.unsentBefore = unsentBefore
+
+	n := min(int(limit), len(r.pending))
+	batch := r.pending[:n]
+	r.pending = r.pending[n:]
+
+	return batch, nil
+}
+
+func (r *fakeOrphanedAttachmentRepo) DeleteByChat(context.Context, pgx.Tx, uuid.UUID) ([]entities.Attachment, error) {
+	return nil, nil
+}
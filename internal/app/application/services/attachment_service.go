@@ -0,0 +1,346 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/pkg/imaging"
+)
+
+const (
+	// sniffLen is how much of an upload http.DetectContentType looks at.
+	sniffLen                 = 512
+	maxFileNameLen           = 255
+	defaultFileName          = "file"
+	maxAttachmentsPerMessage = 10
+)
+
+var (
+	ErrAttachmentNotFound       = errors.New("attachment doesn't exist")
+	ErrAttachmentTooLarge       = errors.New("attachment is too large")
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type is not allowed")
+	ErrEmptyAttachment          = errors.New("attachment is empty")
+	ErrAttachmentSizeMismatch   = errors.New("uploaded file doesn't match its declared size")
+	ErrInvalidAttachment        = errors.New("messages can only carry up to 10 of the author's unsent uploads to the same chat")
+	ErrInvalidImage             = errors.New("file is not a valid image")
+	ErrThumbnailNotFound        = errors.New("attachment has no thumbnail")
+)
+
+// BlobStore keeps attachment contents. Get returns a nil reader for a missing
+// blob.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// ImageProcessor strips metadata from uploaded images and renders their
+// previews.
+type ImageProcessor interface {
+	StripMetadata(data []byte, mimeType string) ([]byte, error)
+	Preview(data []byte) (imaging.Preview, error)
+}
+
+// ThumbnailQueue is told when an image is waiting for its preview.
+type ThumbnailQueue interface {
+	Notify()
+}
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, a entities.Attachment) error
+	ReadByID(ctx context.Context, id uuid.UUID) (*entities.Attachment, error)
+	ReadByMessageIDs(ctx context.Context, messageIDs []uuid.UUID) ([]entities.Attachment, error)
+	Attach(ctx context.Context, tx pgx.Tx, ids []uuid.UUID, msg *entities.Message) (int64, error)
+}
+
+type AttachmentService struct {
+	repo        AttachmentRepository
+	store       BlobStore
+	msgRepo     MessageReader
+	permissions ChatPermissionChecker
+	images      ImageProcessor
+	thumbnails  ThumbnailQueue
+	maxSize     int64
+	logger      logger.Logger
+}
+
+// NewAttachmentService creates the service. maxSize caps a single upload in
+// bytes.
+func NewAttachmentService(repo AttachmentRepository, store BlobStore, msgRepo MessageReader,
+	permissions ChatPermissionChecker, images ImageProcessor, thumbnails ThumbnailQueue, maxSize int64,
+	logger logger.Logger) *AttachmentService {
+	return &AttachmentService{
+		repo:        repo,
+		store:       store,
+		msgRepo:     msgRepo,
+		permissions: permissions,
+		images:      images,
+		thumbnails:  thumbnails,
+		maxSize:     maxSize,
+		logger:      logger,
+	}
+}
+
+func (as *AttachmentService) MaxSize() int64 {
+	return as.maxSize
+}
+
+// Upload stores a file for the chat. The MIME type is sniffed from the
+// content rather than trusted from the client. Images are stored without
+// their metadata and queued for a preview.
+func (as *AttachmentService) Upload(ctx context.Context, userID, chatID uuid.UUID, fileName string,
+	size int64, r io.Reader) (dtos.Attachment, error) {
+	if err := as.permissions.CheckPermission(ctx, chatID, userID, entities.ChatPermissionPostMessage); err != nil {
+		return dtos.Attachment{}, err
+	}
+	if size <= 0 {
+		return dtos.Attachment{}, ErrEmptyAttachment
+	}
+	if size > as.maxSize {
+		return dtos.Attachment{}, ErrAttachmentTooLarge
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return dtos.Attachment{}, fmt.Errorf("read upload: %w", err)
+	}
+	head = head[:n]
+
+	mimeType := detectMimeType(head)
+	if !allowedAttachmentType(mimeType) {
+		return dtos.Attachment{}, ErrAttachmentTypeNotAllowed
+	}
+
+	attachment := entities.NewAttachment(chatID, userID, sanitizeFileName(fileName), mimeType)
+
+	var content io.Reader = io.MultiReader(bytes.NewReader(head), io.LimitReader(r, size-int64(n)))
+	if entities.IsImageType(mimeType) {
+		if content, size, err = as.stripImage(ctx, content, size, mimeType); err != nil {
+			return dtos.Attachment{}, err
+		}
+	}
+
+	hash := sha256.New()
+	counter := &byteCounter{}
+	body := io.TeeReader(content, io.MultiWriter(hash, counter))
+
+	if err = as.store.Put(ctx, attachment.StorageKey, body, size, mimeType); err != nil {
+		return dtos.Attachment{}, fmt.Errorf("store attachment: %w", err)
+	}
+	if counter.n != size {
+		as.discard(ctx, attachment.StorageKey)
+		return dtos.Attachment{}, ErrAttachmentSizeMismatch
+	}
+
+	attachment.Size = size
+	attachment.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	if err = as.repo.Create(ctx, attachment); err != nil {
+		as.discard(ctx, attachment.StorageKey)
+		return dtos.Attachment{}, fmt.Errorf("save attachment: %w", err)
+	}
+	if attachment.ThumbnailStatus != nil {
+		as.thumbnails.Notify()
+	}
+
+	as.logger.Info(ctx, "attachment uploaded",
+		option.Any("attachment_id", attachment.ID.String()),
+		option.Any("chat_id", chatID.String()),
+		option.Any("mime_type", mimeType),
+		option.Any("size", size),
+	)
+
+	return toAttachmentDto(attachment), nil
+}
+
+// Open returns the attachment and its content for a chat participant. Until
+// it is sent with a message, an upload is visible to its uploader only;
+// attachments of deleted messages are gone. The caller closes the reader.
+func (as *AttachmentService) Open(ctx context.Context, userID, id uuid.UUID) (dtos.Attachment, io.ReadCloser, error) {
+	attachment, err := as.readable(ctx, userID, id)
+	if err != nil {
+		return dtos.Attachment{}, nil, err
+	}
+
+	content, err := as.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return dtos.Attachment{}, nil, fmt.Errorf("open attachment: %w", err)
+	}
+	if content == nil {
+		return dtos.Attachment{}, nil, ErrAttachmentNotFound
+	}
+
+	return toAttachmentDto(*attachment), content, nil
+}
+
+// OpenThumbnail returns the JPEG thumbnail of an image attachment to whoever
+// may open the attachment itself. The caller closes the reader.
+func (as *AttachmentService) OpenThumbnail(ctx context.Context, userID, id uuid.UUID) (dtos.Attachment,
+	io.ReadCloser, error) {
+	attachment, err := as.readable(ctx, userID, id)
+	if err != nil {
+		return dtos.Attachment{}, nil, err
+	}
+	if attachment.ThumbnailKey == nil {
+		return dtos.Attachment{}, nil, ErrThumbnailNotFound
+	}
+
+	content, err := as.store.Get(ctx, *attachment.ThumbnailKey)
+	if err != nil {
+		return dtos.Attachment{}, nil, fmt.Errorf("open thumbnail: %w", err)
+	}
+	if content == nil {
+		return dtos.Attachment{}, nil, ErrThumbnailNotFound
+	}
+
+	return toAttachmentDto(*attachment), content, nil
+}
+
+// readable returns the attachment if the user may open it.
+func (as *AttachmentService) readable(ctx context.Context, userID, id uuid.UUID) (*entities.Attachment, error) {
+	attachment, err := as.repo.ReadByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("read attachment: %w", err)
+	}
+	if attachment == nil {
+		return nil, ErrAttachmentNotFound
+	}
+
+	err = as.permissions.CheckPermission(ctx, attachment.ChatID, userID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	if attachment.MessageID == nil {
+		if attachment.UploaderID != userID {
+			return nil, ErrAttachmentNotFound
+		}
+	} else {
+		msg, err := as.msgRepo.ReadByID(ctx, *attachment.MessageID)
+		if err != nil {
+			return nil, fmt.Errorf("read message: %w", err)
+		}
+		if msg == nil || msg.DeletedAt != nil {
+			return nil, ErrAttachmentNotFound
+		}
+	}
+
+	return attachment, nil
+}
+
+// stripImage buffers an uploaded image and removes its metadata, so that
+// things like the GPS position never reach the store.
+func (as *AttachmentService) stripImage(ctx context.Context, r io.Reader, size int64,
+	mimeType string) (io.Reader, int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read upload: %w", err)
+	}
+	if int64(len(data)) != size {
+		return nil, 0, ErrAttachmentSizeMismatch
+	}
+
+	stripped, err := as.images.StripMetadata(data, mimeType)
+	if err != nil {
+		as.logger.Debug(ctx, "rejected image upload", option.Any("mime_type", mimeType), option.Error(err))
+		return nil, 0, ErrInvalidImage
+	}
+
+	return bytes.NewReader(stripped), int64(len(stripped)), nil
+}
+
+func (as *AttachmentService) discard(ctx context.Context, key string) {
+	if err := as.store.Delete(ctx, key); err != nil {
+		as.logger.Warn(ctx, "failed to delete orphaned blob", option.Any("key", key), option.Error(err))
+	}
+}
+
+type byteCounter struct {
+	n int64
+}
+
+func (bc *byteCounter) Write(p []byte) (int, error) {
+	bc.n += int64(len(p))
+	return len(p), nil
+}
+
+func detectMimeType(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	return mediaType
+}
+
+func allowedAttachmentType(mimeType string) bool {
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp", "image/bmp",
+		"application/pdf", "text/plain", "application/zip", "application/x-gzip",
+		"audio/mpeg", "audio/wave", "application/ogg", "video/mp4", "video/webm":
+		return true
+	default:
+		return false
+	}
+}
+
+// sanitizeFileName keeps the base name of what the client sent, without
+// control characters and within a sane length.
+func sanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+
+	if runes := []rune(name); len(runes) > maxFileNameLen {
+		name = string(runes[:maxFileNameLen])
+	}
+	if name == "" || name == "." || name == "/" {
+		return defaultFileName
+	}
+
+	return name
+}
+
+func toAttachmentDto(a entities.Attachment) dtos.Attachment {
+	var thumbnailStatus string
+	if a.ThumbnailStatus != nil {
+		thumbnailStatus = string(*a.ThumbnailStatus)
+	}
+
+	return dtos.Attachment{
+		ID:        a.ID,
+		ChatID:    a.ChatID,
+		MessageID: a.MessageID,
+		FileName:  a.FileName,
+		MimeType:  a.MimeType,
+		Size:      a.Size,
+		Checksum:  a.Checksum,
+		CreatedAt: a.CreatedAt,
+
+		Width:           a.Width,
+		Height:          a.Height,
+		Placeholder:     a.Placeholder,
+		ThumbnailStatus: thumbnailStatus,
+	}
+}
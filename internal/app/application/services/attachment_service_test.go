@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/pkg/imaging"
+)
+
+const testMaxAttachmentSize = 1024
+
+func TestUploadChecksContentType(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		content  []byte
+		wantType string
+		wantErr  error
+	}{
+		{"plain text", []byte("just some notes\n"), "text/plain", nil},
+		{"pdf", []byte("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n1 0 obj\n"), "application/pdf", nil},
+		{"png", pngData.Bytes(), "image/png", nil},
+		{"zip", []byte("PK\x03\x04\x14\x00\x00\x00\x08\x00"), "application/zip", nil},
+		{"html", []byte("<!DOCTYPE html><html><script>alert(1)</script></html>"), "", ErrAttachmentTypeNotAllowed},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), "",
+			ErrAttachmentTypeNotAllowed},
+		{"executable", []byte("\x7FELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00"), "", ErrAttachmentTypeNotAllowed},
+		{"unknown binary", []byte{0x00, 0x01, 0x02, 0x03, 0xFE, 0xFF}, "", ErrAttachmentTypeNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, store := newTestAttachmentService(nil)
+
+			// The file name claims something harmless; only the content counts.
+			got, err := svc.Upload(context.Background(), uuid.New(), uuid.New(), "photo.jpg",
+				int64(len(tt.content)), bytes.NewReader(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Upload() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(store.blobs) != 0 || len(repo.created) != 0 {
+					t.Fatal("a rejected upload was stored")
+				}
+				return
+			}
+			if got.MimeType != tt.wantType {
+				t.Fatalf("MimeType = %q, want %q", got.MimeType, tt.wantType)
+			}
+			if len(repo.created) != 1 || !bytes.Equal(store.blobs[repo.created[0].StorageKey], tt.content) {
+				t.Fatal("upload wasn't stored")
+			}
+		})
+	}
+}
+
+func TestUploadChecksSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared int64
+		content  string
+		wantErr  error
+	}{
+		{"empty", 0, "", ErrEmptyAttachment},
+		{"at the limit", testMaxAttachmentSize, strings.Repeat("a", testMaxAttachmentSize), nil},
+		{"over the limit", testMaxAttachmentSize + 1, strings.Repeat("a", testMaxAttachmentSize+1),
+			ErrAttachmentTooLarge},
+		{"shorter than declared", 100, "only a few bytes", ErrAttachmentSizeMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, store := newTestAttachmentService(nil)
+
+			_, err := svc.Upload(context.Background(), uuid.New(), uuid.New(), "notes.txt", tt.declared,
+				strings.NewReader(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Upload() error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantStored := 0
+			if tt.wantErr == nil {
+				wantStored = 1
+			}
+			if len(store.blobs) != wantStored || len(repo.created) != wantStored {
+				t.Fatalf("stored %d blobs and %d rows, want %d", len(store.blobs), len(repo.created), wantStored)
+			}
+		})
+	}
+}
+
+func TestUploadChecksPermission(t *testing.T) {
+	svc, repo, store := newTestAttachmentService(ErrChatPermissionDenied)
+
+	_, err := svc.Upload(context.Background(), uuid.New(), uuid.New(), "notes.txt", 5, strings.NewReader("notes"))
+	if !errors.Is(err, ErrChatPermissionDenied) {
+		t.Fatalf("Upload() error = %v, want %v", err, ErrChatPermissionDenied)
+	}
+	if len(store.blobs) != 0 || len(repo.created) != 0 {
+		t.Fatal("a rejected upload was stored")
+	}
+}
+
+func newTestAttachmentService(permissionErr error) (*AttachmentService, *fakeAttachmentRepo, *fakeBlobStore) {
+	repo := &fakeAttachmentRepo{}
+	store := &fakeBlobStore{blobs: make(map[string][]byte)}
+	svc := NewAttachmentService(repo, store, nil, fakePermissions{err: permissionErr}, fakeImages{},
+		fakeThumbnailQueue{}, testMaxAttachmentSize, nopLogger{})
+
+	return svc, repo, store
+}
+
+type fakeAttachmentRepo struct {
+	created []entities.Attachment
+}
+
+func (r *fakeAttachmentRepo) Create(_ context.Context, a entities.Attachment) error {
+	r.created = append(r.created, a)
+	return nil
+}
+
+func (r *fakeAttachmentRepo) ReadByID(context.Context, uuid.UUID) (*entities.Attachment, error) {
+	return nil, nil
+}
+
+func (r *fakeAttachmentRepo) ReadByMessageIDs(context.Context, []uuid.UUID) ([]entities.Attachment, error) {
+	return nil, nil
+}
+
+func (r *fakeAttachmentRepo) Attach(context.Context, pgx.Tx, []uuid.UUID, *entities.Message) (int64, error) {
+	return 0, nil
+}
+
+type fakeBlobStore struct {
+	blobs map[string][]byte
+}
+
+func (s *fakeBlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.blobs[key] = data
+
+	return nil
+}
+
+func (s *fakeBlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *fakeBlobStore) Delete(_ context.Context, key string) error {
+	delete(s.blobs, key)
+	return nil
+}
+
+type fakePermissions struct {
+	err error
+}
+
+func (p fakePermissions) CheckPermission(context.Context, uuid.UUID, uuid.UUID, entities.ChatPermission) error {
+	return p.err
+}
+
+func (p fakePermissions) CheckPermissionByTag(context.Context, string, uuid.UUID, entities.ChatPermission) error {
+	return p.err
+}
+
+// fakeImages leaves images as they are.
+type fakeImages struct{}
+
+func (fakeImages) StripMetadata(data []byte, _ string) ([]byte, error) {
+	return data, nil
+}
+
+func (fakeImages) Preview([]byte) (imaging.Preview, error) {
+	return imaging.Preview{}, nil
+}
+
+type fakeThumbnailQueue struct{}
+
+func (fakeThumbnailQueue) Notify() {}
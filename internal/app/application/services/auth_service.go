@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 	"github.com/renderview-inc/backend/internal/app/domain/entities"
 	"github.com/renderview-inc/backend/internal/pkg/txhelper"
 )
@@ -38,8 +39,9 @@ type UserSessionRepository interface {
 }
 
 type UserSessionCache interface {
-	SaveToken(ctx context.Context, token string, ttl time.Duration) error
+	SaveToken(ctx context.Context, token string, userID uuid.UUID, ttl time.Duration) error
 	CheckToken(ctx context.Context, token string) (bool, error)
+	ReadTokenOwner(ctx context.Context, token string) (*uuid.UUID, error)
 	RevokeToken(ctx context.Context, token string) error
 }
 
@@ -60,15 +62,16 @@ type AuthService struct {
 	txHelper               *txhelper.TxHelper
 	tokenIssuer            TokenIssuer
 	tokenHasher            TokenHasher
+	logger                 logger.Logger
 }
 
 func NewAuthService(loginHistoryRepository LoginHistoryRepository,
 	sessionRepository UserSessionRepository, sessionCache UserSessionCache,
 	accountService UserAccountService, txHelper *txhelper.TxHelper, tokenIssuer TokenIssuer,
-	tokenHasher TokenHasher) *AuthService {
+	tokenHasher TokenHasher, logger logger.Logger) *AuthService {
 	return &AuthService{
 		loginHistoryRepository, sessionRepository, sessionCache, accountService, txHelper, tokenIssuer,
-		tokenHasher,
+		tokenHasher, logger,
 	}
 }
 
@@ -138,28 +141,32 @@ func (as *AuthService) Login(ctx context.Context, loginDto dtos.FullLoginInfo) (
 		return dtos.Tokens{}, fmt.Errorf("commit transaction to save session and login info: %w", err)
 	}
 
-	if err = as.sessionCache.SaveToken(ctx, accessToken, accessLifeTime); err != nil {
+	if err = as.sessionCache.SaveToken(ctx, accessToken, *userID, accessLifeTime); err != nil {
 		return dtos.Tokens{}, fmt.Errorf("save access token to cache: %w", err)
 	}
-	if err = as.sessionCache.SaveToken(ctx, refreshToken, refreshLifeTime); err != nil {
-		// TODO just log that cache failed
-		log.Printf("Just logging cache failed: %s", err.Error())
+	if err = as.sessionCache.SaveToken(ctx, refreshToken, *userID, refreshLifeTime); err != nil {
+		as.logger.Warn(ctx, "failed to cache refresh token",
+			option.Any("session_id", sessionID.String()),
+			option.Error(err),
+		)
 	}
 
 	return dtos.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
-func (as *AuthService) Authorize(ctx context.Context, accessToken string) error {
-	ok, err := as.sessionCache.CheckToken(ctx, accessToken)
+// Authorize checks the access token and returns the ID of the user it was
+// issued to.
+func (as *AuthService) Authorize(ctx context.Context, accessToken string) (uuid.UUID, error) {
+	userID, err := as.sessionCache.ReadTokenOwner(ctx, accessToken)
 	if err != nil {
-		return fmt.Errorf("check access token in cache: %w", err)
+		return uuid.Nil, fmt.Errorf("check access token in cache: %w", err)
 	}
 
-	if !ok {
-		return ErrAccessTokenInvalid
+	if userID == nil {
+		return uuid.Nil, ErrAccessTokenInvalid
 	}
 
-	return nil
+	return *userID, nil
 }
 
 func (as *AuthService) Refresh(ctx context.Context, refreshToken string) (dtos.Tokens, error) {
@@ -182,15 +189,15 @@ func (as *AuthService) Refresh(ctx context.Context, refreshToken string) (dtos.T
 		return dtos.Tokens{}, fmt.Errorf("check refresh token in cache: %w", err)
 	}
 
-	if !ok {
-		session, err = as.sessionRepository.ReadById(ctx, sessionID)
-		if err != nil {
-			return dtos.Tokens{}, fmt.Errorf("read session by ID: %w", err)
-		}
-		if session == nil {
-			return dtos.Tokens{}, ErrInvalidSessionID
-		}
+	session, err = as.sessionRepository.ReadById(ctx, sessionID)
+	if err != nil {
+		return dtos.Tokens{}, fmt.Errorf("read session by ID: %w", err)
+	}
+	if session == nil {
+		return dtos.Tokens{}, ErrInvalidSessionID
+	}
 
+	if !ok {
 		if session.RefreshTokenHash != refreshTokenHash {
 			return dtos.Tokens{}, ErrInvalidRefreshToken
 		}
@@ -253,12 +260,14 @@ func (as *AuthService) Refresh(ctx context.Context, refreshToken string) (dtos.T
 		}
 	}
 
-	if err = as.sessionCache.SaveToken(ctx, newAccessToken, newAccessLifeTime); err != nil {
+	if err = as.sessionCache.SaveToken(ctx, newAccessToken, session.UserID, newAccessLifeTime); err != nil {
 		return dtos.Tokens{}, fmt.Errorf("new access token cache: %w", err)
 	}
-	if err = as.sessionCache.SaveToken(ctx, newRefreshToken, newRefreshLifeTime); err != nil {
-		// TODO just log
-		log.Printf("Just logging cache failed: %s", err.Error())
+	if err = as.sessionCache.SaveToken(ctx, newRefreshToken, session.UserID, newRefreshLifeTime); err != nil {
+		as.logger.Warn(ctx, "failed to cache refresh token",
+			option.Any("session_id", newSession.Id.String()),
+			option.Error(err),
+		)
 	}
 
 	if err = tx.Commit(ctx); err != nil {
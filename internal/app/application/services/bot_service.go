@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/pkg/txhelper"
+)
+
+const (
+	botKeySecretLen  = 32
+	maxBotNameLen    = 32
+	maxBotKeyNameLen = 64
+	// botKeyTouchInterval is how often a key's last use is written down.
+	botKeyTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidBotTag         = errors.New("bot tag must be 3-12 letters, digits, '_' or '.' and end with 'bot'")
+	ErrInvalidBotName        = errors.New("bot name must be at most 32 characters long")
+	ErrBotTagTaken           = errors.New("account with this tag already exists")
+	ErrBotNotFound           = errors.New("bot doesn't exist")
+	ErrBotKeyNotFound        = errors.New("API key doesn't exist")
+	ErrInvalidBotKeySettings = errors.New("API key needs a name of 1-64 characters, at least one known scope " +
+		"and an expiry in the future")
+	ErrBotKeyInvalid = errors.New("API key is invalid, expired or revoked")
+)
+
+type BotRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, bot entities.Bot) error
+	ReadByID(ctx context.Context, id uuid.UUID) (*entities.Bot, error)
+	ReadByOwner(ctx context.Context, ownerID uuid.UUID) ([]entities.Bot, error)
+	CreateKey(ctx context.Context, key entities.BotKey) error
+	ReadKey(ctx context.Context, id uuid.UUID) (*entities.BotKey, error)
+	ReadKeys(ctx context.Context, botID uuid.UUID) ([]entities.BotKey, error)
+	RevokeKey(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+	TouchKey(ctx context.Context, id uuid.UUID, usedAt time.Time, interval time.Duration) error
+}
+
+type AccountTagReader interface {
+	ReadByTag(ctx context.Context, accTag string) (*entities.UserAccount, error)
+}
+
+// BotService manages bot accounts and their API keys, and authenticates
+// requests made with those keys. A key is "<key ID>.<secret>"; only its
+// hash is stored.
+type BotService struct {
+	repo        BotRepository
+	accounts    AccountTagReader
+	tokenHasher TokenHasher
+	txHelper    *txhelper.TxHelper
+	logger      logger.Logger
+}
+
+func NewBotService(repo BotRepository, accounts AccountTagReader, tokenHasher TokenHasher,
+	txHelper *txhelper.TxHelper, logger logger.Logger) *BotService {
+	return &BotService{
+		repo:        repo,
+		accounts:    accounts,
+		tokenHasher: tokenHasher,
+		txHelper:    txHelper,
+		logger:      logger,
+	}
+}
+
+// Create adds a bot owned by the user. Bot tags end with "bot" so people
+// can tell bots apart from each other.
+func (bs *BotService) Create(ctx context.Context, ownerID uuid.UUID, req dtos.BotRequest) (dtos.Bot, error) {
+	tagRe := regexp.MustCompile(`^[a-zA-Z0-9_.]{3,12}$`)
+	if !tagRe.MatchString(req.Tag) || !strings.HasSuffix(strings.ToLower(req.Tag), "bot") {
+		return dtos.Bot{}, ErrInvalidBotTag
+	}
+	if utf8.RuneCountInString(req.Name) > maxBotNameLen {
+		return dtos.Bot{}, ErrInvalidBotName
+	}
+
+	existing, err := bs.accounts.ReadByTag(ctx, req.Tag)
+	if err != nil {
+		return dtos.Bot{}, fmt.Errorf("failed to check account existence: %w", err)
+	}
+	if existing != nil {
+		return dtos.Bot{}, ErrBotTagTaken
+	}
+
+	name := req.Name
+	if name == "" {
+		name = req.Tag
+	}
+	bot := entities.NewBot(ownerID, req.Tag, name, req.Desc)
+
+	err = bs.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		return bs.repo.Create(ctx, tx, bot)
+	})
+	if err != nil {
+		return dtos.Bot{}, fmt.Errorf("failed to create bot: %w", err)
+	}
+
+	bs.logger.Info(ctx, "bot created",
+		option.Any("bot_id", bot.ID.String()),
+		option.Any("owner_id", ownerID.String()),
+	)
+
+	return toBotDto(bot), nil
+}
+
+func (bs *BotService) List(ctx context.Context, ownerID uuid.UUID) ([]dtos.Bot, error) {
+	bots, err := bs.repo.ReadByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bots: %w", err)
+	}
+
+	result := make([]dtos.Bot, 0, len(bots))
+	for _, bot := range bots {
+		result = append(result, toBotDto(bot))
+	}
+
+	return result, nil
+}
+
+// CreateKey issues an API key for the owner's bot. The key is part of the
+// result and can't be read again later.
+func (bs *BotService) CreateKey(ctx context.Context, ownerID uuid.UUID, req dtos.BotKeyRequest) (dtos.BotKey, error) {
+	scopes := make([]entities.BotScope, 0, len(req.Scopes))
+	for _, name := range req.Scopes {
+		scope := entities.BotScope(name)
+		if !scope.Valid() {
+			return dtos.BotKey{}, ErrInvalidBotKeySettings
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	nameLen := utf8.RuneCountInString(req.Name)
+	if len(scopes) == 0 || nameLen == 0 || nameLen > maxBotKeyNameLen ||
+		(req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now())) {
+		return dtos.BotKey{}, ErrInvalidBotKeySettings
+	}
+
+	if _, err := bs.ownedBot(ctx, req.BotID, ownerID); err != nil {
+		return dtos.BotKey{}, err
+	}
+
+	keyID := uuid.New()
+	secret, err := generateBotKeySecret()
+	if err != nil {
+		return dtos.BotKey{}, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := keyID.String() + "." + secret
+
+	keyHash, err := bs.tokenHasher.HashToken(key)
+	if err != nil {
+		return dtos.BotKey{}, fmt.Errorf("failed to hash API key: %w", err)
+	}
+
+	botKey := entities.NewBotKey(keyID, req.BotID, req.Name, keyHash, scopes, req.ExpiresAt)
+	if err = bs.repo.CreateKey(ctx, botKey); err != nil {
+		return dtos.BotKey{}, fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	bs.logger.Info(ctx, "bot API key issued",
+		option.Any("bot_id", req.BotID.String()),
+		option.Any("key_id", keyID.String()),
+		option.Any("owner_id", ownerID.String()),
+	)
+
+	dto := toBotKeyDto(botKey)
+	dto.Key = key
+
+	return dto, nil
+}
+
+func (bs *BotService) ListKeys(ctx context.Context, ownerID uuid.UUID, botID uuid.UUID) ([]dtos.BotKey, error) {
+	if _, err := bs.ownedBot(ctx, botID, ownerID); err != nil {
+		return nil, err
+	}
+
+	keys, err := bs.repo.ReadKeys(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+
+	result := make([]dtos.BotKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, toBotKeyDto(key))
+	}
+
+	return result, nil
+}
+
+// RevokeKey stops the key from working. Revoking it again is a no-op.
+func (bs *BotService) RevokeKey(ctx context.Context, ownerID uuid.UUID, keyID uuid.UUID) error {
+	key, err := bs.repo.ReadKey(ctx, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to read API key: %w", err)
+	}
+	if key == nil {
+		return ErrBotKeyNotFound
+	}
+
+	if _, err = bs.ownedBot(ctx, key.BotID, ownerID); err != nil {
+		if errors.Is(err, ErrBotNotFound) {
+			return ErrBotKeyNotFound
+		}
+		return err
+	}
+
+	if err = bs.repo.RevokeKey(ctx, keyID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	bs.logger.Info(ctx, "bot API key revoked",
+		option.Any("bot_id", key.BotID.String()),
+		option.Any("key_id", keyID.String()),
+		option.Any("owner_id", ownerID.String()),
+	)
+
+	return nil
+}
+
+// Authorize checks the API key and returns the bot it belongs to along with
+// the scopes it grants.
+func (bs *BotService) Authorize(ctx context.Context, key string) (entities.BotIdentity, error) {
+	rawID, _, found := strings.Cut(key, ".")
+	if !found {
+		return entities.BotIdentity{}, ErrBotKeyInvalid
+	}
+	keyID, err := uuid.Parse(rawID)
+	if err != nil {
+		return entities.BotIdentity{}, ErrBotKeyInvalid
+	}
+
+	botKey, err := bs.repo.ReadKey(ctx, keyID)
+	if err != nil {
+		return entities.BotIdentity{}, fmt.Errorf("read API key: %w", err)
+	}
+	now := time.Now()
+	if botKey == nil || !botKey.Usable(now) {
+		return entities.BotIdentity{}, ErrBotKeyInvalid
+	}
+
+	keyHash, err := bs.tokenHasher.HashToken(key)
+	if err != nil {
+		return entities.BotIdentity{}, fmt.Errorf("hash API key: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(keyHash), []byte(botKey.KeyHash)) != 1 {
+		return entities.BotIdentity{}, ErrBotKeyInvalid
+	}
+
+	if err = bs.repo.TouchKey(ctx, keyID, now, botKeyTouchInterval); err != nil {
+		bs.logger.Warn(ctx, "failed to record API key use",
+			option.Any("key_id", keyID.String()),
+			option.Error(err),
+		)
+	}
+
+	return entities.BotIdentity{BotID: botKey.BotID, KeyID: keyID, Scopes: botKey.Scopes}, nil
+}
+
+// ownedBot reads the bot if it belongs to the owner. Bots of other users
+// are reported as missing.
+func (bs *BotService) ownedBot(ctx context.Context, botID, ownerID uuid.UUID) (*entities.Bot, error) {
+	bot, err := bs.repo.ReadByID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bot: %w", err)
+	}
+	if bot == nil || bot.OwnerID != ownerID {
+		return nil, ErrBotNotFound
+	}
+
+	return bot, nil
+}
+
+func generateBotKeySecret() (string, error) {
+	bytes := make([]byte, botKeySecretLen)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func toBotDto(bot entities.Bot) dtos.Bot {
+	return dtos.Bot{
+		ID:        bot.ID,
+		Tag:       bot.Tag,
+		Name:      bot.Name,
+		Desc:      bot.Desc,
+		CreatedAt: bot.CreatedAt,
+	}
+}
+
+func toBotKeyDto(key entities.BotKey) dtos.BotKey {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, s := range key.Scopes {
+		scopes = append(scopes, string(s))
+	}
+
+	return dtos.BotKey{
+		ID:         key.ID,
+		BotID:      key.BotID,
+		Name:       key.Name,
+		Scopes:     scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/pkg/txhelper"
+)
+
+const inviteCodeLen = 12
+
+var (
+	ErrInviteNotFound        = errors.New("invite doesn't exist")
+	ErrInviteExpired         = errors.New("invite is expired, revoked or used up")
+	ErrInvalidInviteSettings = errors.New("invite must expire in the future and allow at least one use")
+)
+
+type ChatInviteRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, invite entities.ChatInvite) error
+	ReadByCode(ctx context.Context, code string) (*entities.ChatInvite, error)
+	ReadActiveByChatID(ctx context.Context, chatID uuid.UUID) ([]entities.ChatInvite, error)
+	Use(ctx context.Context, tx pgx.Tx, id uuid.UUID) (bool, error)
+	Revoke(ctx context.Context, tx pgx.Tx, id uuid.UUID, revokedAt time.Time) error
+	CreateEvent(ctx context.Context, tx pgx.Tx, event entities.ChatInviteEvent) error
+}
+
+// ChatEventPublisher tells chat event handlers about a change made in tx.
+type ChatEventPublisher interface {
+	PublishChatEvent(ctx context.Context, tx pgx.Tx, event entities.ChatEvent) error
+}
+
+type ChatInviteService struct {
+	inviteRepo  ChatInviteRepository
+	chatRepo    ChatRepository
+	permissions ChatPermissionChecker
+	events      ChatEventPublisher
+	txHelper    *txhelper.TxHelper
+	logger      logger.Logger
+}
+
+func NewChatInviteService(inviteRepo ChatInviteRepository, chatRepo ChatRepository,
+	permissions ChatPermissionChecker, events ChatEventPublisher, txHelper *txhelper.TxHelper,
+	logger logger.Logger) *ChatInviteService {
+	return &ChatInviteService{
+		inviteRepo:  inviteRepo,
+		chatRepo:    chatRepo,
+		permissions: permissions,
+		events:      events,
+		txHelper:    txHelper,
+		logger:      logger,
+	}
+}
+
+func (cis *ChatInviteService) Create(ctx context.Context, actorID uuid.UUID,
+	req dtos.ChatInviteRequest) (dtos.ChatInvite, error) {
+	if (req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now())) || (req.MaxUses != nil && *req.MaxUses < 1) {
+		return dtos.ChatInvite{}, ErrInvalidInviteSettings
+	}
+
+	err := cis.permissions.CheckPermission(ctx, req.ChatID, actorID, entities.ChatPermissionManageMembers)
+	if err != nil {
+		return dtos.ChatInvite{}, err
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return dtos.ChatInvite{}, fmt.Errorf("generate invite code: %w", err)
+	}
+
+	invite := entities.NewChatInvite(req.ChatID, code, actorID, req.ExpiresAt, req.MaxUses)
+
+	err = cis.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := cis.inviteRepo.Create(ctx, tx, invite); err != nil {
+			return fmt.Errorf("save invite: %w", err)
+		}
+
+		return cis.inviteRepo.CreateEvent(ctx, tx, entities.NewChatInviteEvent(invite.ID, actorID, entities.ChatInviteCreated))
+	})
+	if err != nil {
+		return dtos.ChatInvite{}, err
+	}
+
+	cis.logger.Info(ctx, "chat invite created",
+		option.Any("invite_id", invite.ID.String()),
+		option.Any("chat_id", invite.ChatID.String()),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return toInviteDto(invite), nil
+}
+
+func (cis *ChatInviteService) ListActive(ctx context.Context, actorID uuid.UUID, chatID uuid.UUID) ([]dtos.ChatInvite, error) {
+	if err := cis.permissions.CheckPermission(ctx, chatID, actorID, entities.ChatPermissionManageMembers); err != nil {
+		return nil, err
+	}
+
+	invites, err := cis.inviteRepo.ReadActiveByChatID(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("read invites: %w", err)
+	}
+
+	result := make([]dtos.ChatInvite, 0, len(invites))
+	for _, invite := range invites {
+		result = append(result, toInviteDto(invite))
+	}
+
+	return result, nil
+}
+
+func (cis *ChatInviteService) Revoke(ctx context.Context, actorID uuid.UUID, code string) error {
+	invite, err := cis.inviteRepo.ReadByCode(ctx, code)
+	if err != nil {
+		return fmt.Errorf("read invite: %w", err)
+	}
+	if invite == nil {
+		return ErrInviteNotFound
+	}
+
+	err = cis.permissions.CheckPermission(ctx, invite.ChatID, actorID, entities.ChatPermissionManageMembers)
+	if err != nil {
+		return err
+	}
+	if invite.RevokedAt != nil {
+		return nil
+	}
+
+	err = cis.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := cis.inviteRepo.Revoke(ctx, tx, invite.ID, time.Now()); err != nil {
+			return fmt.Errorf("revoke invite: %w", err)
+		}
+
+		return cis.inviteRepo.CreateEvent(ctx, tx, entities.NewChatInviteEvent(invite.ID, actorID, entities.ChatInviteRevoked))
+	})
+	if err != nil {
+		return err
+	}
+
+	cis.logger.Info(ctx, "chat invite revoked",
+		option.Any("invite_id", invite.ID.String()),
+		option.Any("chat_id", invite.ChatID.String()),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return nil
+}
+
+// Join adds the user to the chat the invite belongs to as a member.
+func (cis *ChatInviteService) Join(ctx context.Context, userID uuid.UUID, code string) (dtos.ChatResponse, error) {
+	invite, err := cis.inviteRepo.ReadByCode(ctx, code)
+	if err != nil {
+		return dtos.ChatResponse{}, fmt.Errorf("read invite: %w", err)
+	}
+	if invite == nil {
+		return dtos.ChatResponse{}, ErrInviteNotFound
+	}
+	if !invite.Usable(time.Now()) {
+		return dtos.ChatResponse{}, ErrInviteExpired
+	}
+
+	chat, err := cis.chatRepo.ReadByID(ctx, invite.ChatID)
+	if err != nil {
+		return dtos.ChatResponse{}, fmt.Errorf("read chat: %w", err)
+	}
+	if chat == nil {
+		return dtos.ChatResponse{}, ErrChatNotFound
+	}
+
+	role, err := cis.chatRepo.ReadParticipantRole(ctx, invite.ChatID, userID)
+	if err != nil {
+		return dtos.ChatResponse{}, fmt.Errorf("check participation: %w", err)
+	}
+	if role != nil {
+		return dtos.ChatResponse{}, ErrAlreadyChatParticipant
+	}
+
+	err = cis.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		ok, err := cis.inviteRepo.Use(ctx, tx, invite.ID)
+		if err != nil {
+			return fmt.Errorf("use invite: %w", err)
+		}
+		if !ok {
+			return ErrInviteExpired
+		}
+
+		memberRole := entities.ChatRoleMember
+		if err = cis.chatRepo.AddParticipant(ctx, tx, invite.ChatID, userID, memberRole); err != nil {
+			return fmt.Errorf("add participant: %w", err)
+		}
+
+		change := entities.NewChatRoleChange(invite.ChatID, userID, userID, nil, &memberRole)
+		if err = cis.chatRepo.CreateRoleChange(ctx, tx, change); err != nil {
+			return fmt.Errorf("record role change: %w", err)
+		}
+
+		event := entities.NewChatEvent(entities.ChatMemberJoined, invite.ChatID, userID, userID)
+		if err = cis.events.PublishChatEvent(ctx, tx, event); err != nil {
+			return err
+		}
+
+		return cis.inviteRepo.CreateEvent(ctx, tx, entities.NewChatInviteEvent(invite.ID, userID, entities.ChatInviteUsed))
+	})
+	if err != nil {
+		return dtos.ChatResponse{}, err
+	}
+
+	cis.logger.Info(ctx, "chat joined by invite",
+		option.Any("invite_id", invite.ID.String()),
+		option.Any("chat_id", invite.ChatID.String()),
+		option.Any("user_id", userID.String()),
+	)
+
+	return dtos.ChatResponse{Id: chat.Id.String(), Tag: chat.Tag}, nil
+}
+
+func generateInviteCode() (string, error) {
+	bytes := make([]byte, inviteCodeLen)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func toInviteDto(invite entities.ChatInvite) dtos.ChatInvite {
+	return dtos.ChatInvite{
+		Code:      invite.Code,
+		ChatID:    invite.ChatID,
+		CreatedBy: invite.CreatedBy,
+		CreatedAt: invite.CreatedAt,
+		ExpiresAt: invite.ExpiresAt,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+	}
+}
@@ -2,172 +2,975 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/pkg/txhelper"
+)
+
+var (
+	ErrChatNotFound           = errors.New("chat doesn't exist")
+	ErrChatTagTaken           = errors.New("chat with this tag already exists")
+	ErrNotChatParticipant     = errors.New("user is not a participant of the chat")
+	ErrAlreadyChatParticipant = errors.New("user is already a participant of the chat")
+	ErrChatPermissionDenied   = errors.New("not enough rights in the chat")
+	ErrInvalidChatRole        = errors.New("invalid chat role")
+	ErrInvalidChatTag         = errors.New("chat tag must be 3-15 letters, digits, '_' or '.'")
+	ErrDirectChatRestricted   = errors.New("direct chats always have exactly two members and can't be changed")
+	ErrDirectChatWithSelf     = errors.New("can't start a direct chat with yourself")
+	ErrTooManyPins            = errors.New("chat has reached the maximum number of pinned messages")
+	ErrInvalidNotifyLevel     = errors.New("notify level must be 'all' or 'mentions'")
+)
+
+// directChatTagPrefix can't appear in group chat tags, so the internal tags of
+// direct chats never collide with them.
+const (
+	directChatTagPrefix  = "~"
+	directChatTagRandLen = 10
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
 )
 
 type ChatRepository interface {
-	Create(ctx context.Context, chat entities.Chat) error
-	AddParticipant(ctx context.Context, chatID, userID uuid.UUID) error
+	Create(ctx context.Context, tx pgx.Tx, chat entities.Chat) error
+	AddParticipant(ctx context.Context, tx pgx.Tx, chatID, userID uuid.UUID, role entities.ChatRole) error
+	ReadParticipantRole(ctx context.Context, chatID, userID uuid.UUID) (*entities.ChatRole, error)
+	UpdateParticipantRole(ctx context.Context, tx pgx.Tx, chatID, userID uuid.UUID, role entities.ChatRole) error
+	UpdateOwner(ctx context.Context, tx pgx.Tx, chatID, ownerID uuid.UUID) error
+	CreateRoleChange(ctx context.Context, tx pgx.Tx, change entities.ChatRoleChange) error
 	ReadByTag(ctx context.Context, tag string) (*entities.Chat, error)
 	ReadByID(ctx context.Context, id uuid.UUID) (*entities.Chat, error)
-	GetChatsWithLastMessages(ctx context.Context) ([]entities.ChatLastMessages, error)
-	Update(ctx context.Context, chat entities.Chat) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	RemoveParticipant(ctx context.Context, chatID, userID uuid.UUID) error
-	RemoveAllParticipants(ctx context.Context, chatID uuid.UUID) error
+	GetChatsWithLastMessages(ctx context.Context, userID uuid.UUID, archived bool,
+		limit, offset uint64) ([]entities.ChatLastMessages, error)
+	ReadPreferences(ctx context.Context, chatID, userID uuid.UUID) (*entities.ChatPreferences, error)
+	ReadPreferencesByChat(ctx context.Context, chatID uuid.UUID) ([]entities.ChatPreferences, error)
+	UpdatePreferences(ctx context.Context, prefs entities.ChatPreferences) error
+	Update(ctx context.Context, tx pgx.Tx, chat entities.Chat) error
+	Delete(ctx context.Context, tx pgx.Tx, id uuid.UUID) error
+	RemoveParticipant(ctx context.Context, tx pgx.Tx, chatID, userID uuid.UUID) error
+	CreateDirect(ctx context.Context, tx pgx.Tx, chat entities.Chat, pair entities.DirectChat) (bool, error)
+	ReadDirectByUsers(ctx context.Context, userLow, userHigh uuid.UUID) (*entities.Chat, error)
+	ReadDirectPair(ctx context.Context, chatID uuid.UUID) (*entities.DirectChat, error)
+}
+
+type PinnedMessageRepository interface {
+	CountForUpdate(ctx context.Context, tx pgx.Tx, chatID uuid.UUID) (int, error)
+	Exists(ctx context.Context, tx pgx.Tx, chatID, messageID uuid.UUID) (bool, error)
+	Create(ctx context.Context, tx pgx.Tx, pin entities.PinnedMessage) error
+	Delete(ctx context.Context, chatID, messageID uuid.UUID) error
+	ReadByChatID(ctx context.Context, chatID uuid.UUID) ([]entities.PinnedMessage, error)
+}
+
+type MessageReader interface {
+	ReadByID(ctx context.Context, id uuid.UUID) (*entities.Message, error)
+}
+
+type UserProfileReader interface {
+	ReadById(ctx context.Context, accID uuid.UUID) (*entities.UserAccount, error)
+}
+
+// ChatAttachmentCleaner deletes the attachments of a deleted chat: the rows
+// along with the chat, the blobs once that is committed.
+type ChatAttachmentCleaner interface {
+	DeleteChatAttachments(ctx context.Context, tx pgx.Tx, chatID uuid.UUID) ([]entities.Attachment, error)
+	RemoveBlobs(ctx context.Context, attachments []entities.Attachment)
+}
+
+// ChatEventHandler is told about every membership change and rename inside
+// the transaction making it, so it should only record work to be done later.
+type ChatEventHandler interface {
+	HandleChatEvent(ctx context.Context, tx pgx.Tx, event entities.ChatEvent) error
 }
 
 type ChatService struct {
-	chatRepo ChatRepository
+	chatRepo      ChatRepository
+	userRepo      UserProfileReader
+	pinRepo       PinnedMessageRepository
+	msgRepo       MessageReader
+	blocks        BlockChecker
+	attachments   ChatAttachmentCleaner
+	txHelper      *txhelper.TxHelper
+	maxPins       int
+	eventHandlers []ChatEventHandler
+	logger        logger.Logger
 }
 
-func NewChatService(chatRepo ChatRepository) *ChatService {
+// NewChatService creates the service. maxPins caps the number of pinned
+// messages in one chat.
+func NewChatService(chatRepo ChatRepository, userRepo UserProfileReader, pinRepo PinnedMessageRepository,
+	msgRepo MessageReader, blocks BlockChecker, attachments ChatAttachmentCleaner, txHelper *txhelper.TxHelper,
+	maxPins int, logger logger.Logger) *ChatService {
 	return &ChatService{
-		chatRepo: chatRepo,
+		chatRepo:    chatRepo,
+		userRepo:    userRepo,
+		pinRepo:     pinRepo,
+		msgRepo:     msgRepo,
+		blocks:      blocks,
+		attachments: attachments,
+		txHelper:    txHelper,
+		maxPins:     maxPins,
+		logger:      logger,
+	}
+}
+
+// Subscribe adds a handler for chat events. It must be called before the
+// service starts handling requests.
+func (cr *ChatService) Subscribe(handler ChatEventHandler) {
+	cr.eventHandlers = append(cr.eventHandlers, handler)
+}
+
+// PublishChatEvent tells the subscribed handlers about a change made in tx.
+// Other services changing chats publish through it too.
+func (cr *ChatService) PublishChatEvent(ctx context.Context, tx pgx.Tx, event entities.ChatEvent) error {
+	for _, handler := range cr.eventHandlers {
+		if err := handler.HandleChatEvent(ctx, tx, event); err != nil {
+			return fmt.Errorf("failed to handle chat event: %w", err)
+		}
 	}
+
+	return nil
 }
 
-func (cr *ChatService) Create(ctx context.Context, chat dtos.ChatRequest) (dtos.ChatResponse, error) {
-    foundChat, err := cr.chatRepo.ReadByTag(ctx, chat.Tag)
-    if err != nil {
-        return dtos.ChatResponse{}, fmt.Errorf("failed to check existence of chat: %w", err)
-    }
+func (cr *ChatService) Create(ctx context.Context, ownerID uuid.UUID, chat dtos.ChatRequest) (dtos.ChatResponse, error) {
+	tagRe := regexp.MustCompile(`^[a-zA-Z0-9_.]{3,15}$`)
+	if !tagRe.MatchString(chat.Tag) {
+		return dtos.ChatResponse{}, ErrInvalidChatTag
+	}
 
-    if foundChat != nil {
-        return dtos.ChatResponse{}, errors.New("chat with this tag already exists")
-    }
+	foundChat, err := cr.chatRepo.ReadByTag(ctx, chat.Tag)
+	if err != nil {
+		return dtos.ChatResponse{}, fmt.Errorf("failed to check existence of chat: %w", err)
+	}
 
-    chatFinal := entities.NewChat(
-        chat.Tag,
-        chat.OwnerId,
-        chat.Title,
-    )
+	if foundChat != nil {
+		return dtos.ChatResponse{}, ErrChatTagTaken
+	}
 
-    if err := cr.chatRepo.Create(ctx, chatFinal); err != nil {
-        return dtos.ChatResponse{}, fmt.Errorf("failed to create the chat: %w", err)
-    }
+	chatFinal := entities.NewChat(
+		chat.Tag,
+		ownerID,
+		chat.Title,
+	)
+
+	err = cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := cr.chatRepo.Create(ctx, tx, chatFinal); err != nil {
+			return fmt.Errorf("failed to create the chat: %w", err)
+		}
+		if err := cr.addParticipant(ctx, tx, chatFinal.Id, ownerID, ownerID, entities.ChatRoleOwner); err != nil {
+			return fmt.Errorf("failed to add owner as participant: %w", err)
+		}
 
-    if err := cr.chatRepo.AddParticipant(ctx, chatFinal.Id, chatFinal.OwnerId); err != nil {
-        return dtos.ChatResponse{}, fmt.Errorf("failed to add owner as participant: %w", err)
-    }
+		return nil
+	})
+	if err != nil {
+		return dtos.ChatResponse{}, err
+	}
 
-    return dtos.ChatResponse{Id: chatFinal.Id.String(), Tag: chatFinal.Tag}, nil
+	return dtos.ChatResponse{Id: chatFinal.Id.String(), Tag: chatFinal.Tag}, nil
 }
 
-func (cr *ChatService) AddParticipant(ctx context.Context, participation dtos.ChatParticipation) error {
-    foundChat, err := cr.chatRepo.ReadByID(ctx, participation.ChatID)
-    if err != nil {
-        return fmt.Errorf("failed to check existence of chat: %w", err)
-    }
-    if foundChat == nil {
-        return errors.New("chat doesn't exist")
-    }
+func (cr *ChatService) AddParticipant(ctx context.Context, actorID uuid.UUID, participation dtos.ChatParticipation) error {
+	role := entities.ChatRoleMember
+	if participation.Role != "" {
+		role = entities.ChatRole(participation.Role)
+	}
+	if !role.Valid() || role == entities.ChatRoleOwner {
+		return ErrInvalidChatRole
+	}
 
-    return cr.chatRepo.AddParticipant(ctx, participation.ChatID, participation.UserID)
+	actorRole, err := cr.requirePermission(ctx, participation.ChatID, actorID, entities.ChatPermissionManageMembers)
+	if err != nil {
+		return err
+	}
+	if !actorRole.Outranks(role) {
+		return ErrChatPermissionDenied
+	}
+	if err = cr.rejectDirect(ctx, participation.ChatID); err != nil {
+		return err
+	}
+
+	existingRole, err := cr.chatRepo.ReadParticipantRole(ctx, participation.ChatID, participation.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check participation: %w", err)
+	}
+	if existingRole != nil {
+		return ErrAlreadyChatParticipant
+	}
+	if err = cr.rejectBlocked(ctx, participation.UserID, actorID); err != nil {
+		return err
+	}
+
+	return cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		return cr.addParticipant(ctx, tx, participation.ChatID, participation.UserID, actorID, role)
+	})
 }
 
+// GetByTag looks up a group chat. Direct chats are never found by tag.
 func (cr *ChatService) GetByTag(ctx context.Context, tag string) (dtos.ChatRequest, error) {
 	foundChat, err := cr.chatRepo.ReadByTag(ctx, tag)
 	if err != nil {
 		return dtos.ChatRequest{}, fmt.Errorf("failed to retrieve chat information: %w", err)
 	}
+	if foundChat == nil || foundChat.Kind == entities.ChatKindDirect {
+		return dtos.ChatRequest{}, ErrChatNotFound
+	}
 
 	chat := dtos.ChatRequest{
 		Tag:     foundChat.Tag,
 		OwnerId: foundChat.OwnerId,
 		Title:   foundChat.Title,
+		Kind:    string(foundChat.Kind),
 	}
 
 	return chat, nil
 }
 
-func (cr *ChatService) GetByID(ctx context.Context, id uuid.UUID) (dtos.ChatRequest, error) {
+// GetByID returns the chat as the viewer sees it. A direct chat is visible to
+// its two users only and is titled after the other one.
+func (cr *ChatService) GetByID(ctx context.Context, viewerID uuid.UUID, id uuid.UUID) (dtos.ChatRequest, error) {
 	foundChat, err := cr.chatRepo.ReadByID(ctx, id)
 	if err != nil {
 		return dtos.ChatRequest{}, fmt.Errorf("failed to retrieve chat information: %w", err)
 	}
+	if foundChat == nil {
+		return dtos.ChatRequest{}, ErrChatNotFound
+	}
 
 	chat := dtos.ChatRequest{
 		Tag:     foundChat.Tag,
 		OwnerId: foundChat.OwnerId,
 		Title:   foundChat.Title,
+		Kind:    string(foundChat.Kind),
+	}
+
+	if foundChat.Kind == entities.ChatKindDirect {
+		pair, err := cr.chatRepo.ReadDirectPair(ctx, id)
+		if err != nil {
+			return dtos.ChatRequest{}, fmt.Errorf("failed to read direct chat users: %w", err)
+		}
+		if pair == nil || !pair.Has(viewerID) {
+			return dtos.ChatRequest{}, ErrChatNotFound
+		}
+
+		chat.Title, err = cr.directChatTitle(ctx, pair.Other(viewerID))
+		if err != nil {
+			return dtos.ChatRequest{}, err
+		}
 	}
 
 	return chat, nil
 }
 
-func (cr *ChatService) GetChatsWithLastMessages(ctx context.Context) ([]dtos.ChatLastMessages, error) {
-    entitiesMsgs, err := cr.chatRepo.GetChatsWithLastMessages(ctx)
-    if err != nil {
-        return nil, fmt.Errorf("failed to get chats with last messages: %w", err)
-    }
-
-    result := make([]dtos.ChatLastMessages, 0, len(entitiesMsgs))
-    for _, m := range entitiesMsgs {
-		hhmm := time.UnixMilli(m.Timestamp).Format("15:04")
-		
-        result = append(result, dtos.ChatLastMessages{
-            ChatID:    m.ChatID,
-            ID:        m.ID,
-            UserID:    m.UserID,
-            Message:   m.Message,
-            Timestamp: hhmm,
-        })
-    }
-	
-    return result, nil
-}
-
-func (cr *ChatService) Update(ctx context.Context, chat dtos.ChatRequest) error {
+// OpenDirect returns the direct chat between the two users, creating it on
+// first use. Concurrent calls for the same pair end up with the same chat. A
+// user blocked by the other one can't open it.
+func (cr *ChatService) OpenDirect(ctx context.Context, userID uuid.UUID, otherID uuid.UUID) (dtos.DirectChatResponse, error) {
+	if userID == otherID {
+		return dtos.DirectChatResponse{}, ErrDirectChatWithSelf
+	}
+	if err := cr.rejectBlocked(ctx, otherID, userID); err != nil {
+		return dtos.DirectChatResponse{}, err
+	}
+
+	title, err := cr.directChatTitle(ctx, otherID)
+	if err != nil {
+		return dtos.DirectChatResponse{}, err
+	}
+
+	low, high := entities.OrderUserPair(userID, otherID)
+
+	chat, err := cr.chatRepo.ReadDirectByUsers(ctx, low, high)
+	if err != nil {
+		return dtos.DirectChatResponse{}, fmt.Errorf("failed to check existence of direct chat: %w", err)
+	}
+
+	if chat == nil {
+		chat, err = cr.createDirect(ctx, userID, otherID)
+		if err != nil {
+			return dtos.DirectChatResponse{}, err
+		}
+	}
+
+	return dtos.DirectChatResponse{Id: chat.Id.String(), Tag: chat.Tag, Title: title, UserID: otherID}, nil
+}
+
+// GetChatsWithLastMessages returns the user's inbox page, most recently active
+// chats first. Archived chats are listed only when archived is set, and then
+// alone.
+func (cr *ChatService) GetChatsWithLastMessages(ctx context.Context, userID uuid.UUID, archived bool,
+	limit, offset int) (dtos.ChatLastMessagesResponse, error) {
+	limit, offset = normalizePage(limit, offset)
+
+	entitiesMsgs, err := cr.chatRepo.GetChatsWithLastMessages(ctx, userID, archived, uint64(limit), uint64(offset))
+	if err != nil {
+		return dtos.ChatLastMessagesResponse{}, fmt.Errorf("failed to get chats with last messages: %w", err)
+	}
+
+	now := time.Now()
+	result := make([]dtos.ChatLastMessages, 0, len(entitiesMsgs))
+	for _, m := range entitiesMsgs {
+		prefs := entities.ChatPreferences{MutedUntil: m.MutedUntil, NotifyLevel: m.NotifyLevel, Archived: m.Archived}
+
+		entry := dtos.ChatLastMessages{
+			ChatID:      m.ChatID,
+			ChatTag:     m.ChatTag,
+			Title:       m.Title,
+			Kind:        string(m.Kind),
+			Role:        string(m.Role),
+			UnreadCount: m.UnreadCount,
+			LastReadAt:  m.LastReadAt,
+			Muted:       prefs.Muted(now),
+			NotifyLevel: string(m.NotifyLevel),
+			Archived:    m.Archived,
+			Timestamp:   m.ActivityAt,
+		}
+		if entry.Muted {
+			entry.MutedUntil = m.MutedUntil
+		}
+		if m.LastMessage != nil {
+			entry.ID = &m.LastMessage.ID
+			entry.UserID = &m.LastMessage.UserID
+			entry.Message = m.LastMessage.Content
+		}
+
+		result = append(result, entry)
+	}
+
+	return dtos.ChatLastMessagesResponse{Info: result, Limit: limit, Offset: offset}, nil
+}
+
+// normalizePage replaces a missing limit and a negative offset with
+// defaults and caps a too large limit at maxPageSize.
+func normalizePage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+	if offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
+
+// GetPreferences returns the participant's own settings for the chat.
+func (cr *ChatService) GetPreferences(ctx context.Context, userID uuid.UUID, chatID uuid.UUID) (dtos.ChatPreferences, error) {
+	prefs, err := cr.chatRepo.ReadPreferences(ctx, chatID, userID)
+	if err != nil {
+		return dtos.ChatPreferences{}, fmt.Errorf("failed to read chat preferences: %w", err)
+	}
+	if prefs == nil {
+		return dtos.ChatPreferences{}, ErrNotChatParticipant
+	}
+
+	resp := dtos.ChatPreferences{
+		ChatID:      chatID,
+		NotifyLevel: string(prefs.NotifyLevel),
+		Archived:    prefs.Archived,
+	}
+	if prefs.Muted(time.Now()) {
+		resp.MutedUntil = prefs.MutedUntil
+	}
+
+	return resp, nil
+}
+
+// UpdatePreferences replaces the participant's settings for the chat. An
+// empty notify level means all messages.
+func (cr *ChatService) UpdatePreferences(ctx context.Context, userID uuid.UUID, req dtos.ChatPreferences) error {
+	level := entities.NotifyAll
+	if req.NotifyLevel != "" {
+		level = entities.NotifyLevel(req.NotifyLevel)
+	}
+	if !level.Valid() {
+		return ErrInvalidNotifyLevel
+	}
+
+	existing, err := cr.chatRepo.ReadPreferences(ctx, req.ChatID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to read chat preferences: %w", err)
+	}
+	if existing == nil {
+		return ErrNotChatParticipant
+	}
+
+	prefs := entities.ChatPreferences{
+		ChatID:      req.ChatID,
+		UserID:      userID,
+		MutedUntil:  req.MutedUntil,
+		NotifyLevel: level,
+		Archived:    req.Archived,
+	}
+	if err = cr.chatRepo.UpdatePreferences(ctx, prefs); err != nil {
+		return fmt.Errorf("failed to update chat preferences: %w", err)
+	}
+
+	return nil
+}
+
+// NotificationRecipients returns the participants of the chat to notify of a
+// new message by authorID: everyone but the author whose preferences let the
+// message through and who hasn't blocked the author. mentioned lists the
+// users the message mentions.
+func (cr *ChatService) NotificationRecipients(ctx context.Context, chatID, authorID uuid.UUID,
+	mentioned []uuid.UUID) ([]uuid.UUID, error) {
+	all, err := cr.chatRepo.ReadPreferencesByChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat preferences: %w", err)
+	}
+
+	isMentioned := make(map[uuid.UUID]bool, len(mentioned))
+	for _, id := range mentioned {
+		isMentioned[id] = true
+	}
+
+	now := time.Now()
+	candidates := make([]uuid.UUID, 0, len(all))
+	for _, prefs := range all {
+		if prefs.UserID != authorID && prefs.Notifies(now, isMentioned[prefs.UserID]) {
+			candidates = append(candidates, prefs.UserID)
+		}
+	}
+
+	blockers, err := cr.blocks.ReadBlockers(ctx, authorID, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check block lists: %w", err)
+	}
+	blocked := make(map[uuid.UUID]bool, len(blockers))
+	for _, id := range blockers {
+		blocked[id] = true
+	}
+
+	recipients := make([]uuid.UUID, 0, len(candidates))
+	for _, id := range candidates {
+		if !blocked[id] {
+			recipients = append(recipients, id)
+		}
+	}
+
+	return recipients, nil
+}
+
+// Update renames the chat. Ownership is changed with TransferOwnership only.
+func (cr *ChatService) Update(ctx context.Context, actorID uuid.UUID, chat dtos.ChatRequest) error {
 	foundChat, err := cr.chatRepo.ReadByTag(ctx, chat.Tag)
 	if err != nil {
 		return fmt.Errorf("failed to check existence of chat: %w", err)
 	}
 
 	if foundChat == nil {
-		return errors.New("chat doesn't exist")
+		return ErrChatNotFound
 	}
 
-	chatFinal := entities.NewChat(
-		chat.Tag,
-		chat.OwnerId,
-		chat.Title,
-	)
+	if foundChat.Kind == entities.ChatKindDirect {
+		return ErrDirectChatRestricted
+	}
 
-	return cr.chatRepo.Update(ctx, chatFinal)
+	if _, err = cr.requirePermission(ctx, foundChat.Id, actorID, entities.ChatPermissionRename); err != nil {
+		return err
+	}
+
+	if foundChat.Title == chat.Title {
+		return nil
+	}
+	foundChat.Title = chat.Title
+
+	return cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := cr.chatRepo.Update(ctx, tx, *foundChat); err != nil {
+			return fmt.Errorf("failed to update chat: %w", err)
+		}
+
+		event := entities.NewChatEvent(entities.ChatRenamed, foundChat.Id, uuid.Nil, actorID)
+		event.Title = foundChat.Title
+
+		return cr.PublishChatEvent(ctx, tx, event)
+	})
 }
 
-func (cr *ChatService) Delete(ctx context.Context, id uuid.UUID) error {
+// Delete deletes the chat with its participants, messages and attachments
+// in one transaction. The attachments' blobs are removed once it commits.
+func (cr *ChatService) Delete(ctx context.Context, actorID uuid.UUID, id uuid.UUID) error {
 	foundChat, err := cr.chatRepo.ReadByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to check existence of chat: %w", err)
 	}
 
 	if foundChat == nil {
-		return errors.New("chat doesn't exist")
+		return ErrChatNotFound
 	}
 
-	if err := cr.chatRepo.Delete(ctx, id); err != nil {
-		return fmt.Errorf("failed to delete chat: %w", err)
+	if _, err = cr.requirePermission(ctx, id, actorID, entities.ChatPermissionDeleteChat); err != nil {
+		return err
+	}
+
+	var attachments []entities.Attachment
+	err = cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		attachments, err = cr.attachments.DeleteChatAttachments(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete attachments: %w", err)
+		}
+		if err = cr.chatRepo.Delete(ctx, tx, id); err != nil {
+			return fmt.Errorf("failed to delete chat: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	cr.attachments.RemoveBlobs(ctx, attachments)
 
-	return cr.chatRepo.RemoveAllParticipants(ctx, id)
+	cr.logger.Info(ctx, "chat deleted", option.Any("chat_id", id.String()), option.Any("actor_id", actorID.String()))
+
+	return nil
+}
+
+// RemoveParticipant removes a participant on behalf of the actor. Any
+// participant but the owner may remove themselves; removing someone else
+// requires outranking them.
+func (cr *ChatService) RemoveParticipant(ctx context.Context, actorID uuid.UUID, participation dtos.ChatParticipation) error {
+	targetRole, err := cr.chatRepo.ReadParticipantRole(ctx, participation.ChatID, participation.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check participation: %w", err)
+	}
+	if targetRole == nil {
+		return ErrNotChatParticipant
+	}
+	if err = cr.rejectDirect(ctx, participation.ChatID); err != nil {
+		return err
+	}
+
+	if actorID == participation.UserID {
+		if *targetRole == entities.ChatRoleOwner {
+			return ErrChatPermissionDenied
+		}
+	} else {
+		actorRole, err := cr.requirePermission(ctx, participation.ChatID, actorID, entities.ChatPermissionManageMembers)
+		if err != nil {
+			return err
+		}
+		if !actorRole.Outranks(*targetRole) {
+			return ErrChatPermissionDenied
+		}
+	}
+
+	return cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := cr.chatRepo.RemoveParticipant(ctx, tx, participation.ChatID, participation.UserID); err != nil {
+			return fmt.Errorf("failed to remove participant: %w", err)
+		}
+
+		change := entities.NewChatRoleChange(participation.ChatID, participation.UserID, actorID, targetRole, nil)
+		if err := cr.chatRepo.CreateRoleChange(ctx, tx, change); err != nil {
+			return fmt.Errorf("failed to record role change: %w", err)
+		}
+
+		event := entities.NewChatEvent(entities.ChatMemberLeft, participation.ChatID, participation.UserID, actorID)
+
+		return cr.PublishChatEvent(ctx, tx, event)
+	})
 }
 
-func (cr *ChatService) RemoveParticipant(ctx context.Context, participation dtos.ChatParticipation) error {
-    foundChat, err := cr.chatRepo.ReadByID(ctx, participation.ChatID)
-    if err != nil {
-        return fmt.Errorf("failed to check existence of chat: %w", err)
-    }
-    if foundChat == nil {
-        return errors.New("chat doesn't exist")
-    }
+// ChangeRole promotes or demotes a participant. The actor must outrank both
+// the participant's current role and the new one, so only the owner manages
+// admins.
+func (cr *ChatService) ChangeRole(ctx context.Context, actorID uuid.UUID, participation dtos.ChatParticipation) error {
+	newRole := entities.ChatRole(participation.Role)
+	if !newRole.Valid() || newRole == entities.ChatRoleOwner {
+		return ErrInvalidChatRole
+	}
 
-    return cr.chatRepo.RemoveParticipant(ctx, participation.ChatID, participation.UserID)
-}
\ No newline at end of file
+	actorRole, err := cr.requirePermission(ctx, participation.ChatID, actorID, entities.ChatPermissionManageMembers)
+	if err != nil {
+		return err
+	}
+
+	oldRole, err := cr.chatRepo.ReadParticipantRole(ctx, participation.ChatID, participation.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check participation: %w", err)
+	}
+	if oldRole == nil {
+		return ErrNotChatParticipant
+	}
+
+	if !actorRole.Outranks(*oldRole) || !actorRole.Outranks(newRole) {
+		return ErrChatPermissionDenied
+	}
+	if *oldRole == newRole {
+		return nil
+	}
+	if err = cr.rejectDirect(ctx, participation.ChatID); err != nil {
+		return err
+	}
+
+	return cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		return cr.changeRole(ctx, tx, participation.ChatID, participation.UserID, actorID, *oldRole, newRole)
+	})
+}
+
+// TransferOwnership makes another participant the owner. The previous owner
+// stays in the chat as an admin.
+func (cr *ChatService) TransferOwnership(ctx context.Context, actorID uuid.UUID, participation dtos.ChatParticipation) error {
+	if actorID == participation.UserID {
+		return nil
+	}
+
+	if _, err := cr.requirePermission(ctx, participation.ChatID, actorID, entities.ChatPermissionTransferOwnership); err != nil {
+		return err
+	}
+
+	newOwnerRole, err := cr.chatRepo.ReadParticipantRole(ctx, participation.ChatID, participation.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check participation: %w", err)
+	}
+	if newOwnerRole == nil {
+		return ErrNotChatParticipant
+	}
+
+	return cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		err := cr.changeRole(ctx, tx, participation.ChatID, participation.UserID, actorID, *newOwnerRole, entities.ChatRoleOwner)
+		if err != nil {
+			return err
+		}
+
+		err = cr.changeRole(ctx, tx, participation.ChatID, actorID, actorID, entities.ChatRoleOwner, entities.ChatRoleAdmin)
+		if err != nil {
+			return err
+		}
+
+		if err = cr.chatRepo.UpdateOwner(ctx, tx, participation.ChatID, participation.UserID); err != nil {
+			return fmt.Errorf("failed to update chat owner: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Pin pins a message of the chat. Pinning an already pinned message is a
+// no-op.
+func (cr *ChatService) Pin(ctx context.Context, actorID uuid.UUID, req dtos.PinRequest) error {
+	if err := cr.CheckPermission(ctx, req.ChatID, actorID, entities.ChatPermissionPinMessages); err != nil {
+		return err
+	}
+
+	msg, err := cr.chatMessage(ctx, req.ChatID, req.MessageID)
+	if err != nil {
+		return err
+	}
+	if msg.DeletedAt != nil {
+		return ErrMessageDeleted
+	}
+
+	err = cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		count, err := cr.pinRepo.CountForUpdate(ctx, tx, req.ChatID)
+		if err != nil {
+			return fmt.Errorf("failed to count pins: %w", err)
+		}
+
+		pinned, err := cr.pinRepo.Exists(ctx, tx, req.ChatID, req.MessageID)
+		if err != nil {
+			return fmt.Errorf("failed to check pin: %w", err)
+		}
+		if pinned {
+			return nil
+		}
+		if count >= cr.maxPins {
+			return ErrTooManyPins
+		}
+
+		return cr.pinRepo.Create(ctx, tx, entities.NewPinnedMessage(req.ChatID, *msg, actorID))
+	})
+	if err != nil {
+		return err
+	}
+
+	cr.logger.Info(ctx, "message pinned",
+		option.Any("chat_id", req.ChatID.String()),
+		option.Any("message_id", req.MessageID.String()),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return nil
+}
+
+func (cr *ChatService) Unpin(ctx context.Context, actorID uuid.UUID, req dtos.PinRequest) error {
+	if err := cr.CheckPermission(ctx, req.ChatID, actorID, entities.ChatPermissionPinMessages); err != nil {
+		return err
+	}
+
+	if err := cr.pinRepo.Delete(ctx, req.ChatID, req.MessageID); err != nil {
+		return fmt.Errorf("failed to unpin message: %w", err)
+	}
+
+	cr.logger.Info(ctx, "message unpinned",
+		option.Any("chat_id", req.ChatID.String()),
+		option.Any("message_id", req.MessageID.String()),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return nil
+}
+
+// GetPinned lists the chat's pinned messages in the order they were pinned.
+func (cr *ChatService) GetPinned(ctx context.Context, viewerID uuid.UUID, chatID uuid.UUID) (dtos.PinnedMessagesResponse, error) {
+	if err := cr.CheckPermission(ctx, chatID, viewerID, entities.ChatPermissionReadMessages); err != nil {
+		return dtos.PinnedMessagesResponse{}, err
+	}
+
+	pins, err := cr.pinRepo.ReadByChatID(ctx, chatID)
+	if err != nil {
+		return dtos.PinnedMessagesResponse{}, fmt.Errorf("failed to read pinned messages: %w", err)
+	}
+
+	resp := dtos.PinnedMessagesResponse{
+		ChatID: chatID,
+		Pins:   make([]dtos.PinnedMessage, 0, len(pins)),
+	}
+	for _, pin := range pins {
+		resp.Pins = append(resp.Pins, dtos.PinnedMessage{
+			Message:  toMessageDto(&pin.Message),
+			PinnedBy: pin.PinnedBy,
+			PinnedAt: pin.PinnedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+// chatMessage reads a message, making sure it belongs to the chat.
+func (cr *ChatService) chatMessage(ctx context.Context, chatID, messageID uuid.UUID) (*entities.Message, error) {
+	chat, err := cr.chatRepo.ReadByID(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existence of chat: %w", err)
+	}
+	if chat == nil {
+		return nil, ErrChatNotFound
+	}
+
+	msg, err := cr.msgRepo.ReadByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve message: %w", err)
+	}
+	if msg == nil || msg.ChatTag != chat.Tag {
+		return nil, ErrMessageNotFound
+	}
+
+	return msg, nil
+}
+
+// CheckPermission returns nil if the user participates in the chat with a role
+// granting the permission.
+func (cr *ChatService) CheckPermission(ctx context.Context, chatID, userID uuid.UUID,
+	permission entities.ChatPermission) error {
+	_, err := cr.requirePermission(ctx, chatID, userID, permission)
+
+	return err
+}
+
+// CheckPermissionByTag is CheckPermission for a chat referenced by tag.
+func (cr *ChatService) CheckPermissionByTag(ctx context.Context, chatTag string, userID uuid.UUID,
+	permission entities.ChatPermission) error {
+	foundChat, err := cr.chatRepo.ReadByTag(ctx, chatTag)
+	if err != nil {
+		return fmt.Errorf("failed to check existence of chat: %w", err)
+	}
+	if foundChat == nil {
+		return ErrChatNotFound
+	}
+
+	_, err = cr.requirePermission(ctx, foundChat.Id, userID, permission)
+
+	return err
+}
+
+// CheckDirectBlockByTag returns ErrBlockedByUser if the chat is a direct chat
+// whose other user has blocked userID. Group chats always pass.
+func (cr *ChatService) CheckDirectBlockByTag(ctx context.Context, chatTag string, userID uuid.UUID) error {
+	foundChat, err := cr.chatRepo.ReadByTag(ctx, chatTag)
+	if err != nil {
+		return fmt.Errorf("failed to check existence of chat: %w", err)
+	}
+	if foundChat == nil {
+		return ErrChatNotFound
+	}
+	if foundChat.Kind != entities.ChatKindDirect {
+		return nil
+	}
+
+	pair, err := cr.chatRepo.ReadDirectPair(ctx, foundChat.Id)
+	if err != nil {
+		return fmt.Errorf("failed to read direct chat users: %w", err)
+	}
+	if pair == nil || !pair.Has(userID) {
+		return ErrChatNotFound
+	}
+
+	return cr.rejectBlocked(ctx, pair.Other(userID), userID)
+}
+
+func (cr *ChatService) rejectDirect(ctx context.Context, chatID uuid.UUID) error {
+	chat, err := cr.chatRepo.ReadByID(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to check existence of chat: %w", err)
+	}
+	if chat == nil {
+		return ErrChatNotFound
+	}
+	if chat.Kind == entities.ChatKindDirect {
+		return ErrDirectChatRestricted
+	}
+
+	return nil
+}
+
+// rejectBlocked returns ErrBlockedByUser if userID has blocked actorID.
+func (cr *ChatService) rejectBlocked(ctx context.Context, userID, actorID uuid.UUID) error {
+	blocked, err := cr.blocks.IsBlocked(ctx, userID, actorID)
+	if err != nil {
+		return fmt.Errorf("failed to check block list: %w", err)
+	}
+	if blocked {
+		return ErrBlockedByUser
+	}
+
+	return nil
+}
+
+func (cr *ChatService) directChatTitle(ctx context.Context, otherID uuid.UUID) (string, error) {
+	other, err := cr.userRepo.ReadById(ctx, otherID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read user profile: %w", err)
+	}
+	if other == nil {
+		return "", ErrNoAccountFound
+	}
+	if other.Name != "" {
+		return other.Name, nil
+	}
+
+	return other.Tag, nil
+}
+
+func (cr *ChatService) createDirect(ctx context.Context, userID, otherID uuid.UUID) (*entities.Chat, error) {
+	tag, err := generateDirectChatTag()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate direct chat tag: %w", err)
+	}
+
+	chat := entities.NewDirectChat(tag, userID)
+	pair := entities.NewDirectChatPair(chat.Id, userID, otherID)
+	errPairTaken := errors.New("direct chat already exists")
+
+	err = cr.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		created, err := cr.chatRepo.CreateDirect(ctx, tx, chat, pair)
+		if err != nil {
+			return fmt.Errorf("failed to create direct chat: %w", err)
+		}
+		if !created {
+			return errPairTaken
+		}
+
+		member := entities.ChatRoleMember
+		for _, id := range []uuid.UUID{userID, otherID} {
+			if err = cr.chatRepo.AddParticipant(ctx, tx, chat.Id, id, member); err != nil {
+				return fmt.Errorf("failed to add participant: %w", err)
+			}
+
+			change := entities.NewChatRoleChange(chat.Id, id, userID, nil, &member)
+			if err = cr.chatRepo.CreateRoleChange(ctx, tx, change); err != nil {
+				return fmt.Errorf("failed to record role change: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, errPairTaken) {
+		existing, err := cr.chatRepo.ReadDirectByUsers(ctx, pair.UserLow, pair.UserHigh)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read direct chat: %w", err)
+		}
+		if existing == nil {
+			return nil, ErrChatNotFound
+		}
+
+		return existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &chat, nil
+}
+
+func generateDirectChatTag() (string, error) {
+	bytes := make([]byte, directChatTagRandLen)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return directChatTagPrefix + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func (cr *ChatService) requirePermission(ctx context.Context, chatID, userID uuid.UUID,
+	permission entities.ChatPermission) (entities.ChatRole, error) {
+	role, err := cr.chatRepo.ReadParticipantRole(ctx, chatID, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to check participation: %w", err)
+	}
+	if role == nil {
+		return "", ErrNotChatParticipant
+	}
+	if !role.Can(permission) {
+		return "", ErrChatPermissionDenied
+	}
+
+	return *role, nil
+}
+
+func (cr *ChatService) addParticipant(ctx context.Context, tx pgx.Tx, chatID, userID, actorID uuid.UUID,
+	role entities.ChatRole) error {
+	if err := cr.chatRepo.AddParticipant(ctx, tx, chatID, userID, role); err != nil {
+		return fmt.Errorf("failed to add participant: %w", err)
+	}
+
+	change := entities.NewChatRoleChange(chatID, userID, actorID, nil, &role)
+	if err := cr.chatRepo.CreateRoleChange(ctx, tx, change); err != nil {
+		return fmt.Errorf("failed to record role change: %w", err)
+	}
+
+	return cr.PublishChatEvent(ctx, tx, entities.NewChatEvent(entities.ChatMemberJoined, chatID, userID, actorID))
+}
+
+func (cr *ChatService) changeRole(ctx context.Context, tx pgx.Tx, chatID, userID, actorID uuid.UUID,
+	oldRole, newRole entities.ChatRole) error {
+	if err := cr.chatRepo.UpdateParticipantRole(ctx, tx, chatID, userID, newRole); err != nil {
+		return fmt.Errorf("failed to update participant role: %w", err)
+	}
+
+	change := entities.NewChatRoleChange(chatID, userID, actorID, &oldRole, &newRole)
+	if err := cr.chatRepo.CreateRoleChange(ctx, tx, change); err != nil {
+		return fmt.Errorf("failed to record role change: %w", err)
+	}
+
+	cr.logger.Info(ctx, "chat role changed",
+		option.Any("chat_id", chatID.String()),
+		option.Any("user_id", userID.String()),
+		option.Any("actor_id", actorID.String()),
+		option.Any("old_role", string(oldRole)),
+		option.Any("new_role", string(newRole)),
+	)
+
+	return nil
+}
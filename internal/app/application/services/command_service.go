@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	maxCommandDescLen = 256
+	// commandAnswerWindow is how long a bot has to pick up and answer a
+	// command.
+	commandAnswerWindow = 15 * time.Minute
+	// commandReplyTTL is how long ephemeral replies can be read back.
+	commandReplyTTL = 24 * time.Hour
+)
+
+var (
+	ErrInvalidCommandName = errors.New("command name must be 1-32 lower case letters, digits or '_' " +
+		"and not a built-in command")
+	ErrInvalidCommandDesc  = errors.New("command description must be at most 256 characters long")
+	ErrCommandNotFound     = errors.New("command doesn't exist")
+	ErrCommandTaken        = errors.New("command is already registered in the chat")
+	ErrCommandBotNotInChat = errors.New("bot must be a participant of the chat allowed to post")
+	ErrInvocationNotFound  = errors.New("command invocation doesn't exist")
+	ErrInvocationExpired   = errors.New("command invocation can no longer be answered")
+)
+
+type CommandRepository interface {
+	Create(ctx context.Context, cmd entities.ChatCommand) error
+	ReadCommand(ctx context.Context, chatID uuid.UUID, name string) (*entities.ChatCommand, error)
+	ReadCommands(ctx context.Context, chatID uuid.UUID) ([]entities.ChatCommand, error)
+	Delete(ctx context.Context, chatID uuid.UUID, name string) error
+	CreateInvocation(ctx context.Context, inv entities.CommandInvocation) error
+	ReadInvocation(ctx context.Context, id uuid.UUID) (*entities.CommandInvocation, error)
+	ClaimInvocations(ctx context.Context, botID uuid.UUID, since time.Time,
+		limit uint64) ([]entities.CommandInvocation, error)
+	CreateReply(ctx context.Context, reply entities.CommandReply) error
+	ReadReplies(ctx context.Context, userID, chatID uuid.UUID, since time.Time) ([]entities.CommandReply, error)
+}
+
+type CommandChatReader interface {
+	ChatTagReader
+	ChatByIDReader
+}
+
+type BotByIDReader interface {
+	ReadByID(ctx context.Context, id uuid.UUID) (*entities.Bot, error)
+}
+
+type ChatRenamer interface {
+	Update(ctx context.Context, actorID uuid.UUID, chat dtos.ChatRequest) error
+}
+
+type MessagePoster interface {
+	Create(ctx context.Context, authorID uuid.UUID, msg dtos.Message) (*dtos.CommandResult, error)
+}
+
+// CommandService handles slash commands. Every chat has the built-in /help
+// and /topic, the latter showing or changing the chat's title; chat admins
+// register further commands for bots in the chat. A bot claims the commands
+// sent to it and answers each with a message or with an ephemeral reply only
+// the invoker sees.
+type CommandService struct {
+	repo        CommandRepository
+	chats       CommandChatReader
+	bots        BotByIDReader
+	permissions ChatPermissionChecker
+	renamer     ChatRenamer
+	messages    MessagePoster
+	logger      logger.Logger
+}
+
+func NewCommandService(repo CommandRepository, chats CommandChatReader, bots BotByIDReader,
+	permissions ChatPermissionChecker, renamer ChatRenamer, messages MessagePoster,
+	logger logger.Logger) *CommandService {
+	return &CommandService{
+		repo:        repo,
+		chats:       chats,
+		bots:        bots,
+		permissions: permissions,
+		renamer:     renamer,
+		messages:    messages,
+		logger:      logger,
+	}
+}
+
+// Route handles the message if it is a command, returning nil otherwise.
+// The author must already be allowed to post in the chat. Commands sent by
+// bots are left as text so bots can't set each other off, and so are
+// commands neither built in nor registered in the chat, e.g. a message
+// starting with a path.
+func (cs *CommandService) Route(ctx context.Context, authorID uuid.UUID, msg dtos.Message) (*dtos.CommandResult, error) {
+	name, args, ok := entities.ParseCommand(msg.Content)
+	if !ok {
+		return nil, nil
+	}
+
+	bot, err := cs.bots.ReadByID(ctx, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether author is a bot: %w", err)
+	}
+	if bot != nil {
+		return nil, nil
+	}
+
+	chat, err := cs.chats.ReadByTag(ctx, msg.ChatTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existence of chat: %w", err)
+	}
+	if chat == nil {
+		return nil, ErrChatNotFound
+	}
+
+	switch name {
+	case entities.CommandHelp:
+		return cs.help(ctx, chat)
+	case entities.CommandTopic:
+		return cs.topic(ctx, authorID, chat, args)
+	}
+
+	cmd, err := cs.repo.ReadCommand(ctx, chat.Id, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read command: %w", err)
+	}
+	if cmd == nil {
+		return nil, nil
+	}
+
+	err = cs.permissions.CheckPermission(ctx, chat.Id, cmd.BotID, entities.ChatPermissionPostMessage)
+	if errors.Is(err, ErrNotChatParticipant) || errors.Is(err, ErrChatPermissionDenied) {
+		return ephemeralResult(name, fmt.Sprintf("/%s is unavailable: its bot can't post in this chat.", name)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	inv := entities.NewCommandInvocation(chat.Id, cmd.BotID, authorID, name, args)
+	if err = cs.repo.CreateInvocation(ctx, inv); err != nil {
+		return nil, fmt.Errorf("failed to save command invocation: %w", err)
+	}
+
+	cs.logger.Debug(ctx, "command sent to bot",
+		option.Any("invocation_id", inv.ID.String()),
+		option.Any("command", name),
+		option.Any("bot_id", cmd.BotID.String()),
+		option.Any("chat_id", chat.Id.String()),
+	)
+
+	return &dtos.CommandResult{
+		Command:      name,
+		InvocationID: &inv.ID,
+		BotID:        &cmd.BotID,
+		Replies:      []dtos.CommandReply{},
+	}, nil
+}
+
+func (cs *CommandService) help(ctx context.Context, chat *entities.Chat) (*dtos.CommandResult, error) {
+	commands, err := cs.repo.ReadCommands(ctx, chat.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commands: %w", err)
+	}
+
+	var text strings.Builder
+	text.WriteString("Commands of this chat:")
+	for _, cmd := range append(builtinCommands(), toChatCommandDtos(commands)...) {
+		fmt.Fprintf(&text, "\n/%s", cmd.Name)
+		if cmd.Desc != "" {
+			fmt.Fprintf(&text, " - %s", cmd.Desc)
+		}
+	}
+
+	return ephemeralResult(entities.CommandHelp, text.String()), nil
+}
+
+// topic shows the chat's title, or changes it if a new one is given.
+func (cs *CommandService) topic(ctx context.Context, actorID uuid.UUID, chat *entities.Chat,
+	title string) (*dtos.CommandResult, error) {
+	if title == "" {
+		if chat.Kind == entities.ChatKindDirect || chat.Title == "" {
+			return ephemeralResult(entities.CommandTopic, "This chat has no topic."), nil
+		}
+		return ephemeralResult(entities.CommandTopic, "Topic: "+chat.Title), nil
+	}
+
+	if err := cs.renamer.Update(ctx, actorID, dtos.ChatRequest{Tag: chat.Tag, Title: title}); err != nil {
+		return nil, err
+	}
+
+	return ephemeralResult(entities.CommandTopic, "Topic changed to: "+title), nil
+}
+
+// Register makes "/<name>" in the chat go to the bot.
+func (cs *CommandService) Register(ctx context.Context, actorID uuid.UUID,
+	req dtos.ChatCommandRequest) (dtos.ChatCommand, error) {
+	name := strings.ToLower(strings.TrimPrefix(req.Name, "/"))
+	if !entities.ValidCommandName(name) || entities.IsBuiltinCommand(name) {
+		return dtos.ChatCommand{}, ErrInvalidCommandName
+	}
+	if utf8.RuneCountInString(req.Desc) > maxCommandDescLen {
+		return dtos.ChatCommand{}, ErrInvalidCommandDesc
+	}
+
+	chat, err := cs.chats.ReadByID(ctx, req.ChatID)
+	if err != nil {
+		return dtos.ChatCommand{}, fmt.Errorf("failed to check existence of chat: %w", err)
+	}
+	if chat == nil {
+		return dtos.ChatCommand{}, ErrChatNotFound
+	}
+	if chat.Kind == entities.ChatKindDirect {
+		return dtos.ChatCommand{}, ErrDirectChatRestricted
+	}
+
+	err = cs.permissions.CheckPermission(ctx, chat.Id, actorID, entities.ChatPermissionManageCommands)
+	if err != nil {
+		return dtos.ChatCommand{}, err
+	}
+
+	bot, err := cs.bots.ReadByID(ctx, req.BotID)
+	if err != nil {
+		return dtos.ChatCommand{}, fmt.Errorf("failed to read bot: %w", err)
+	}
+	if bot == nil {
+		return dtos.ChatCommand{}, ErrBotNotFound
+	}
+
+	err = cs.permissions.CheckPermission(ctx, chat.Id, bot.ID, entities.ChatPermissionPostMessage)
+	if errors.Is(err, ErrNotChatParticipant) || errors.Is(err, ErrChatPermissionDenied) {
+		return dtos.ChatCommand{}, ErrCommandBotNotInChat
+	}
+	if err != nil {
+		return dtos.ChatCommand{}, err
+	}
+
+	existing, err := cs.repo.ReadCommand(ctx, chat.Id, name)
+	if err != nil {
+		return dtos.ChatCommand{}, fmt.Errorf("failed to check existence of command: %w", err)
+	}
+	if existing != nil {
+		return dtos.ChatCommand{}, ErrCommandTaken
+	}
+
+	cmd := entities.NewChatCommand(chat.Id, name, bot.ID, req.Desc, actorID)
+	if err = cs.repo.Create(ctx, cmd); err != nil {
+		return dtos.ChatCommand{}, fmt.Errorf("failed to register command: %w", err)
+	}
+
+	cs.logger.Info(ctx, "command registered",
+		option.Any("chat_id", chat.Id.String()),
+		option.Any("command", name),
+		option.Any("bot_id", bot.ID.String()),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return toChatCommandDtos([]entities.ChatCommand{cmd})[0], nil
+}
+
+// List returns the commands available in the chat, built-in ones first.
+func (cs *CommandService) List(ctx context.Context, viewerID uuid.UUID, chatID uuid.UUID) ([]dtos.ChatCommand, error) {
+	err := cs.permissions.CheckPermission(ctx, chatID, viewerID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := cs.repo.ReadCommands(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commands: %w", err)
+	}
+
+	return append(builtinCommands(), toChatCommandDtos(commands)...), nil
+}
+
+func (cs *CommandService) Unregister(ctx context.Context, actorID uuid.UUID, req dtos.ChatCommandID) error {
+	err := cs.permissions.CheckPermission(ctx, req.ChatID, actorID, entities.ChatPermissionManageCommands)
+	if err != nil {
+		return err
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(req.Name, "/"))
+	cmd, err := cs.repo.ReadCommand(ctx, req.ChatID, name)
+	if err != nil {
+		return fmt.Errorf("failed to read command: %w", err)
+	}
+	if cmd == nil {
+		return ErrCommandNotFound
+	}
+
+	if err = cs.repo.Delete(ctx, req.ChatID, name); err != nil {
+		return fmt.Errorf("failed to unregister command: %w", err)
+	}
+
+	cs.logger.Info(ctx, "command unregistered",
+		option.Any("chat_id", req.ChatID.String()),
+		option.Any("command", name),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return nil
+}
+
+// ClaimInvocations hands the bot up to limit commands sent to it that it
+// hasn't picked up yet, oldest first. Each command is handed out once.
+func (cs *CommandService) ClaimInvocations(ctx context.Context, botID uuid.UUID,
+	limit int) ([]dtos.CommandInvocation, error) {
+	limit, _ = normalizePage(limit, 0)
+
+	invocations, err := cs.repo.ClaimInvocations(ctx, botID, time.Now().Add(-commandAnswerWindow), uint64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim command invocations: %w", err)
+	}
+	slices.SortFunc(invocations, func(a, b entities.CommandInvocation) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	result := make([]dtos.CommandInvocation, 0, len(invocations))
+	for _, inv := range invocations {
+		result = append(result, dtos.CommandInvocation{
+			ID:        inv.ID,
+			ChatID:    inv.ChatID,
+			ChatTag:   inv.ChatTag,
+			UserID:    inv.UserID,
+			Command:   inv.Command,
+			Args:      inv.Args,
+			CreatedAt: inv.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// Respond answers a command sent to the bot. A message is posted on the
+// bot's behalf, so the bot must still be allowed to post in the chat.
+func (cs *CommandService) Respond(ctx context.Context, botID uuid.UUID, req dtos.CommandResponseRequest) error {
+	if strings.TrimSpace(req.Content) == "" {
+		return ErrEmptyMessage
+	}
+
+	inv, err := cs.repo.ReadInvocation(ctx, req.InvocationID)
+	if err != nil {
+		return fmt.Errorf("failed to read command invocation: %w", err)
+	}
+	if inv == nil || inv.BotID != botID {
+		return ErrInvocationNotFound
+	}
+	if time.Since(inv.CreatedAt) > commandAnswerWindow {
+		return ErrInvocationExpired
+	}
+
+	if req.Ephemeral {
+		if err = cs.repo.CreateReply(ctx, entities.NewCommandReply(inv.ID, req.Content)); err != nil {
+			return fmt.Errorf("failed to save command reply: %w", err)
+		}
+	} else {
+		if _, err = cs.messages.Create(ctx, botID, dtos.Message{ChatTag: inv.ChatTag, Content: req.Content}); err != nil {
+			return err
+		}
+	}
+
+	cs.logger.Debug(ctx, "command answered",
+		option.Any("invocation_id", inv.ID.String()),
+		option.Any("bot_id", botID.String()),
+		option.Any("ephemeral", req.Ephemeral),
+	)
+
+	return nil
+}
+
+// GetReplies returns the ephemeral replies to the user's commands in the
+// chat from the last commandReplyTTL, oldest first.
+func (cs *CommandService) GetReplies(ctx context.Context, userID uuid.UUID,
+	chatID uuid.UUID) ([]dtos.CommandReply, error) {
+	err := cs.permissions.CheckPermission(ctx, chatID, userID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := cs.repo.ReadReplies(ctx, userID, chatID, time.Now().Add(-commandReplyTTL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read command replies: %w", err)
+	}
+
+	result := make([]dtos.CommandReply, 0, len(replies))
+	for _, r := range replies {
+		result = append(result, dtos.CommandReply{
+			InvocationID: &r.InvocationID,
+			Command:      r.Command,
+			Content:      r.Content,
+			CreatedAt:    r.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func builtinCommands() []dtos.ChatCommand {
+	return []dtos.ChatCommand{
+		{Name: entities.CommandHelp, Desc: "list the commands of this chat", Builtin: true},
+		{Name: entities.CommandTopic, Desc: "show the chat's topic, or change it: /topic <new topic>", Builtin: true},
+	}
+}
+
+func toChatCommandDtos(commands []entities.ChatCommand) []dtos.ChatCommand {
+	result := make([]dtos.ChatCommand, 0, len(commands))
+	for _, cmd := range commands {
+		result = append(result, dtos.ChatCommand{
+			Name:  cmd.Name,
+			Desc:  cmd.Desc,
+			BotID: &cmd.BotID,
+		})
+	}
+
+	return result
+}
+
+func ephemeralResult(command, content string) *dtos.CommandResult {
+	return &dtos.CommandResult{
+		Command: command,
+		Replies: []dtos.CommandReply{{Command: command, Content: content, CreatedAt: time.Now()}},
+	}
+}
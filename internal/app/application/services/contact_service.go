@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+var (
+	ErrContactWithSelf = errors.New("can't add yourself to contacts")
+	ErrBlockSelf       = errors.New("can't block yourself")
+	ErrBlockedByUser   = errors.New("user has blocked you")
+)
+
+type ContactRepository interface {
+	AddContact(ctx context.Context, userID, contactID uuid.UUID, at time.Time) error
+	RemoveContact(ctx context.Context, userID, contactID uuid.UUID) error
+	ReadContacts(ctx context.Context, userID uuid.UUID, limit, offset uint64) ([]entities.Contact, error)
+	Block(ctx context.Context, userID, blockedID uuid.UUID, at time.Time) error
+	Unblock(ctx context.Context, userID, blockedID uuid.UUID) error
+	ReadBlocked(ctx context.Context, userID uuid.UUID, limit, offset uint64) ([]entities.BlockedUser, error)
+}
+
+// BlockChecker tells whether one user has blocked another, and which of
+// several users have blocked one.
+type BlockChecker interface {
+	IsBlocked(ctx context.Context, userID, blockedID uuid.UUID) (bool, error)
+	ReadBlockers(ctx context.Context, blockedID uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error)
+}
+
+// ContactService manages users' contact and block lists. Both are one-sided:
+// adding or blocking someone isn't visible to them, except that a blocked
+// user can no longer open a direct chat with the blocker, add them to chats
+// or see their presence.
+type ContactService struct {
+	repo     ContactRepository
+	userRepo UserProfileReader
+	logger   logger.Logger
+}
+
+func NewContactService(repo ContactRepository, userRepo UserProfileReader, logger logger.Logger) *ContactService {
+	return &ContactService{
+		repo:     repo,
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// AddContact adds the user to the owner's contacts. Adding a contact twice is
+// a no-op.
+func (cs *ContactService) AddContact(ctx context.Context, ownerID uuid.UUID, userID uuid.UUID) error {
+	if ownerID == userID {
+		return ErrContactWithSelf
+	}
+	if err := cs.requireAccount(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := cs.repo.AddContact(ctx, ownerID, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to add contact: %w", err)
+	}
+
+	return nil
+}
+
+func (cs *ContactService) RemoveContact(ctx context.Context, ownerID uuid.UUID, userID uuid.UUID) error {
+	if err := cs.repo.RemoveContact(ctx, ownerID, userID); err != nil {
+		return fmt.Errorf("failed to remove contact: %w", err)
+	}
+
+	return nil
+}
+
+func (cs *ContactService) ListContacts(ctx context.Context, ownerID uuid.UUID,
+	limit, offset int) (dtos.ContactsResponse, error) {
+	limit, offset = normalizePage(limit, offset)
+
+	contacts, err := cs.repo.ReadContacts(ctx, ownerID, uint64(limit), uint64(offset))
+	if err != nil {
+		return dtos.ContactsResponse{}, fmt.Errorf("failed to read contacts: %w", err)
+	}
+
+	resp := dtos.ContactsResponse{
+		Contacts: make([]dtos.Contact, 0, len(contacts)),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	for _, c := range contacts {
+		resp.Contacts = append(resp.Contacts, dtos.Contact{User: toUserProfileDto(c.Profile), AddedAt: c.AddedAt})
+	}
+
+	return resp, nil
+}
+
+// Block adds the user to the owner's block list. Blocking a user twice is a
+// no-op.
+func (cs *ContactService) Block(ctx context.Context, ownerID uuid.UUID, userID uuid.UUID) error {
+	if ownerID == userID {
+		return ErrBlockSelf
+	}
+	if err := cs.requireAccount(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := cs.repo.Block(ctx, ownerID, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to block user: %w", err)
+	}
+
+	cs.logger.Info(ctx, "user blocked",
+		option.Any("user_id", ownerID.String()),
+		option.Any("blocked_id", userID.String()),
+	)
+
+	return nil
+}
+
+func (cs *ContactService) Unblock(ctx context.Context, ownerID uuid.UUID, userID uuid.UUID) error {
+	if err := cs.repo.Unblock(ctx, ownerID, userID); err != nil {
+		return fmt.Errorf("failed to unblock user: %w", err)
+	}
+
+	cs.logger.Info(ctx, "user unblocked",
+		option.Any("user_id", ownerID.String()),
+		option.Any("blocked_id", userID.String()),
+	)
+
+	return nil
+}
+
+func (cs *ContactService) ListBlocked(ctx context.Context, ownerID uuid.UUID,
+	limit, offset int) (dtos.BlockedUsersResponse, error) {
+	limit, offset = normalizePage(limit, offset)
+
+	blocked, err := cs.repo.ReadBlocked(ctx, ownerID, uint64(limit), uint64(offset))
+	if err != nil {
+		return dtos.BlockedUsersResponse{}, fmt.Errorf("failed to read blocked users: %w", err)
+	}
+
+	resp := dtos.BlockedUsersResponse{
+		Users:  make([]dtos.BlockedUser, 0, len(blocked)),
+		Limit:  limit,
+		Offset: offset,
+	}
+	for _, b := range blocked {
+		resp.Users = append(resp.Users, dtos.BlockedUser{User: toUserProfileDto(b.Profile), BlockedAt: b.BlockedAt})
+	}
+
+	return resp, nil
+}
+
+func (cs *ContactService) requireAccount(ctx context.Context, userID uuid.UUID) error {
+	account, err := cs.userRepo.ReadById(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to read user account: %w", err)
+	}
+	if account == nil {
+		return ErrNoAccountFound
+	}
+
+	return nil
+}
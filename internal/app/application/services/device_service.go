@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+// maxDeviceTokenLen comfortably fits FCM registration tokens and APNs device
+// tokens.
+const maxDeviceTokenLen = 4096
+
+var (
+	ErrInvalidDeviceToken  = errors.New("device token must be 1 to 4096 characters long")
+	ErrInvalidPushPlatform = errors.New("push platform must be 'fcm' or 'apns'")
+)
+
+type DeviceTokenRepository interface {
+	Upsert(ctx context.Context, token entities.DeviceToken) error
+	Delete(ctx context.Context, userID uuid.UUID, token string) error
+	DeleteTokens(ctx context.Context, tokens []string) error
+	ReadByUsers(ctx context.Context, userIDs []uuid.UUID) ([]entities.DeviceToken, error)
+}
+
+// DeviceService registers the devices push notifications are sent to.
+type DeviceService struct {
+	repo   DeviceTokenRepository
+	logger logger.Logger
+}
+
+func NewDeviceService(repo DeviceTokenRepository, logger logger.Logger) *DeviceService {
+	return &DeviceService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Register ties the device token to the user, taking it over from whoever had
+// registered it before.
+func (ds *DeviceService) Register(ctx context.Context, userID uuid.UUID, req dtos.DeviceRegistration) error {
+	if req.Token == "" || len(req.Token) > maxDeviceTokenLen {
+		return ErrInvalidDeviceToken
+	}
+	platform := entities.PushPlatform(req.Platform)
+	if !platform.Valid() {
+		return ErrInvalidPushPlatform
+	}
+
+	if err := ds.repo.Upsert(ctx, entities.NewDeviceToken(req.Token, userID, platform)); err != nil {
+		return fmt.Errorf("failed to register device: %w", err)
+	}
+
+	ds.logger.Debug(ctx, "device registered",
+		option.Any("user_id", userID.String()),
+		option.Any("platform", string(platform)),
+	)
+
+	return nil
+}
+
+func (ds *DeviceService) Unregister(ctx context.Context, userID uuid.UUID, req dtos.DeviceRegistration) error {
+	if req.Token == "" {
+		return ErrInvalidDeviceToken
+	}
+
+	if err := ds.repo.Delete(ctx, userID, req.Token); err != nil {
+		return fmt.Errorf("failed to unregister device: %w", err)
+	}
+
+	return nil
+}
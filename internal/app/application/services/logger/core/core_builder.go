@@ -7,14 +7,18 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
-	"path/filepath"
+	"sync"
 )
 
 const fileMode = 0644
 
 type CoreBuilder struct {
-	level zapcore.Level
+	level zap.AtomicLevel
 	cfg   *config.LogConfig
+
+	fileOnce sync.Once
+	file     zapcore.WriteSyncer
+	fileErr  error
 }
 
 func NewCoreBuilder(cfg *config.LogConfig) (*CoreBuilder, error) {
@@ -24,12 +28,18 @@ func NewCoreBuilder(cfg *config.LogConfig) (*CoreBuilder, error) {
 	}
 
 	return &CoreBuilder{
-		level: level,
+		level: zap.NewAtomicLevelAt(level),
 		cfg:   cfg,
 	}, nil
 }
 
-func (c *CoreBuilder) ConsoleCore() zapcore.Core {
+// Level returns the builder's root level. Changing it affects every core
+// built with the default enabler.
+func (c *CoreBuilder) Level() zap.AtomicLevel {
+	return c.level
+}
+
+func (c *CoreBuilder) ConsoleCore(enabler zapcore.LevelEnabler) zapcore.Core {
 	encoderCfg := zap.NewDevelopmentEncoderConfig()
 	encoderCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	encoderCfg.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05")
@@ -37,21 +47,10 @@ func (c *CoreBuilder) ConsoleCore() zapcore.Core {
 
 	consoleEncoder := zapcore.NewConsoleEncoder(encoderCfg)
 
-	consoleLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= c.level
-	})
-
-	return zapcore.NewCore(consoleEncoder, zapcore.Lock(os.Stdout), consoleLevel)
+	return zapcore.NewCore(consoleEncoder, zapcore.Lock(os.Stdout), enabler)
 }
 
-func (c *CoreBuilder) JSONCore() (zapcore.Core, error) {
-	if c.cfg.Logger.LogsDir == "" {
-		return nil, errors.New("logs dir path is empty")
-	}
-	if c.cfg.Logger.LogsFile == "" {
-		return nil, errors.New("logs file name is empty")
-	}
-
+func (c *CoreBuilder) JSONCore(enabler zapcore.LevelEnabler) (zapcore.Core, error) {
 	jsonCfg := zap.NewProductionEncoderConfig()
 	jsonCfg.TimeKey = "@timestamp"
 	jsonCfg.LevelKey = "log.level"
@@ -61,27 +60,24 @@ func (c *CoreBuilder) JSONCore() (zapcore.Core, error) {
 	jsonCfg.EncodeLevel = zapcore.LowercaseLevelEncoder
 	jsonEncoder := zapcore.NewJSONEncoder(jsonCfg)
 
-	if err := os.MkdirAll(c.cfg.Logger.LogsDir, os.ModePerm); err != nil {
-		return nil, fmt.Errorf("create logs dir err: %w", err)
-	}
-
-	logFile := filepath.Join(c.cfg.Logger.LogsDir, c.cfg.Logger.LogsFile)
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, fileMode)
+	file, err := c.logFile()
 	if err != nil {
-		return nil, fmt.Errorf("create log file failed: %w", err)
+		return nil, err
 	}
 
-	jsonLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl != zapcore.DebugLevel
-	})
-
-	return zapcore.NewCore(jsonEncoder, zapcore.AddSync(file), jsonLevel), nil
+	return zapcore.NewCore(jsonEncoder, file, enabler), nil
 }
 
-func (c *CoreBuilder) DualLogger() (*zap.Logger, error) {
-	consoleCore := c.ConsoleCore()
+// DualLogger builds a logger writing to both console and JSON file. A nil
+// enabler means the builder's root level.
+func (c *CoreBuilder) DualLogger(enabler zapcore.LevelEnabler) (*zap.Logger, error) {
+	if enabler == nil {
+		enabler = c.level
+	}
+
+	consoleCore := c.ConsoleCore(enabler)
 
-	jsonCore, err := c.JSONCore()
+	jsonCore, err := c.JSONCore(enabler)
 	if err != nil {
 		return nil, err
 	}
@@ -90,3 +86,39 @@ func (c *CoreBuilder) DualLogger() (*zap.Logger, error) {
 
 	return zap.New(core, zap.AddCaller()), nil
 }
+
+// logFile opens the JSON log file once, so that every logger built by this
+// builder shares a single handle.
+func (c *CoreBuilder) logFile() (zapcore.WriteSyncer, error) {
+	c.fileOnce.Do(func() {
+		if c.cfg.Logger.LogsDir == "" {
+			c.fileErr = errors.New("logs dir path is empty")
+			return
+		}
+		if c.cfg.Logger.LogsFile == "" {
+			c.fileErr = errors.New("logs file name is empty")
+			return
+		}
+
+		if err := os.MkdirAll(c.cfg.Logger.LogsDir, os.ModePerm); err != nil {
+			c.fileErr = fmt.Errorf("create logs dir err: %w", err)
+			return
+		}
+
+		rotation := c.cfg.Logger.Rotation
+		file, err := NewRotatingFile(c.cfg.Logger.LogsDir, c.cfg.Logger.LogsFile, RotationOptions{
+			MaxSizeMB:  rotation.MaxSizeMB,
+			Daily:      rotation.Daily,
+			MaxBackups: rotation.MaxBackups,
+			Compress:   rotation.Compress,
+		})
+		if err != nil {
+			c.fileErr = err
+			return
+		}
+
+		c.file = file
+	})
+
+	return c.file, c.fileErr
+}
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	dayFormat        = "2006-01-02"
+	compressSuffix   = ".gz"
+	bytesInMegabyte  = 1024 * 1024
+)
+
+type RotationOptions struct {
+	MaxSizeMB  int
+	Daily      bool
+	MaxBackups int
+	Compress   bool
+}
+
+// RotatingFile is an append-only log file that is rotated by size and/or by
+// day. Rotation renames the current file instead of copying and truncating
+// it, so a reader tracking files by inode (Filebeat's filestream) keeps
+// reading the rotated file to the end and then picks up the new one.
+type RotatingFile struct {
+	mu       sync.Mutex
+	dir      string
+	name     string
+	opts     RotationOptions
+	file     *os.File
+	size     int64
+	openedOn string
+
+	cleanupMu sync.Mutex
+}
+
+func NewRotatingFile(dir, name string, opts RotationOptions) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		dir:  dir,
+		name: name,
+		opts: opts,
+	}
+
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	// A failed rotation leaves the current file open, so the entry is still
+	// written and the rotation is retried on the next write.
+	var rotateErr error
+	if rf.shouldRotate(len(p)) {
+		rotateErr = rf.rotate()
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	if err == nil && rotateErr != nil {
+		err = fmt.Errorf("rotate log file: %w", rotateErr)
+	}
+
+	return n, err
+}
+
+func (rf *RotatingFile) Sync() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	return rf.file.Sync()
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	return rf.file.Close()
+}
+
+func (rf *RotatingFile) path() string {
+	return filepath.Join(rf.dir, rf.name)
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("create log file failed: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	// An existing file keeps the day it was last written on, so a restart
+	// after midnight still rotates yesterday's logs away.
+	rf.openedOn = info.ModTime().Format(dayFormat)
+	if info.Size() == 0 {
+		rf.openedOn = time.Now().Format(dayFormat)
+	}
+
+	return nil
+}
+
+func (rf *RotatingFile) shouldRotate(writeLen int) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if rf.opts.MaxSizeMB > 0 && rf.size+int64(writeLen) > int64(rf.opts.MaxSizeMB)*bytesInMegabyte {
+		return true
+	}
+
+	return rf.opts.Daily && time.Now().Format(dayFormat) != rf.openedOn
+}
+
+// rotate renames the file while it is still open and only closes it once
+// the new one is in place, so the writer never ends up without a file.
+func (rf *RotatingFile) rotate() error {
+	backup := rf.backupPath(time.Now())
+	if err := os.Rename(rf.path(), backup); err != nil {
+		return err
+	}
+
+	old := rf.file
+	if err := rf.open(); err != nil {
+		// Put the file back so the next rotation starts from the same state.
+		_ = os.Rename(backup, rf.path())
+		return err
+	}
+	_ = old.Close()
+
+	go rf.cleanup()
+
+	return nil
+}
+
+// backupPath turns app.log into app-2006-01-02T15-04-05.000.log.
+func (rf *RotatingFile) backupPath(t time.Time) string {
+	ext := filepath.Ext(rf.name)
+	base := strings.TrimSuffix(rf.name, ext)
+
+	return filepath.Join(rf.dir, base+"-"+t.Format(backupTimeFormat)+ext)
+}
+
+// backups returns rotated files, newest first.
+func (rf *RotatingFile) backups() ([]string, error) {
+	entries, err := os.ReadDir(rf.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(rf.name)
+	prefix := strings.TrimSuffix(rf.name, ext) + "-"
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], compressSuffix), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+
+		backups = append(backups, name)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	return backups, nil
+}
+
+// cleanup compresses and prunes rotated files. The newest backup is left
+// uncompressed because Filebeat may still be reading it.
+func (rf *RotatingFile) cleanup() {
+	rf.cleanupMu.Lock()
+	defer rf.cleanupMu.Unlock()
+
+	backups, err := rf.backups()
+	if err != nil {
+		return
+	}
+
+	if rf.opts.MaxBackups > 0 && len(backups) > rf.opts.MaxBackups {
+		for _, name := range backups[rf.opts.MaxBackups:] {
+			_ = os.Remove(filepath.Join(rf.dir, name))
+		}
+		backups = backups[:rf.opts.MaxBackups]
+	}
+
+	if !rf.opts.Compress {
+		return
+	}
+
+	for i, name := range backups {
+		if i == 0 || strings.HasSuffix(name, compressSuffix) {
+			continue
+		}
+		_ = compressFile(filepath.Join(rf.dir, name))
+	}
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fileMode)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + compressSuffix)
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + compressSuffix)
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		_ = os.Remove(path + compressSuffix)
+		return err
+	}
+
+	return os.Remove(path)
+}
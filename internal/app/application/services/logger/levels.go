@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const RootLogger = "root"
+
+var ErrUnknownLogger = errors.New("unknown logger")
+
+// LevelRegistry keeps the root level and per-logger overrides. A named logger
+// without an override follows the root level.
+type LevelRegistry struct {
+	mu        sync.RWMutex
+	root      zap.AtomicLevel
+	overrides map[string]zapcore.Level
+	known     map[string]struct{}
+}
+
+func NewLevelRegistry(root zap.AtomicLevel) *LevelRegistry {
+	return &LevelRegistry{
+		root:      root,
+		overrides: make(map[string]zapcore.Level),
+		known:     make(map[string]struct{}),
+	}
+}
+
+func (r *LevelRegistry) register(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.known[name] = struct{}{}
+}
+
+// SetLevel changes the level of the named logger. An empty level removes the
+// override so the logger follows the root level again.
+func (r *LevelRegistry) SetLevel(name, level string) error {
+	if name == "" || name == RootLogger {
+		lvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("parse level: %w", err)
+		}
+		r.root.SetLevel(lvl)
+
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.known[name]; !ok {
+		return ErrUnknownLogger
+	}
+
+	if level == "" {
+		delete(r.overrides, name)
+		return nil
+	}
+
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("parse level: %w", err)
+	}
+	r.overrides[name] = lvl
+
+	return nil
+}
+
+// Levels returns the effective level of the root and every named logger.
+func (r *LevelRegistry) Levels() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	levels := make(map[string]string, len(r.known)+1)
+	levels[RootLogger] = r.root.Level().String()
+	for name := range r.known {
+		levels[name] = r.level(name).String()
+	}
+
+	return levels
+}
+
+func (r *LevelRegistry) level(name string) zapcore.Level {
+	if lvl, ok := r.overrides[name]; ok {
+		return lvl
+	}
+
+	return r.root.Level()
+}
+
+func (r *LevelRegistry) enabler(name string) zapcore.LevelEnabler {
+	return zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		return lvl >= r.level(name)
+	})
+}
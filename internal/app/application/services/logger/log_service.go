@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"github.com/renderview-inc/backend/internal/app/application/services/logger/core"
 	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 	"github.com/renderview-inc/backend/internal/app/infrastructure/repositories/logger"
@@ -16,12 +17,22 @@ const (
 	CorrelationID ctxKey = "correlation_id"
 )
 
+// Logger is the part of LogService the rest of the application depends on.
+type Logger interface {
+	Debug(ctx context.Context, msg string, opts ...option.LogOption)
+	Info(ctx context.Context, msg string, opts ...option.LogOption)
+	Warn(ctx context.Context, msg string, opts ...option.LogOption)
+	Error(ctx context.Context, msg string, opts ...option.LogOption)
+}
+
 type LogRepository interface {
 	Save(ctx context.Context, log map[string]any) error
 }
 
 type LogService struct {
-	logger *zap.Logger
+	logger  *zap.Logger
+	builder *core.CoreBuilder
+	levels  *LevelRegistry
 }
 
 func (l *LogService) Sync() error {
@@ -34,16 +45,54 @@ func NewLogService(cfg *config.LogConfig) (*LogService, error) {
 		return nil, err
 	}
 
-	newLogger, err := builder.DualLogger()
+	newLogger, err := builder.DualLogger(nil)
 	if err != nil {
 		return nil, err
 	}
 
+	levels := NewLevelRegistry(builder.Level())
+	for name, level := range cfg.Logger.Levels {
+		levels.register(name)
+		if err = levels.SetLevel(name, level); err != nil {
+			return nil, fmt.Errorf("set level of %s logger: %w", name, err)
+		}
+	}
+
 	return &LogService{
-		logger: newLogger,
+		logger:  newLogger,
+		builder: builder,
+		levels:  levels,
 	}, nil
 }
 
+// Named returns a child logger whose level can be overridden independently of
+// the root one through Levels.
+func (l *LogService) Named(name string) (*LogService, error) {
+	l.levels.register(name)
+
+	newLogger, err := l.builder.DualLogger(l.levels.enabler(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogService{
+		logger:  newLogger.Named(name),
+		builder: l.builder,
+		levels:  l.levels,
+	}, nil
+}
+
+func (l *LogService) Levels() *LevelRegistry {
+	return l.levels
+}
+
+// RedirectStdLog sends output of the standard library logger, used by
+// third-party packages, to this logger at info level. The returned function
+// restores the original output.
+func (l *LogService) RedirectStdLog() func() {
+	return zap.RedirectStdLog(l.logger)
+}
+
 func (l *LogService) log(ctx context.Context, level zapcore.Level, msg string, opts ...option.LogOption) {
 	additional := make(map[string]any)
 	for _, opt := range opts {
@@ -4,97 +4,900 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/pkg/txhelper"
+)
+
+var (
+	ErrMessageNotFound = errors.New("message doesn't exist")
+	ErrMessageDeleted  = errors.New("message is deleted")
+	ErrEmptyMessage    = errors.New("message content can't be empty")
+	ErrInvalidReply    = errors.New("reply must point to a message in the same chat")
+	ErrInvalidReaction = errors.New("reaction must be a single emoji")
+
+	ErrInvalidSearchQuery = errors.New("search query must be 1 to 256 characters long")
+	ErrInvalidSearchRange = errors.New("search date range must end after it starts")
+)
+
+const (
+	// quoteSnippetLen is how many characters of the parent message are
+	// quoted in a reply.
+	quoteSnippetLen   = 100
+	maxSearchQueryLen = 256
 )
 
 type MessageRepository interface {
-	Create(ctx context.Context, msg *entities.Message) error
+	Create(ctx context.Context, tx pgx.Tx, msg *entities.Message) error
 	ReadByID(ctx context.Context, id uuid.UUID) (*entities.Message, error)
+	ReadByIDs(ctx context.Context, ids []uuid.UUID) ([]entities.Message, error)
+	ReadReplies(ctx context.Context, parentID uuid.UUID, limit, offset uint64) ([]entities.Message, error)
+	CountReplies(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]int, error)
 	GetLastByChatTag(ctx context.Context, chatTag string) (*entities.Message, error)
-	Update(ctx context.Context, msg *entities.Message) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Update(ctx context.Context, tx pgx.Tx, msg *entities.Message) error
+	Delete(ctx context.Context, tx pgx.Tx, id uuid.UUID, deletedAt time.Time) error
+	CreateRevision(ctx context.Context, tx pgx.Tx, rev entities.MessageRevision) error
+	ReadRevisions(ctx context.Context, messageID uuid.UUID) ([]entities.MessageRevision, error)
+}
+
+type MentionRepository interface {
+	ResolveParticipants(ctx context.Context, chatTag string, tags []string) (map[string]uuid.UUID, error)
+	Create(ctx context.Context, tx pgx.Tx, chatTag string, createdAt time.Time, mentions []entities.MessageMention) error
+	DeleteByMessage(ctx context.Context, tx pgx.Tx, messageID uuid.UUID) error
+	ReadByMessageIDs(ctx context.Context, ids []uuid.UUID) ([]entities.MessageMention, error)
+	ReadFeed(ctx context.Context, userID uuid.UUID, limit, offset uint64) ([]entities.MentionFeedEntry, error)
+}
+
+// MessageSearchIndex answers message searches. Indexes kept apart from the
+// messages table are fed through Index and Delete. A rebuild fills a new
+// version of the index with IndexVersion while the live one keeps serving
+// searches, then switches to it with ActivateVersion. The Postgres index
+// reads the table itself and ignores all of that.
+type MessageSearchIndex interface {
+	Search(ctx context.Context, query entities.MessageSearchQuery) ([]entities.MessageSearchHit, error)
+	Index(ctx context.Context, docs []entities.MessageDocument) error
+	Delete(ctx context.Context, ids []uuid.UUID) error
+	CreateVersion(ctx context.Context) (string, error)
+	IndexVersion(ctx context.Context, version string, docs []entities.MessageDocument) error
+	ActivateVersion(ctx context.Context, version string) error
+}
+
+// MessageEventHandler is told about every message change inside the
+// transaction making it, so it should only record work to be done later.
+type MessageEventHandler interface {
+	HandleMessageEvent(ctx context.Context, tx pgx.Tx, event entities.MessageEvent) error
+}
+
+// CommandRouter takes slash commands out of the message flow. It returns nil
+// for messages that aren't commands.
+type CommandRouter interface {
+	Route(ctx context.Context, authorID uuid.UUID, msg dtos.Message) (*dtos.CommandResult, error)
+}
+
+type ReadReceiptRepository interface {
+	MarkRead(ctx context.Context, userID uuid.UUID, msg *entities.Message) error
+	ReadMessageReceipts(ctx context.Context, msg *entities.Message) (entities.MessageReceipts, error)
+}
+
+type ReactionRepository interface {
+	Create(ctx context.Context, reaction entities.MessageReaction) error
+	Delete(ctx context.Context, messageID, userID uuid.UUID, emoji string) error
+	CountByMessages(ctx context.Context, messageIDs []uuid.UUID, viewerID uuid.UUID) ([]entities.ReactionCount, error)
+}
+
+type ChatPermissionChecker interface {
+	CheckPermission(ctx context.Context, chatID, userID uuid.UUID, permission entities.ChatPermission) error
+	CheckPermissionByTag(ctx context.Context, chatTag string, userID uuid.UUID, permission entities.ChatPermission) error
+}
+
+// DirectBlockChecker keeps users from writing in a direct chat whose other
+// user has blocked them.
+type DirectBlockChecker interface {
+	CheckDirectBlockByTag(ctx context.Context, chatTag string, userID uuid.UUID) error
 }
 
 type MessageService struct {
-	msgRepo MessageRepository
+	msgRepo        MessageRepository
+	receiptRepo    ReadReceiptRepository
+	reactionRepo   ReactionRepository
+	attachmentRepo AttachmentRepository
+	mentionRepo    MentionRepository
+	searchIndex    MessageSearchIndex
+	permissions    ChatPermissionChecker
+	blocks         DirectBlockChecker
+	txHelper       *txhelper.TxHelper
+	eventHandlers  []MessageEventHandler
+	commands       CommandRouter
+	logger         logger.Logger
 }
 
-func NewMessageService(msgRepo MessageRepository) *MessageService {
+func NewMessageService(msgRepo MessageRepository, receiptRepo ReadReceiptRepository, reactionRepo ReactionRepository,
+	attachmentRepo AttachmentRepository, mentionRepo MentionRepository, searchIndex MessageSearchIndex,
+	permissions ChatPermissionChecker, blocks DirectBlockChecker, txHelper *txhelper.TxHelper,
+	logger logger.Logger) *MessageService {
 	return &MessageService{
-		msgRepo: msgRepo,
+		msgRepo:        msgRepo,
+		receiptRepo:    receiptRepo,
+		reactionRepo:   reactionRepo,
+		attachmentRepo: attachmentRepo,
+		mentionRepo:    mentionRepo,
+		searchIndex:    searchIndex,
+		permissions:    permissions,
+		blocks:         blocks,
+		txHelper:       txHelper,
+		logger:         logger,
 	}
 }
 
-func (ms *MessageService) Create(ctx context.Context, msg dtos.Message) error {
+// Subscribe adds a handler for message events. It must be called before the
+// service starts handling requests.
+func (ms *MessageService) Subscribe(handler MessageEventHandler) {
+	ms.eventHandlers = append(ms.eventHandlers, handler)
+}
+
+// RouteCommands makes the router handle commands posted as messages. It must
+// be called before the service starts handling requests.
+func (ms *MessageService) RouteCommands(router CommandRouter) {
+	ms.commands = router
+}
+
+func (ms *MessageService) publish(ctx context.Context, tx pgx.Tx, event entities.MessageEvent) error {
+	for _, handler := range ms.eventHandlers {
+		if err := handler.HandleMessageEvent(ctx, tx, event); err != nil {
+			return fmt.Errorf("failed to handle message event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Create posts a message. A message may have no text as long as it carries
+// attachments. @tags of chat participants in the text are recorded as
+// mentions. Text-only "/command args" messages are handed to the command
+// router instead of being stored; the result is what it made of them. A user
+// blocked by the other user of a direct chat can't post there.
+func (ms *MessageService) Create(ctx context.Context, authorID uuid.UUID, msg dtos.Message) (*dtos.CommandResult, error) {
+	attachmentIDs := uniqueIDs(msg.AttachmentIDs)
+	if len(attachmentIDs) > maxAttachmentsPerMessage {
+		return nil, ErrInvalidAttachment
+	}
+	if strings.TrimSpace(msg.Content) == "" && len(attachmentIDs) == 0 {
+		return nil, ErrEmptyMessage
+	}
+
+	err := ms.permissions.CheckPermissionByTag(ctx, msg.ChatTag, authorID, entities.ChatPermissionPostMessage)
+	if err != nil {
+		return nil, err
+	}
+	if err = ms.blocks.CheckDirectBlockByTag(ctx, msg.ChatTag, authorID); err != nil {
+		return nil, err
+	}
+
+	if ms.commands != nil && len(attachmentIDs) == 0 {
+		result, err := ms.commands.Route(ctx, authorID, msg)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			return result, nil
+		}
+	}
+
+	if msg.ReplyToID != uuid.Nil {
+		parent, err := ms.msgRepo.ReadByID(ctx, msg.ReplyToID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve replied message: %w", err)
+		}
+		if parent == nil || parent.ChatTag != msg.ChatTag {
+			return nil, ErrInvalidReply
+		}
+		if parent.DeletedAt != nil {
+			return nil, ErrMessageDeleted
+		}
+	}
+
 	msgEntity := entities.NewMessage(
 		uuid.New(),
 		msg.ReplyToID,
-		msg.UserID,
+		authorID,
 		msg.ChatTag,
 		msg.Content,
 		time.Now(),
 	)
-	return ms.msgRepo.Create(ctx, msgEntity)
+
+	mentions, err := ms.resolveMentions(ctx, msgEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ms.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := ms.msgRepo.Create(ctx, tx, msgEntity); err != nil {
+			return err
+		}
+		if err := ms.mentionRepo.Create(ctx, tx, msgEntity.ChatTag, msgEntity.CreatedAt, mentions); err != nil {
+			return fmt.Errorf("failed to save mentions: %w", err)
+		}
+
+		if len(attachmentIDs) > 0 {
+			attached, err := ms.attachmentRepo.Attach(ctx, tx, attachmentIDs, msgEntity)
+			if err != nil {
+				return fmt.Errorf("failed to attach files: %w", err)
+			}
+			if attached != int64(len(attachmentIDs)) {
+				return ErrInvalidAttachment
+			}
+		}
+
+		event := entities.NewMessageEvent(entities.MessageCreated, *msgEntity, authorID)
+		event.Mentions = mentions
+
+		return ms.publish(ctx, tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ms.logger.Debug(ctx, "message created",
+		option.Any("message_id", msgEntity.ID.String()),
+		option.Any("chat_tag", msgEntity.ChatTag),
+	)
+
+	// The author has obviously seen their own message.
+	if err := ms.receiptRepo.MarkRead(ctx, authorID, msgEntity); err != nil {
+		ms.logger.Warn(ctx, "failed to advance author's read marker",
+			option.Any("message_id", msgEntity.ID.String()),
+			option.Error(err),
+		)
+	}
+
+	return nil, nil
 }
 
-func (ms *MessageService) GetByID(ctx context.Context, id uuid.UUID) (dtos.Message, error) {
+// GetByID returns the message together with its read-by summary. Readers are
+// listed individually in group chats only.
+func (ms *MessageService) GetByID(ctx context.Context, viewerID uuid.UUID, id uuid.UUID) (dtos.Message, error) {
 	msgEntity, err := ms.msgRepo.ReadByID(ctx, id)
 	if err != nil {
 		return dtos.Message{}, fmt.Errorf("failed to retrieve message: %w", err)
 	}
 	if msgEntity == nil {
-		return dtos.Message{}, errors.New("message not found")
+		return dtos.Message{}, ErrMessageNotFound
 	}
-	return dtos.Message{
-		ID:      msgEntity.ID,
-		UserID:  msgEntity.UserID,
-		ChatTag: msgEntity.ChatTag,
-		Content: msgEntity.Content,
-	}, nil
+
+	err = ms.permissions.CheckPermissionByTag(ctx, msgEntity.ChatTag, viewerID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return dtos.Message{}, err
+	}
+
+	receipts, err := ms.receiptRepo.ReadMessageReceipts(ctx, msgEntity)
+	if err != nil {
+		return dtos.Message{}, fmt.Errorf("failed to read receipts: %w", err)
+	}
+
+	described, err := ms.describe(ctx, viewerID, []entities.Message{*msgEntity})
+	if err != nil {
+		return dtos.Message{}, err
+	}
+
+	msg := described[0]
+	msg.ReadBy = &dtos.ReadReceipts{
+		ReadCount:      len(receipts.Readers),
+		RecipientCount: receipts.RecipientCount,
+	}
+	if receipts.ChatKind != entities.ChatKindDirect {
+		msg.ReadBy.Readers = receipts.Readers
+	}
+
+	return msg, nil
 }
 
-func (ms *MessageService) GetLastByChatTag(ctx context.Context, chatTag string) (dtos.Message, error) {
+func (ms *MessageService) GetLastByChatTag(ctx context.Context, viewerID uuid.UUID, chatTag string) (dtos.Message, error) {
+	err := ms.permissions.CheckPermissionByTag(ctx, chatTag, viewerID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return dtos.Message{}, err
+	}
+
 	msgEntity, err := ms.msgRepo.GetLastByChatTag(ctx, chatTag)
 	if err != nil {
 		return dtos.Message{}, fmt.Errorf("failed to get last message: %w", err)
 	}
 	if msgEntity == nil {
-		return dtos.Message{}, errors.New("no messages found")
+		return dtos.Message{}, ErrMessageNotFound
 	}
 
-	msg := dtos.Message{
-		ID:      msgEntity.ID,
-		UserID:  msgEntity.UserID,
-		ChatTag: msgEntity.ChatTag,
-		Content: msgEntity.Content,
+	described, err := ms.describe(ctx, viewerID, []entities.Message{*msgEntity})
+	if err != nil {
+		return dtos.Message{}, err
 	}
 
-	return msg, nil
+	return described[0], nil
 }
 
-func (ms *MessageService) Update(ctx context.Context, msg dtos.Message) error {
+// GetThread returns the root message and a page of its direct replies,
+// oldest first.
+func (ms *MessageService) GetThread(ctx context.Context, viewerID uuid.UUID, rootID uuid.UUID,
+	limit, offset int) (dtos.ThreadResponse, error) {
+	root, err := ms.msgRepo.ReadByID(ctx, rootID)
+	if err != nil {
+		return dtos.ThreadResponse{}, fmt.Errorf("failed to retrieve message: %w", err)
+	}
+	if root == nil {
+		return dtos.ThreadResponse{}, ErrMessageNotFound
+	}
+
+	err = ms.permissions.CheckPermissionByTag(ctx, root.ChatTag, viewerID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return dtos.ThreadResponse{}, err
+	}
+
+	limit, offset = normalizePage(limit, offset)
+
+	replies, err := ms.msgRepo.ReadReplies(ctx, rootID, uint64(limit), uint64(offset))
+	if err != nil {
+		return dtos.ThreadResponse{}, fmt.Errorf("failed to read replies: %w", err)
+	}
+
+	described, err := ms.describe(ctx, viewerID, append([]entities.Message{*root}, replies...))
+	if err != nil {
+		return dtos.ThreadResponse{}, err
+	}
+
+	return dtos.ThreadResponse{
+		Root:    described[0],
+		Replies: described[1:],
+		Limit:   limit,
+		Offset:  offset,
+	}, nil
+}
+
+// Search finds messages by keyword in the chats the viewer participates in,
+// best matches first, each with a snippet of where it matched.
+func (ms *MessageService) Search(ctx context.Context, viewerID uuid.UUID,
+	req dtos.MessageSearchRequest) (dtos.MessageSearchResponse, error) {
+	text := strings.TrimSpace(req.Query)
+	if text == "" || utf8.RuneCountInString(text) > maxSearchQueryLen {
+		return dtos.MessageSearchResponse{}, ErrInvalidSearchQuery
+	}
+	if req.From != nil && req.To != nil && !req.To.After(*req.From) {
+		return dtos.MessageSearchResponse{}, ErrInvalidSearchRange
+	}
+	if req.ChatID != nil {
+		err := ms.permissions.CheckPermission(ctx, *req.ChatID, viewerID, entities.ChatPermissionReadMessages)
+		if err != nil {
+			return dtos.MessageSearchResponse{}, err
+		}
+	}
+
+	limit, offset := normalizePage(req.Limit, req.Offset)
+
+	query := entities.MessageSearchQuery{
+		ViewerID: viewerID,
+		Text:     text,
+		ChatID:   req.ChatID,
+		AuthorID: req.UserID,
+		From:     req.From,
+		To:       req.To,
+		Limit:    uint64(limit),
+		Offset:   uint64(offset),
+	}
+
+	hits, err := ms.searchIndex.Search(ctx, query)
+	if err != nil {
+		return dtos.MessageSearchResponse{}, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	result := dtos.MessageSearchResponse{
+		Results: make([]dtos.MessageSearchResult, 0, len(hits)),
+		Limit:   limit,
+		Offset:  offset,
+	}
+	if len(hits) == 0 {
+		return result, nil
+	}
+
+	// An index fed in the background may lag behind; messages deleted since
+	// they were indexed are left out.
+	ids := make([]uuid.UUID, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.MessageID)
+	}
+
+	found, err := ms.msgRepo.ReadByIDs(ctx, ids)
+	if err != nil {
+		return dtos.MessageSearchResponse{}, fmt.Errorf("failed to retrieve found messages: %w", err)
+	}
+
+	live := make(map[uuid.UUID]entities.Message, len(found))
+	for _, msg := range found {
+		if msg.DeletedAt == nil {
+			live[msg.ID] = msg
+		}
+	}
+
+	msgs := make([]entities.Message, 0, len(hits))
+	matched := make([]entities.MessageSearchHit, 0, len(hits))
+	for _, hit := range hits {
+		if msg, ok := live[hit.MessageID]; ok {
+			msgs = append(msgs, msg)
+			matched = append(matched, hit)
+		}
+	}
+
+	described, err := ms.describe(ctx, viewerID, msgs)
+	if err != nil {
+		return dtos.MessageSearchResponse{}, err
+	}
+
+	for i, hit := range matched {
+		result.Results = append(result.Results, dtos.MessageSearchResult{
+			Message:   described[i],
+			ChatID:    hit.ChatID,
+			Rank:      hit.Rank,
+			Highlight: highlight(hit.Snippet),
+		})
+	}
+
+	return result, nil
+}
+
+// GetMentions returns a page of the messages mentioning the viewer in chats
+// they participate in, newest first.
+func (ms *MessageService) GetMentions(ctx context.Context, viewerID uuid.UUID,
+	limit, offset int) (dtos.MentionsResponse, error) {
+	limit, offset = normalizePage(limit, offset)
+
+	feed, err := ms.mentionRepo.ReadFeed(ctx, viewerID, uint64(limit), uint64(offset))
+	if err != nil {
+		return dtos.MentionsResponse{}, fmt.Errorf("failed to read mentions: %w", err)
+	}
+
+	result := dtos.MentionsResponse{
+		Mentions: make([]dtos.MentionFeedEntry, 0, len(feed)),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	if len(feed) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(feed))
+	for _, entry := range feed {
+		ids = append(ids, entry.MessageID)
+	}
+
+	found, err := ms.msgRepo.ReadByIDs(ctx, ids)
+	if err != nil {
+		return dtos.MentionsResponse{}, fmt.Errorf("failed to retrieve mentioning messages: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]entities.Message, len(found))
+	for _, msg := range found {
+		byID[msg.ID] = msg
+	}
+
+	msgs := make([]entities.Message, 0, len(feed))
+	chatIDs := make([]uuid.UUID, 0, len(feed))
+	for _, entry := range feed {
+		if msg, ok := byID[entry.MessageID]; ok {
+			msgs = append(msgs, msg)
+			chatIDs = append(chatIDs, entry.ChatID)
+		}
+	}
+
+	described, err := ms.describe(ctx, viewerID, msgs)
+	if err != nil {
+		return dtos.MentionsResponse{}, err
+	}
+
+	for i, msg := range described {
+		result.Mentions = append(result.Mentions, dtos.MentionFeedEntry{Message: msg, ChatID: chatIDs[i]})
+	}
+
+	return result, nil
+}
+
+// MarkRead advances the user's read marker in the message's chat up to the
+// message. Markers never move backwards.
+func (ms *MessageService) MarkRead(ctx context.Context, userID uuid.UUID, messageID uuid.UUID) error {
+	msgEntity, err := ms.msgRepo.ReadByID(ctx, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve message: %w", err)
+	}
+	if msgEntity == nil {
+		return ErrMessageNotFound
+	}
+
+	err = ms.permissions.CheckPermissionByTag(ctx, msgEntity.ChatTag, userID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return err
+	}
+
+	if err := ms.receiptRepo.MarkRead(ctx, userID, msgEntity); err != nil {
+		return fmt.Errorf("failed to advance read marker: %w", err)
+	}
+
+	ms.logger.Debug(ctx, "read marker advanced",
+		option.Any("message_id", messageID.String()),
+		option.Any("user_id", userID.String()),
+	)
+
+	return nil
+}
+
+// React adds the user's reaction to a message. Any chat participant may
+// react, including read-only ones.
+func (ms *MessageService) React(ctx context.Context, userID uuid.UUID, req dtos.ReactionRequest) error {
+	msgEntity, err := ms.reactableMessage(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+
+	reaction := entities.NewMessageReaction(msgEntity.ID, userID, req.Emoji)
+	if err := ms.reactionRepo.Create(ctx, reaction); err != nil {
+		return fmt.Errorf("failed to save reaction: %w", err)
+	}
+
+	return nil
+}
+
+func (ms *MessageService) Unreact(ctx context.Context, userID uuid.UUID, req dtos.ReactionRequest) error {
+	msgEntity, err := ms.reactableMessage(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+
+	if err := ms.reactionRepo.Delete(ctx, msgEntity.ID, userID, req.Emoji); err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	return nil
+}
+
+func (ms *MessageService) reactableMessage(ctx context.Context, userID uuid.UUID,
+	req dtos.ReactionRequest) (*entities.Message, error) {
+	if !entities.ValidEmoji(req.Emoji) {
+		return nil, ErrInvalidReaction
+	}
+
+	msgEntity, err := ms.msgRepo.ReadByID(ctx, req.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve message: %w", err)
+	}
+	if msgEntity == nil {
+		return nil, ErrMessageNotFound
+	}
+	if msgEntity.DeletedAt != nil {
+		return nil, ErrMessageDeleted
+	}
+
+	err = ms.permissions.CheckPermissionByTag(ctx, msgEntity.ChatTag, userID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgEntity, nil
+}
+
+// Update replaces the content of the actor's own message. The previous
+// content is kept as a revision and mentions are taken from the new one. Like
+// posting, editing in a direct chat stops once the other user blocks the
+// author.
+func (ms *MessageService) Update(ctx context.Context, actorID uuid.UUID, msg dtos.Message) error {
+	if strings.TrimSpace(msg.Content) == "" {
+		return ErrEmptyMessage
+	}
+
 	msgEntity, err := ms.msgRepo.ReadByID(ctx, msg.ID)
 	if err != nil {
 		return fmt.Errorf("failed to check existence of message: %w", err)
 	}
 	if msgEntity == nil {
-		return errors.New("message doesn't exist")
+		return ErrMessageNotFound
+	}
+	if msgEntity.DeletedAt != nil {
+		return ErrMessageDeleted
+	}
+	if msgEntity.UserID != actorID {
+		return ErrChatPermissionDenied
+	}
+	// Authors who lost the right to post can't edit what they posted.
+	err = ms.permissions.CheckPermissionByTag(ctx, msgEntity.ChatTag, actorID, entities.ChatPermissionPostMessage)
+	if err != nil {
+		return err
+	}
+	if err = ms.blocks.CheckDirectBlockByTag(ctx, msgEntity.ChatTag, actorID); err != nil {
+		return err
+	}
+	if msgEntity.Content == msg.Content {
+		return nil
+	}
+
+	editedAt := time.Now()
+	revision := entities.NewMessageRevision(msgEntity.ID, msgEntity.Content, editedAt)
+	msgEntity.Content = msg.Content
+	msgEntity.EditedAt = &editedAt
+
+	mentions, err := ms.resolveMentions(ctx, msgEntity)
+	if err != nil {
+		return err
+	}
+
+	err = ms.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := ms.msgRepo.CreateRevision(ctx, tx, revision); err != nil {
+			return fmt.Errorf("failed to save revision: %w", err)
+		}
+		if err := ms.msgRepo.Update(ctx, tx, msgEntity); err != nil {
+			return err
+		}
+		if err := ms.mentionRepo.DeleteByMessage(ctx, tx, msgEntity.ID); err != nil {
+			return fmt.Errorf("failed to remove old mentions: %w", err)
+		}
+		if err := ms.mentionRepo.Create(ctx, tx, msgEntity.ChatTag, msgEntity.CreatedAt, mentions); err != nil {
+			return fmt.Errorf("failed to save mentions: %w", err)
+		}
+
+		event := entities.NewMessageEvent(entities.MessageUpdated, *msgEntity, actorID)
+		event.Mentions = mentions
+
+		return ms.publish(ctx, tx, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	ms.logger.Debug(ctx, "message edited", option.Any("message_id", msgEntity.ID.String()))
+
+	return nil
+}
+
+// GetRevisions returns the earlier contents of the actor's own message,
+// oldest first.
+func (ms *MessageService) GetRevisions(ctx context.Context, actorID uuid.UUID, id uuid.UUID) (dtos.MessageRevisionsResponse, error) {
+	msgEntity, err := ms.msgRepo.ReadByID(ctx, id)
+	if err != nil {
+		return dtos.MessageRevisionsResponse{}, fmt.Errorf("failed to check existence of message: %w", err)
+	}
+	if msgEntity == nil {
+		return dtos.MessageRevisionsResponse{}, ErrMessageNotFound
+	}
+	if msgEntity.UserID != actorID {
+		return dtos.MessageRevisionsResponse{}, ErrChatPermissionDenied
+	}
+
+	revisions, err := ms.msgRepo.ReadRevisions(ctx, id)
+	if err != nil {
+		return dtos.MessageRevisionsResponse{}, fmt.Errorf("failed to read revisions: %w", err)
+	}
+
+	resp := dtos.MessageRevisionsResponse{
+		MessageID: id,
+		Revisions: make([]dtos.MessageRevision, 0, len(revisions)),
+	}
+	for _, rev := range revisions {
+		resp.Revisions = append(resp.Revisions, dtos.MessageRevision{
+			Content:  rev.Content,
+			EditedAt: rev.EditedAt,
+		})
 	}
 
-	return ms.msgRepo.Update(ctx, msgEntity)
+	return resp, nil
 }
 
-func (ms *MessageService) Delete(ctx context.Context, id uuid.UUID) error {
+// Delete turns a message into a tombstone. Deleting someone else's message
+// requires the corresponding chat permission.
+func (ms *MessageService) Delete(ctx context.Context, actorID uuid.UUID, id uuid.UUID) error {
 	msgEntity, err := ms.msgRepo.ReadByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to check existence of message: %w", err)
 	}
 	if msgEntity == nil {
-		return errors.New("message doesn't exist")
+		return ErrMessageNotFound
+	}
+	if msgEntity.UserID != actorID {
+		err = ms.permissions.CheckPermissionByTag(ctx, msgEntity.ChatTag, actorID, entities.ChatPermissionDeleteOthersMessages)
+		if err != nil {
+			return err
+		}
+	}
+	if msgEntity.DeletedAt != nil {
+		return nil
+	}
+
+	deletedAt := time.Now()
+	err = ms.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := ms.msgRepo.Delete(ctx, tx, id, deletedAt); err != nil {
+			return err
+		}
+
+		msgEntity.Content = ""
+		msgEntity.DeletedAt = &deletedAt
+
+		return ms.publish(ctx, tx, entities.NewMessageEvent(entities.MessageDeleted, *msgEntity, actorID))
+	})
+	if err != nil {
+		return err
+	}
+
+	ms.logger.Info(ctx, "message deleted",
+		option.Any("message_id", id.String()),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return nil
+}
+
+// resolveMentions finds the @tags in the message that belong to participants
+// of its chat. Authors mentioning themselves are ignored.
+func (ms *MessageService) resolveMentions(ctx context.Context, msg *entities.Message) ([]entities.MessageMention, error) {
+	candidates := entities.ParseMentions(msg.Content)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	users, err := ms.mentionRepo.ResolveParticipants(ctx, msg.ChatTag, entities.MentionTags(candidates))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mentions: %w", err)
+	}
+
+	var mentions []entities.MessageMention
+	for _, c := range candidates {
+		userID, ok := users[strings.ToLower(c.Tag)]
+		if !ok || userID == msg.UserID {
+			continue
+		}
+		mentions = append(mentions, entities.MessageMention{
+			MessageID: msg.ID,
+			UserID:    userID,
+			Offset:    c.Offset,
+			Length:    c.Length,
+		})
+	}
+
+	return mentions, nil
+}
+
+// describe turns messages into DTOs carrying their attachments, mentions,
+// reply counts, reactions as seen by the viewer and a quote of the message
+// each one replies to.
+func (ms *MessageService) describe(ctx context.Context, viewerID uuid.UUID,
+	msgs []entities.Message) ([]dtos.Message, error) {
+	ids := make([]uuid.UUID, 0, len(msgs))
+	parentIDs := make([]uuid.UUID, 0, len(msgs))
+	for _, msg := range msgs {
+		ids = append(ids, msg.ID)
+		if msg.ReplyToID != uuid.Nil {
+			parentIDs = append(parentIDs, msg.ReplyToID)
+		}
+	}
+
+	replyCounts, err := ms.msgRepo.CountReplies(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count replies: %w", err)
+	}
+
+	reactionCounts, err := ms.reactionRepo.CountByMessages(ctx, ids, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reactions: %w", err)
+	}
+
+	reactions := make(map[uuid.UUID][]dtos.Reaction, len(msgs))
+	for _, count := range reactionCounts {
+		reactions[count.MessageID] = append(reactions[count.MessageID], dtos.Reaction{
+			Emoji:       count.Emoji,
+			Count:       count.Count,
+			ReactedByMe: count.ReactedByMe,
+		})
+	}
+
+	found, err := ms.attachmentRepo.ReadByMessageIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachments: %w", err)
+	}
+
+	attachments := make(map[uuid.UUID][]dtos.Attachment, len(msgs))
+	for _, a := range found {
+		attachments[*a.MessageID] = append(attachments[*a.MessageID], toAttachmentDto(a))
+	}
+
+	foundMentions, err := ms.mentionRepo.ReadByMessageIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mentions: %w", err)
+	}
+
+	mentions := make(map[uuid.UUID][]dtos.Mention, len(msgs))
+	for _, m := range foundMentions {
+		mentions[m.MessageID] = append(mentions[m.MessageID], dtos.Mention{
+			UserID: m.UserID,
+			Offset: m.Offset,
+			Length: m.Length,
+		})
+	}
+
+	parents := make(map[uuid.UUID]entities.Message, len(parentIDs))
+	if len(parentIDs) > 0 {
+		found, err := ms.msgRepo.ReadByIDs(ctx, parentIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve replied messages: %w", err)
+		}
+		for _, parent := range found {
+			parents[parent.ID] = parent
+		}
+	}
+
+	result := make([]dtos.Message, 0, len(msgs))
+	for i := range msgs {
+		dto := toMessageDto(&msgs[i])
+		dto.ReplyCount = replyCounts[msgs[i].ID]
+		dto.Reactions = reactions[msgs[i].ID]
+		if msgs[i].DeletedAt == nil {
+			dto.Attachments = attachments[msgs[i].ID]
+			dto.Mentions = mentions[msgs[i].ID]
+		}
+		if parent, ok := parents[msgs[i].ReplyToID]; ok {
+			dto.Quote = &dtos.MessageQuote{
+				ID:      parent.ID,
+				UserID:  parent.UserID,
+				Snippet: snippet(parent.Content, quoteSnippetLen),
+				Deleted: parent.DeletedAt != nil,
+			}
+		}
+		result = append(result, dto)
+	}
+
+	return result, nil
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
+
+// snippet cuts the text to at most maxLen characters, marking the cut with
+// an ellipsis.
+func snippet(text string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text
+	}
+
+	return strings.TrimSpace(string(runes[:maxLen])) + "…"
+}
+
+// highlight escapes a search snippet for HTML and marks the matched words
+// with <mark>.
+func highlight(snippet string) string {
+	return strings.NewReplacer(
+		entities.SearchHighlightStart, "<mark>",
+		entities.SearchHighlightStop, "</mark>",
+	).Replace(html.EscapeString(snippet))
+}
+
+func toMessageDto(msg *entities.Message) dtos.Message {
+	return dtos.Message{
+		ID:        msg.ID,
+		ReplyToID: msg.ReplyToID,
+		UserID:    msg.UserID,
+		ChatTag:   msg.ChatTag,
+		Content:   msg.Content,
+		CreatedAt: msg.CreatedAt,
+		EditedAt:  msg.EditedAt,
+		Deleted:   msg.DeletedAt != nil,
 	}
-	return ms.msgRepo.Delete(ctx, id)
 }
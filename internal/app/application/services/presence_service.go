@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	// onlineTTL is how long a heartbeat keeps a user online. Clients are
+	// expected to send heartbeats noticeably more often.
+	onlineTTL        = 60 * time.Second
+	typingTTL        = 6 * time.Second
+	maxPresenceBatch = 100
+)
+
+var (
+	ErrPresenceBatchTooLarge  = errors.New("presence can be requested for at most 100 users at once")
+	ErrInvalidLastSeenSetting = errors.New("last seen visibility must be 'everyone' or 'nobody'")
+)
+
+type PresenceCache interface {
+	MarkOnline(ctx context.Context, userID uuid.UUID, ttl time.Duration) error
+	MarkOffline(ctx context.Context, userID uuid.UUID) error
+	ReadOnline(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	ReadHeartbeats(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]time.Time, error)
+	ClaimExpiredHeartbeats(ctx context.Context, before time.Time, limit int) (map[uuid.UUID]time.Time, error)
+	SetTyping(ctx context.Context, chatID, userID uuid.UUID, ttl time.Duration) error
+	ClearTyping(ctx context.Context, chatID, userID uuid.UUID) error
+	ReadTyping(ctx context.Context, chatID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type PresenceRepository interface {
+	UpdateLastSeen(ctx context.Context, userID uuid.UUID, at time.Time) error
+	UpdateLastSeenVisibility(ctx context.Context, userID uuid.UUID, visibility entities.LastSeenVisibility) error
+	ReadLastSeen(ctx context.Context, userIDs []uuid.UUID) ([]entities.LastSeen, error)
+}
+
+// BlockerReader tells which of the users have blocked a given one.
+type BlockerReader interface {
+	ReadBlockers(ctx context.Context, blockedID uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error)
+}
+
+type PresenceService struct {
+	cache       PresenceCache
+	repo        PresenceRepository
+	permissions ChatPermissionChecker
+	blocks      BlockerReader
+	logger      logger.Logger
+}
+
+func NewPresenceService(cache PresenceCache, repo PresenceRepository, permissions ChatPermissionChecker,
+	blocks BlockerReader, logger logger.Logger) *PresenceService {
+	return &PresenceService{
+		cache:       cache,
+		repo:        repo,
+		permissions: permissions,
+		blocks:      blocks,
+		logger:      logger,
+	}
+}
+
+// Heartbeat keeps the user online for another onlineTTL. It only touches
+// the cache; the last-seen time is saved once the user goes offline, or by
+// PresenceSweeper once the heartbeat expires.
+func (ps *PresenceService) Heartbeat(ctx context.Context, userID uuid.UUID) error {
+	if err := ps.cache.MarkOnline(ctx, userID, onlineTTL); err != nil {
+		return fmt.Errorf("mark online: %w", err)
+	}
+
+	return nil
+}
+
+func (ps *PresenceService) GoOffline(ctx context.Context, userID uuid.UUID) error {
+	if err := ps.cache.MarkOffline(ctx, userID); err != nil {
+		return fmt.Errorf("mark offline: %w", err)
+	}
+
+	if err := ps.repo.UpdateLastSeen(ctx, userID, time.Now()); err != nil {
+		return fmt.Errorf("update last seen: %w", err)
+	}
+
+	return nil
+}
+
+// GetPresence reports which of the users are online. Offline users come with
+// their last-seen time unless they have hidden it; users always see their
+// own. Users who blocked the viewer always appear offline with no last-seen
+// time.
+func (ps *PresenceService) GetPresence(ctx context.Context, viewerID uuid.UUID,
+	userIDs []uuid.UUID) (dtos.PresenceResponse, error) {
+	if len(userIDs) > maxPresenceBatch {
+		return dtos.PresenceResponse{}, ErrPresenceBatchTooLarge
+	}
+
+	online, err := ps.cache.ReadOnline(ctx, userIDs)
+	if err != nil {
+		return dtos.PresenceResponse{}, fmt.Errorf("read online users: %w", err)
+	}
+
+	lastSeen, err := ps.repo.ReadLastSeen(ctx, userIDs)
+	if err != nil {
+		return dtos.PresenceResponse{}, fmt.Errorf("read last seen: %w", err)
+	}
+
+	// Heartbeats that expired but weren't saved yet are newer than the
+	// saved last-seen times.
+	heartbeats, err := ps.cache.ReadHeartbeats(ctx, userIDs)
+	if err != nil {
+		return dtos.PresenceResponse{}, fmt.Errorf("read heartbeats: %w", err)
+	}
+
+	blockers, err := ps.blocks.ReadBlockers(ctx, viewerID, userIDs)
+	if err != nil {
+		return dtos.PresenceResponse{}, fmt.Errorf("read blockers: %w", err)
+	}
+	hidden := make(map[uuid.UUID]bool, len(blockers))
+	for _, id := range blockers {
+		hidden[id] = true
+	}
+
+	users := make([]dtos.Presence, 0, len(lastSeen))
+	for _, record := range lastSeen {
+		if hidden[record.UserID] {
+			users = append(users, dtos.Presence{UserID: record.UserID})
+			continue
+		}
+
+		presence := dtos.Presence{
+			UserID: record.UserID,
+			Online: online[record.UserID],
+		}
+		if !presence.Online && (record.Visibility == entities.LastSeenEveryone || record.UserID == viewerID) {
+			presence.LastSeenAt = record.At
+			if at, ok := heartbeats[record.UserID]; ok && (record.At == nil || at.After(*record.At)) {
+				presence.LastSeenAt = &at
+			}
+		}
+		users = append(users, presence)
+	}
+
+	return dtos.PresenceResponse{Users: users}, nil
+}
+
+func (ps *PresenceService) SetLastSeenVisibility(ctx context.Context, userID uuid.UUID,
+	visibility entities.LastSeenVisibility) error {
+	if !visibility.Valid() {
+		return ErrInvalidLastSeenSetting
+	}
+
+	if err := ps.repo.UpdateLastSeenVisibility(ctx, userID, visibility); err != nil {
+		return fmt.Errorf("update last seen visibility: %w", err)
+	}
+
+	ps.logger.Info(ctx, "last seen visibility changed",
+		option.Any("user_id", userID.String()),
+		option.Any("visibility", string(visibility)),
+	)
+
+	return nil
+}
+
+// SetTyping starts or stops the user's typing indicator in the chat. A
+// started indicator fades on its own after typingTTL.
+func (ps *PresenceService) SetTyping(ctx context.Context, userID uuid.UUID, typing dtos.Typing) error {
+	err := ps.permissions.CheckPermission(ctx, typing.ChatID, userID, entities.ChatPermissionPostMessage)
+	if err != nil {
+		return err
+	}
+
+	if !typing.Typing {
+		return ps.cache.ClearTyping(ctx, typing.ChatID, userID)
+	}
+
+	return ps.cache.SetTyping(ctx, typing.ChatID, userID, typingTTL)
+}
+
+// GetTyping lists who is typing in the chat besides the viewer. Like in
+// GetPresence, users who blocked the viewer are left out.
+func (ps *PresenceService) GetTyping(ctx context.Context, viewerID uuid.UUID, chatID uuid.UUID) (dtos.TypingResponse, error) {
+	err := ps.permissions.CheckPermission(ctx, chatID, viewerID, entities.ChatPermissionReadMessages)
+	if err != nil {
+		return dtos.TypingResponse{}, err
+	}
+
+	userIDs, err := ps.cache.ReadTyping(ctx, chatID)
+	if err != nil {
+		return dtos.TypingResponse{}, fmt.Errorf("read typing users: %w", err)
+	}
+
+	blockers, err := ps.blocks.ReadBlockers(ctx, viewerID, userIDs)
+	if err != nil {
+		return dtos.TypingResponse{}, fmt.Errorf("read blockers: %w", err)
+	}
+	hidden := make(map[uuid.UUID]bool, len(blockers)+1)
+	hidden[viewerID] = true
+	for _, id := range blockers {
+		hidden[id] = true
+	}
+
+	typing := make([]uuid.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !hidden[id] {
+			typing = append(typing, id)
+		}
+	}
+
+	return dtos.TypingResponse{ChatID: chatID, UserIDs: typing}, nil
+}
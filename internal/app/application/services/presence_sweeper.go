@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+const presenceSweepBatchSize = 100
+
+// PresenceSweeper saves the last-seen time of users whose heartbeats ran
+// out without them going offline explicitly, e.g. because they closed the
+// app or lost their connection.
+type PresenceSweeper struct {
+	cache    PresenceCache
+	repo     PresenceRepository
+	interval time.Duration
+	logger   logger.Logger
+}
+
+func NewPresenceSweeper(cache PresenceCache, repo PresenceRepository, interval time.Duration,
+	logger logger.Logger) *PresenceSweeper {
+	return &PresenceSweeper{
+		cache:    cache,
+		repo:     repo,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run saves expired heartbeats every interval until ctx is done.
+func (ps *PresenceSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(ps.interval)
+	defer ticker.Stop()
+
+	for {
+		ps.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep keeps claiming batches of expired heartbeats until none are left.
+// A claimed heartbeat that fails to save is only logged, leaving the user
+// with the last-seen time saved before.
+func (ps *PresenceSweeper) sweep(ctx context.Context) {
+	for ctx.Err() == nil {
+		expired, err := ps.cache.ClaimExpiredHeartbeats(ctx, time.Now().Add(-onlineTTL), presenceSweepBatchSize)
+		if err != nil {
+			ps.logger.Error(ctx, "failed to claim expired heartbeats", option.Error(err))
+			return
+		}
+
+		for userID, at := range expired {
+			if err = ps.repo.UpdateLastSeen(ctx, userID, at); err != nil {
+				ps.logger.Warn(ctx, "failed to save last seen",
+					option.Any("user_id", userID.String()),
+					option.Error(err),
+				)
+			}
+		}
+
+		if len(expired) < presenceSweepBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+func TestPresenceSweeperSavesExpiredHeartbeats(t *testing.T) {
+	now := time.Now()
+	cache := &fakePresenceCache{heartbeats: make(map[uuid.UUID]time.Time)}
+	expired := make(map[uuid.UUID]time.Time, presenceSweepBatchSize+1)
+	for i := 0; i < presenceSweepBatchSize+1; i++ {
+		id := uuid.New()
+		expired[id] = now.Add(-onlineTTL - time.Duration(i+1)*time.Second)
+		cache.heartbeats[id] = expired[id]
+	}
+	active := uuid.New()
+	cache.heartbeats[active] = now.Add(-time.Second)
+	repo := &fakePresenceRepo{lastSeen: make(map[uuid.UUID]time.Time)}
+
+	NewPresenceSweeper(cache, repo, time.Minute, nopLogger{}).sweep(context.Background())
+
+	if len(repo.lastSeen) != len(expired) {
+		t.Fatalf("saved %d last-seen times, want %d", len(repo.lastSeen), len(expired))
+	}
+	for id, at := range expired {
+		if !repo.lastSeen[id].Equal(at) {
+			t.Fatalf("last seen of %s = %v, want %v", id, repo.lastSeen[id], at)
+		}
+	}
+	if _, ok := cache.heartbeats[active]; !ok || len(cache.heartbeats) != 1 {
+		t.Fatalf("heartbeats left = %v, want only the active user's", cache.heartbeats)
+	}
+}
+
+func TestHeartbeatLeavesLastSeenAlone(t *testing.T) {
+	cache := &fakePresenceCache{heartbeats: make(map[uuid.UUID]time.Time)}
+	repo := &fakePresenceRepo{lastSeen: make(map[uuid.UUID]time.Time)}
+	svc := NewPresenceService(cache, repo, fakePermissions{}, nil, nopLogger{})
+	userID := uuid.New()
+
+	if err := svc.Heartbeat(context.Background(), userID); err != nil {
+		t.Fatalf("Heartbeat() error = %v", err)
+	}
+	if len(repo.lastSeen) != 0 {
+		t.Fatal("Heartbeat() wrote the last-seen time")
+	}
+
+	if err := svc.GoOffline(context.Background(), userID); err != nil {
+		t.Fatalf("GoOffline() error = %v", err)
+	}
+	if _, ok := repo.lastSeen[userID]; !ok {
+		t.Fatal("GoOffline() didn't save the last-seen time")
+	}
+	if _, ok := cache.heartbeats[userID]; ok {
+		t.Fatal("GoOffline() left the heartbeat")
+	}
+}
+
+// fakePresenceCache keeps heartbeats only; nobody is typing.
+type fakePresenceCache struct {
+	heartbeats map[uuid.UUID]time.Time
+}
+
+func (c *fakePresenceCache) MarkOnline(_ context.Context, userID uuid.UUID, _ time.Duration) error {
+	c.heartbeats[userID] = time.Now()
+	return nil
+}
+
+func (c *fakePresenceCache) MarkOffline(_ context.Context, userID uuid.UUID) error {
+	delete(c.heartbeats, userID)
+	return nil
+}
+
+func (c *fakePresenceCache) ReadOnline(context.Context, []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return nil, nil
+}
+
+func (c *fakePresenceCache) ReadHeartbeats(context.Context, []uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	return c.heartbeats, nil
+}
+
+func (c *fakePresenceCache) ClaimExpiredHeartbeats(_ context.Context, before time.Time,
+	limit int) (map[uuid.UUID]time.Time, error) {
+	claimed := make(map[uuid.UUID]time.Time)
+	for id, at := range c.heartbeats {
+		if len(claimed) == limit {
+			break
+		}
+		if !at.After(before) {
+			claimed[id] = at
+			delete(c.heartbeats, id)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (c *fakePresenceCache) SetTyping(context.Context, uuid.UUID, uuid.UUID, time.Duration) error {
+	return nil
+}
+
+func (c *fakePresenceCache) ClearTyping(context.Context, uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (c *fakePresenceCache) ReadTyping(context.Context, uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+type fakePresenceRepo struct {
+	lastSeen map[uuid.UUID]time.Time
+}
+
+func (r *fakePresenceRepo) UpdateLastSeen(_ context.Context, userID uuid.UUID, at time.Time) error {
+	r.lastSeen[userID] = at
+	return nil
+}
+
+func (r *fakePresenceRepo) UpdateLastSeenVisibility(context.Context, uuid.UUID, entities.LastSeenVisibility) error {
+	return nil
+}
+
+func (r *fakePresenceRepo) ReadLastSeen(context.Context, []uuid.UUID) ([]entities.LastSeen, error) {
+	return nil, nil
+}
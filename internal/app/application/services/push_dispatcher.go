@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	pushQueueBatchSize = 50
+	// pushSendBatchSize is how many notifications go to the provider at
+	// once; FCM takes up to 500 per multicast.
+	pushSendBatchSize = 500
+	// pushLease keeps claimed messages off limits to other dispatchers. A
+	// message that couldn't be prepared is retried once it runs out.
+	pushLease = time.Minute
+	// Devices a message's notification failed on are retried after
+	// pushRetryDelay, doubling with every attempt, and given up on after
+	// pushMaxAttempts; later than that the notification would be stale.
+	pushMaxAttempts = 4
+	pushRetryDelay  = 15 * time.Second
+	pushSnippetLen  = 120
+)
+
+// PushProvider delivers notifications to devices. It reports outcomes by
+// notification ID; notifications without one count as failed, and an error
+// means none were delivered.
+type PushProvider interface {
+	Send(ctx context.Context, notifications []entities.PushNotification) ([]entities.PushResult, error)
+}
+
+type PushQueueRepository interface {
+	Enqueue(ctx context.Context, tx pgx.Tx, messageID uuid.UUID, queuedAt time.Time) error
+	Claim(ctx context.Context, limit uint64, lease time.Duration) ([]entities.PushQueueItem, error)
+	Retry(ctx context.Context, item entities.PushQueueItem, nextAttemptAt time.Time) error
+	Remove(ctx context.Context, messageIDs []uuid.UUID) error
+}
+
+type ChatTagReader interface {
+	ReadByTag(ctx context.Context, tag string) (*entities.Chat, error)
+}
+
+type MentionReader interface {
+	ReadByMessageIDs(ctx context.Context, ids []uuid.UUID) ([]entities.MessageMention, error)
+}
+
+// NotificationRecipientFinder decides who is to be notified of a new
+// message according to their chat preferences.
+type NotificationRecipientFinder interface {
+	NotificationRecipients(ctx context.Context, chatID, authorID uuid.UUID, mentioned []uuid.UUID) ([]uuid.UUID, error)
+}
+
+type OnlineReader interface {
+	ReadOnline(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+// PushDispatcher sends push notifications of new messages to the devices of
+// participants who aren't online. Messages are queued in the transaction
+// creating them; Run then notifies of queued messages in the background and
+// queues them again for the devices that failed.
+type PushDispatcher struct {
+	queue      PushQueueRepository
+	tokens     DeviceTokenRepository
+	msgRepo    MessageReader
+	chats      ChatTagReader
+	users      UserProfileReader
+	mentions   MentionReader
+	recipients NotificationRecipientFinder
+	presence   OnlineReader
+	provider   PushProvider
+	interval   time.Duration
+	logger     logger.Logger
+}
+
+func NewPushDispatcher(queue PushQueueRepository, tokens DeviceTokenRepository, msgRepo MessageReader,
+	chats ChatTagReader, users UserProfileReader, mentions MentionReader, recipients NotificationRecipientFinder,
+	presence OnlineReader, provider PushProvider, interval time.Duration, logger logger.Logger) *PushDispatcher {
+	return &PushDispatcher{
+		queue:      queue,
+		tokens:     tokens,
+		msgRepo:    msgRepo,
+		chats:      chats,
+		users:      users,
+		mentions:   mentions,
+		recipients: recipients,
+		presence:   presence,
+		provider:   provider,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// HandleMessageEvent queues new messages. Edits and deletions aren't pushed.
+func (pd *PushDispatcher) HandleMessageEvent(ctx context.Context, tx pgx.Tx, event entities.MessageEvent) error {
+	if event.Kind != entities.MessageCreated {
+		return nil
+	}
+
+	return pd.queue.Enqueue(ctx, tx, event.Message.ID, event.OccurredAt)
+}
+
+// Run drains the queue every interval until ctx is done.
+func (pd *PushDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pd.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			processed, err := pd.processBatch(ctx)
+			if err != nil {
+				pd.logger.Error(ctx, "failed to dispatch push notifications", option.Error(err))
+				break
+			}
+			if processed < pushQueueBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (pd *PushDispatcher) processBatch(ctx context.Context) (int, error) {
+	items, err := pd.queue.Claim(ctx, pushQueueBatchSize, pushLease)
+	if err != nil {
+		return 0, fmt.Errorf("claim queued messages: %w", err)
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	var notifications []entities.PushNotification
+	prepared := make([]entities.PushQueueItem, 0, len(items))
+	for _, item := range items {
+		n, err := pd.prepare(ctx, item)
+		if err != nil {
+			pd.logger.Warn(ctx, "failed to prepare push notifications",
+				option.Any("message_id", item.MessageID.String()),
+				option.Error(err),
+			)
+			continue
+		}
+		notifications = append(notifications, n...)
+		prepared = append(prepared, item)
+	}
+
+	failed := pd.deliver(ctx, notifications)
+
+	now := time.Now()
+	done := make([]uuid.UUID, 0, len(prepared))
+	for _, item := range prepared {
+		tokens := failed[item.MessageID]
+		if len(tokens) == 0 {
+			done = append(done, item.MessageID)
+			continue
+		}
+
+		item.Attempts++
+		item.Tokens = tokens
+		if item.Attempts >= pushMaxAttempts {
+			pd.logger.Warn(ctx, "giving up on push notifications",
+				option.Any("message_id", item.MessageID.String()),
+				option.Any("devices", len(tokens)),
+				option.Any("attempts", item.Attempts),
+			)
+			done = append(done, item.MessageID)
+			continue
+		}
+
+		// A message that can't be put back is sent to every device again
+		// once its lease runs out.
+		if err = pd.queue.Retry(ctx, item, now.Add(pushBackoff(item.Attempts))); err != nil {
+			pd.logger.Error(ctx, "failed to requeue push notifications",
+				option.Any("message_id", item.MessageID.String()),
+				option.Error(err),
+			)
+		}
+	}
+
+	if err = pd.queue.Remove(ctx, done); err != nil {
+		return 0, fmt.Errorf("remove dispatched messages from queue: %w", err)
+	}
+
+	return len(items), nil
+}
+
+// prepare builds the notifications of a queued message for every device of
+// the recipients who are offline, or for those of its devices a retry is
+// limited to.
+func (pd *PushDispatcher) prepare(ctx context.Context,
+	item entities.PushQueueItem) ([]entities.PushNotification, error) {
+	msg, err := pd.msgRepo.ReadByID(ctx, item.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("read message: %w", err)
+	}
+	if msg == nil || msg.DeletedAt != nil {
+		return nil, nil
+	}
+
+	chat, err := pd.chats.ReadByTag(ctx, msg.ChatTag)
+	if err != nil {
+		return nil, fmt.Errorf("read chat: %w", err)
+	}
+	if chat == nil {
+		return nil, nil
+	}
+
+	mentions, err := pd.mentions.ReadByMessageIDs(ctx, []uuid.UUID{msg.ID})
+	if err != nil {
+		return nil, fmt.Errorf("read mentions: %w", err)
+	}
+	mentioned := make([]uuid.UUID, 0, len(mentions))
+	for _, m := range mentions {
+		mentioned = append(mentioned, m.UserID)
+	}
+
+	recipients, err := pd.recipients.NotificationRecipients(ctx, chat.Id, msg.UserID, mentioned)
+	if err != nil {
+		return nil, fmt.Errorf("find recipients: %w", err)
+	}
+	if len(recipients) == 0 {
+		return nil, nil
+	}
+
+	online, err := pd.presence.ReadOnline(ctx, recipients)
+	if err != nil {
+		return nil, fmt.Errorf("read online users: %w", err)
+	}
+	offline := make([]uuid.UUID, 0, len(recipients))
+	for _, id := range recipients {
+		if !online[id] {
+			offline = append(offline, id)
+		}
+	}
+
+	devices, err := pd.tokens.ReadByUsers(ctx, offline)
+	if err != nil {
+		return nil, fmt.Errorf("read device tokens: %w", err)
+	}
+	if len(item.Tokens) > 0 {
+		devices = onlyTokens(devices, item.Tokens)
+	}
+	if len(devices) == 0 {
+		return nil, nil
+	}
+
+	title, body, err := pd.describe(ctx, chat, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]entities.PushNotification, 0, len(devices))
+	for _, device := range devices {
+		notifications = append(notifications, entities.PushNotification{
+			ID:        uuid.New(),
+			MessageID: msg.ID,
+			Token:     device.Token,
+			Platform:  device.Platform,
+			UserID:    device.UserID,
+			Title:     title,
+			Body:      body,
+			Data: map[string]string{
+				"chat_id":    chat.Id.String(),
+				"message_id": msg.ID.String(),
+			},
+		})
+	}
+
+	return notifications, nil
+}
+
+// describe returns the title and text of a message's notification: the
+// author's name over the text in direct chats, the chat's title over the
+// author's name and the text in groups.
+func (pd *PushDispatcher) describe(ctx context.Context, chat *entities.Chat,
+	msg *entities.Message) (string, string, error) {
+	author, err := pd.users.ReadById(ctx, msg.UserID)
+	if err != nil {
+		return "", "", fmt.Errorf("read author: %w", err)
+	}
+
+	name := "Someone"
+	if author != nil {
+		name = author.Tag
+		if author.Name != "" {
+			name = author.Name
+		}
+	}
+
+	text := snippet(msg.Content, pushSnippetLen)
+	if text == "" {
+		text = "Sent an attachment"
+	}
+
+	if chat.Kind == entities.ChatKindDirect {
+		return name, text, nil
+	}
+
+	return chat.Title, name + ": " + text, nil
+}
+
+// deliver makes one attempt at sending the notifications, in batches, and
+// forgets tokens the provider reports invalid. It returns the tokens that
+// failed by message, for the caller to retry later.
+func (pd *PushDispatcher) deliver(ctx context.Context,
+	notifications []entities.PushNotification) map[uuid.UUID][]string {
+	var (
+		failed    = make(map[uuid.UUID][]string)
+		invalid   []string
+		delivered int
+		failures  int
+	)
+	for start := 0; start < len(notifications); start += pushSendBatchSize {
+		batch := notifications[start:min(start+pushSendBatchSize, len(notifications))]
+
+		// Nothing was delivered when the provider fails, so every
+		// notification of the batch is left without an outcome.
+		results, err := pd.provider.Send(ctx, batch)
+		if err != nil {
+			pd.logger.Warn(ctx, "push provider failed",
+				option.Any("notifications", len(batch)),
+				option.Error(err),
+			)
+		}
+
+		outcomes := make(map[uuid.UUID]entities.PushOutcome, len(results))
+		for _, res := range results {
+			outcomes[res.NotificationID] = res.Outcome
+		}
+		for _, n := range batch {
+			switch outcomes[n.ID] {
+			case entities.PushDelivered:
+				delivered++
+			case entities.PushInvalidToken:
+				invalid = append(invalid, n.Token)
+			default:
+				failed[n.MessageID] = append(failed[n.MessageID], n.Token)
+				failures++
+			}
+		}
+	}
+
+	if err := pd.tokens.DeleteTokens(ctx, invalid); err != nil {
+		pd.logger.Warn(ctx, "failed to prune invalid device tokens", option.Error(err))
+	}
+
+	pd.logger.Debug(ctx, "push notifications sent",
+		option.Any("delivered", delivered),
+		option.Any("invalid", len(invalid)),
+		option.Any("failed", failures),
+	)
+
+	return failed
+}
+
+// pushBackoff is the delay before the attempt following the given number of
+// failed ones.
+func pushBackoff(attempts int) time.Duration {
+	return pushRetryDelay << (attempts - 1)
+}
+
+func onlyTokens(devices []entities.DeviceToken, tokens []string) []entities.DeviceToken {
+	wanted := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		wanted[t] = true
+	}
+
+	kept := devices[:0]
+	for _, d := range devices {
+		if wanted[d.Token] {
+			kept = append(kept, d)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/app/infrastructure/push"
+)
+
+func TestPushDispatcherWritesToFileProvider(t *testing.T) {
+	world := newPushWorld()
+	msg := world.post("hello")
+
+	path := filepath.Join(t.TempDir(), "push.jsonl")
+	provider, err := push.NewFileProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileProvider() error = %v", err)
+	}
+	dispatcher, queue, _ := world.dispatcher(provider)
+	queue.enqueue(msg.ID)
+
+	processBatch(t, dispatcher)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open push log: %v", err)
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line struct {
+			Token   string `json:"token"`
+			Payload struct {
+				Message struct {
+					Notification struct {
+						Title string `json:"title"`
+						Body  string `json:"body"`
+					} `json:"notification"`
+				} `json:"message"`
+			} `json:"payload"`
+		}
+		if err = json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decode push log line: %v", err)
+		}
+		if n := line.Payload.Message.Notification; n.Title != "Team" || n.Body != "Alice: hello" {
+			t.Fatalf("notification = %q / %q, want %q / %q", n.Title, n.Body, "Team", "Alice: hello")
+		}
+		tokens = append(tokens, line.Token)
+	}
+
+	slices.Sort(tokens)
+	if want := []string{"bob-phone", "bob-tablet"}; !slices.Equal(tokens, want) {
+		t.Fatalf("notified devices = %v, want %v", tokens, want)
+	}
+	if queue.len() != 0 {
+		t.Fatal("delivered message is still queued")
+	}
+}
+
+func TestPushDispatcherRequeuesFailedDevices(t *testing.T) {
+	world := newPushWorld()
+	msg := world.post("hello")
+
+	gateway := newFakeGateway(t, func(n gatewayNotification, _ int) entities.PushOutcome {
+		switch n.Token {
+		case "bob-tablet":
+			return entities.PushFailed
+		case "dave-old-phone":
+			return entities.PushInvalidToken
+		default:
+			return entities.PushDelivered
+		}
+	})
+	dispatcher, queue, tokens := world.dispatcher(push.NewHTTPProvider(gateway.url))
+	world.offline = append(world.offline, world.dave)
+	queue.enqueue(msg.ID)
+
+	start := time.Now()
+	processBatch(t, dispatcher)
+
+	item, due := queue.get(msg.ID)
+	if item.Attempts != 1 || !slices.Equal(item.Tokens, []string{"bob-tablet"}) {
+		t.Fatalf("queued retry = %+v, want 1 attempt for bob-tablet", item)
+	}
+	if want := start.Add(pushRetryDelay); due.Before(want) {
+		t.Fatalf("retry is due at %s, want no earlier than %s", due, want)
+	}
+	if !slices.Equal(tokens.deleted, []string{"dave-old-phone"}) {
+		t.Fatalf("deleted tokens = %v, want [dave-old-phone]", tokens.deleted)
+	}
+
+	// Nothing is due yet.
+	processBatch(t, dispatcher)
+	if calls := gateway.calls(); len(calls) != 1 {
+		t.Fatalf("gateway called %d times before the retry was due, want 1", len(calls))
+	}
+
+	gateway.setOutcome(func(gatewayNotification, int) entities.PushOutcome { return entities.PushDelivered })
+	queue.makeDue(msg.ID)
+	processBatch(t, dispatcher)
+
+	calls := gateway.calls()
+	if len(calls) != 2 || len(calls[1]) != 1 || calls[1][0].Token != "bob-tablet" {
+		t.Fatalf("retry went to %v, want only bob-tablet", calls[len(calls)-1])
+	}
+	if queue.len() != 0 {
+		t.Fatal("delivered message is still queued")
+	}
+}
+
+func TestPushDispatcherGivesUp(t *testing.T) {
+	world := newPushWorld()
+	msg := world.post("hello")
+
+	gateway := newFakeGateway(t, nil)
+	gateway.status = http.StatusServiceUnavailable
+	dispatcher, queue, _ := world.dispatcher(push.NewHTTPProvider(gateway.url))
+	queue.enqueue(msg.ID)
+
+	for attempt := 1; attempt <= pushMaxAttempts; attempt++ {
+		queue.makeDue(msg.ID)
+		processBatch(t, dispatcher)
+
+		item, _ := queue.get(msg.ID)
+		if attempt < pushMaxAttempts && item.Attempts != attempt {
+			t.Fatalf("attempts after attempt %d = %d", attempt, item.Attempts)
+		}
+	}
+
+	if len(gateway.calls()) != pushMaxAttempts {
+		t.Fatalf("gateway called %d times, want %d", len(gateway.calls()), pushMaxAttempts)
+	}
+	if queue.len() != 0 {
+		t.Fatal("message is still queued after the last attempt")
+	}
+}
+
+func TestPushDispatcherMatchesOutcomesByNotification(t *testing.T) {
+	world := newPushWorld()
+	world.devices = world.devices[:1] // bob-phone only
+	first := world.post("first")
+	second := world.post("second")
+
+	// Both messages go to the same device in one batch; only the second one
+	// fails.
+	gateway := newFakeGateway(t, func(_ gatewayNotification, i int) entities.PushOutcome {
+		if i == 1 {
+			return entities.PushFailed
+		}
+		return entities.PushDelivered
+	})
+	dispatcher, queue, _ := world.dispatcher(push.NewHTTPProvider(gateway.url))
+	queue.enqueue(first.ID)
+	queue.enqueue(second.ID)
+
+	processBatch(t, dispatcher)
+
+	if _, due := queue.get(first.ID); !due.IsZero() {
+		t.Fatal("delivered message is still queued")
+	}
+	if item, _ := queue.get(second.ID); item.Attempts != 1 || !slices.Equal(item.Tokens, []string{"bob-phone"}) {
+		t.Fatalf("queued retry = %+v, want 1 attempt for bob-phone", item)
+	}
+}
+
+func processBatch(t *testing.T, dispatcher *PushDispatcher) {
+	t.Helper()
+
+	if _, err := dispatcher.processBatch(context.Background()); err != nil {
+		t.Fatalf("processBatch() error = %v", err)
+	}
+}
+
+// pushWorld is a group chat where Alice posts and Bob, Carol and Dave are
+// notified. Only Bob is offline to begin with.
+type pushWorld struct {
+	alice, bob, carol, dave uuid.UUID
+
+	chat     entities.Chat
+	messages map[uuid.UUID]*entities.Message
+	devices  []entities.DeviceToken
+	offline  []uuid.UUID
+}
+
+func newPushWorld() *pushWorld {
+	alice, bob, carol, dave := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	return &pushWorld{
+		alice: alice,
+		bob:   bob,
+		carol: carol,
+		dave:  dave,
+
+		chat:     entities.Chat{Id: uuid.New(), Tag: "team", Title: "Team", Kind: entities.ChatKindGroup},
+		messages: make(map[uuid.UUID]*entities.Message),
+		devices: []entities.DeviceToken{
+			entities.NewDeviceToken("bob-phone", bob, entities.PushPlatformFCM),
+			entities.NewDeviceToken("bob-tablet", bob, entities.PushPlatformFCM),
+			entities.NewDeviceToken("carol-phone", carol, entities.PushPlatformFCM),
+			entities.NewDeviceToken("dave-old-phone", dave, entities.PushPlatformAPNs),
+		},
+		offline: []uuid.UUID{bob},
+	}
+}
+
+func (w *pushWorld) post(content string) *entities.Message {
+	msg := entities.NewMessage(uuid.New(), uuid.Nil, w.alice, w.chat.Tag, content, time.Now())
+	w.messages[msg.ID] = msg
+
+	return msg
+}
+
+func (w *pushWorld) dispatcher(provider PushProvider) (*PushDispatcher, *fakePushQueue, *fakeDeviceTokens) {
+	queue := &fakePushQueue{items: make(map[uuid.UUID]*queuedPush)}
+	tokens := &fakeDeviceTokens{world: w}
+	dispatcher := NewPushDispatcher(queue, tokens, w, w, w, w, w, w, provider, time.Second, nopLogger{})
+
+	return dispatcher, queue, tokens
+}
+
+func (w *pushWorld) ReadByID(_ context.Context, id uuid.UUID) (*entities.Message, error) {
+	return w.messages[id], nil
+}
+
+func (w *pushWorld) ReadByTag(_ context.Context, tag string) (*entities.Chat, error) {
+	if tag != w.chat.Tag {
+		return nil, nil
+	}
+
+	return &w.chat, nil
+}
+
+func (w *pushWorld) ReadById(_ context.Context, id uuid.UUID) (*entities.UserAccount, error) {
+	if id != w.alice {
+		return nil, nil
+	}
+
+	return &entities.UserAccount{Id: w.alice, Tag: "alice", Name: "Alice"}, nil
+}
+
+func (w *pushWorld) ReadByMessageIDs(context.Context, []uuid.UUID) ([]entities.MessageMention, error) {
+	return nil, nil
+}
+
+func (w *pushWorld) NotificationRecipients(context.Context, uuid.UUID, uuid.UUID,
+	[]uuid.UUID) ([]uuid.UUID, error) {
+	return []uuid.UUID{w.bob, w.carol, w.dave}, nil
+}
+
+func (w *pushWorld) ReadOnline(_ context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	online := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		online[id] = !slices.Contains(w.offline, id)
+	}
+
+	return online, nil
+}
+
+type fakeDeviceTokens struct {
+	world   *pushWorld
+	deleted []string
+}
+
+func (d *fakeDeviceTokens) Upsert(context.Context, entities.DeviceToken) error {
+	return nil
+}
+
+func (d *fakeDeviceTokens) Delete(context.Context, uuid.UUID, string) error {
+	return nil
+}
+
+func (d *fakeDeviceTokens) DeleteTokens(_ context.Context, tokens []string) error {
+	d.deleted = append(d.deleted, tokens...)
+	d.world.devices = slices.DeleteFunc(d.world.devices, func(dt entities.DeviceToken) bool {
+		return slices.Contains(tokens, dt.Token)
+	})
+
+	return nil
+}
+
+func (d *fakeDeviceTokens) ReadByUsers(_ context.Context, userIDs []uuid.UUID) ([]entities.DeviceToken, error) {
+	var devices []entities.DeviceToken
+	for _, dt := range d.world.devices {
+		if slices.Contains(userIDs, dt.UserID) {
+			devices = append(devices, dt)
+		}
+	}
+
+	return devices, nil
+}
+
+type queuedPush struct {
+	item entities.PushQueueItem
+	due  time.Time
+}
+
+type fakePushQueue struct {
+	items map[uuid.UUID]*queuedPush
+	order []uuid.UUID
+}
+
+func (q *fakePushQueue) enqueue(messageID uuid.UUID) {
+	q.items[messageID] = &queuedPush{item: entities.PushQueueItem{MessageID: messageID}, due: time.Now()}
+	q.order = append(q.order, messageID)
+}
+
+func (q *fakePushQueue) get(messageID uuid.UUID) (entities.PushQueueItem, time.Time) {
+	queued, ok := q.items[messageID]
+	if !ok {
+		return entities.PushQueueItem{}, time.Time{}
+	}
+
+	return queued.item, queued.due
+}
+
+func (q *fakePushQueue) makeDue(messageID uuid.UUID) {
+	if queued, ok := q.items[messageID]; ok {
+		queued.due = time.Now()
+	}
+}
+
+func (q *fakePushQueue) len() int {
+	return len(q.items)
+}
+
+func (q *fakePushQueue) Enqueue(context.Context, pgx.Tx, uuid.UUID, time.Time) error {
+	return nil
+}
+
+func (q *fakePushQueue) Claim(_ context.Context, limit uint64, _ time.Duration) ([]entities.PushQueueItem, error) {
+	now := time.Now()
+
+	var items []entities.PushQueueItem
+	for _, id := range q.order {
+		queued, ok := q.items[id]
+		if ok && !queued.due.After(now) && uint64(len(items)) < limit {
+			items = append(items, queued.item)
+		}
+	}
+
+	return items, nil
+}
+
+func (q *fakePushQueue) Retry(_ context.Context, item entities.PushQueueItem, nextAttemptAt time.Time) error {
+	q.items[item.MessageID] = &queuedPush{item: item, due: nextAttemptAt}
+	return nil
+}
+
+func (q *fakePushQueue) Remove(_ context.Context, messageIDs []uuid.UUID) error {
+	for _, id := range messageIDs {
+		delete(q.items, id)
+	}
+
+	return nil
+}
+
+type gatewayNotification struct {
+	ID    uuid.UUID `json:"id"`
+	Token string    `json:"token"`
+}
+
+// fakeGateway is a push gateway that decides the outcome of every
+// notification with outcome, given the notification and its position in the
+// request.
+type fakeGateway struct {
+	url     string
+	status  int
+	outcome func(n gatewayNotification, i int) entities.PushOutcome
+
+	mu       sync.Mutex
+	requests [][]gatewayNotification
+}
+
+func newFakeGateway(t *testing.T, outcome func(gatewayNotification, int) entities.PushOutcome) *fakeGateway {
+	g := &fakeGateway{status: http.StatusOK, outcome: outcome}
+	srv := httptest.NewServer(http.HandlerFunc(g.serve))
+	t.Cleanup(srv.Close)
+	g.url = srv.URL
+
+	return g
+}
+
+func (g *fakeGateway) serve(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Notifications []gatewayNotification `json:"notifications"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	g.mu.Lock()
+	g.requests = append(g.requests, req.Notifications)
+	outcome := g.outcome
+	g.mu.Unlock()
+
+	if g.status != http.StatusOK {
+		w.WriteHeader(g.status)
+		return
+	}
+
+	type result struct {
+		ID     uuid.UUID            `json:"id"`
+		Status entities.PushOutcome `json:"status"`
+	}
+	results := make([]result, 0, len(req.Notifications))
+	// Answer in reverse to make sure outcomes aren't matched by position.
+	for i := len(req.Notifications) - 1; i >= 0; i-- {
+		n := req.Notifications[i]
+		results = append(results, result{ID: n.ID, Status: outcome(n, i)})
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"results": results})
+}
+
+func (g *fakeGateway) setOutcome(outcome func(gatewayNotification, int) entities.PushOutcome) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.outcome = outcome
+}
+
+func (g *fakeGateway) calls() [][]gatewayNotification {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.Clone(g.requests)
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	searchIndexBatchSize = 200
+	// searchIndexLease keeps a claimed batch off limits to other indexers.
+	// A batch that failed is retried once it runs out.
+	searchIndexLease = time.Minute
+	// reindexCatchUpMargin covers clock skew between this instance and
+	// Postgres when queuing the changes made during a rebuild.
+	reindexCatchUpMargin = time.Minute
+)
+
+type SearchQueueRepository interface {
+	Enqueue(ctx context.Context, tx pgx.Tx, messageID uuid.UUID, queuedAt time.Time) error
+	Claim(ctx context.Context, limit uint64, lease time.Duration) ([]entities.SearchQueueEntry, error)
+	Remove(ctx context.Context, entries []entities.SearchQueueEntry) error
+	EnqueueChangedSince(ctx context.Context, since time.Time) (int64, error)
+}
+
+// MessageDocumentReader reads live messages in the shape a search index
+// keeps them.
+type MessageDocumentReader interface {
+	ReadDocuments(ctx context.Context, ids []uuid.UUID) ([]entities.MessageDocument, error)
+	ReadDocumentsAfter(ctx context.Context, after uuid.UUID, limit uint64) ([]entities.MessageDocument, error)
+}
+
+// SearchIndexer keeps a search index that lives outside Postgres in sync
+// with messages. Message events queue the message in the same transaction
+// as the change; Run then indexes queued messages as they are now, or
+// removes them from the index once deleted.
+type SearchIndexer struct {
+	queue    SearchQueueRepository
+	docs     MessageDocumentReader
+	index    MessageSearchIndex
+	interval time.Duration
+	logger   logger.Logger
+}
+
+func NewSearchIndexer(queue SearchQueueRepository, docs MessageDocumentReader, index MessageSearchIndex,
+	interval time.Duration, logger logger.Logger) *SearchIndexer {
+	return &SearchIndexer{
+		queue:    queue,
+		docs:     docs,
+		index:    index,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (si *SearchIndexer) HandleMessageEvent(ctx context.Context, tx pgx.Tx, event entities.MessageEvent) error {
+	return si.queue.Enqueue(ctx, tx, event.Message.ID, event.OccurredAt)
+}
+
+// Run drains the queue every interval until ctx is done.
+func (si *SearchIndexer) Run(ctx context.Context) {
+	ticker := time.NewTicker(si.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			processed, err := si.processBatch(ctx)
+			if err != nil {
+				si.logger.Error(ctx, "failed to update search index", option.Error(err))
+				break
+			}
+			if processed < searchIndexBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (si *SearchIndexer) processBatch(ctx context.Context) (int, error) {
+	entries, err := si.queue.Claim(ctx, searchIndexBatchSize, searchIndexLease)
+	if err != nil {
+		return 0, fmt.Errorf("claim queued messages: %w", err)
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.MessageID)
+	}
+
+	docs, err := si.docs.ReadDocuments(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("read messages: %w", err)
+	}
+
+	live := make(map[uuid.UUID]bool, len(docs))
+	for _, doc := range docs {
+		live[doc.ID] = true
+	}
+	gone := make([]uuid.UUID, 0, len(ids)-len(docs))
+	for _, id := range ids {
+		if !live[id] {
+			gone = append(gone, id)
+		}
+	}
+
+	if err = si.index.Index(ctx, docs); err != nil {
+		return 0, fmt.Errorf("index messages: %w", err)
+	}
+	if err = si.index.Delete(ctx, gone); err != nil {
+		return 0, fmt.Errorf("remove deleted messages: %w", err)
+	}
+	if err = si.queue.Remove(ctx, entries); err != nil {
+		return 0, fmt.Errorf("remove processed messages from queue: %w", err)
+	}
+
+	si.logger.Debug(ctx, "search index updated",
+		option.Any("indexed", len(docs)),
+		option.Any("removed", len(gone)),
+	)
+
+	return len(entries), nil
+}
+
+// Reindex rebuilds the index out of every live message and returns how
+// many were indexed. The rebuild goes into a new version of the index that
+// replaces the live one only once it is complete, so searches keep working
+// meanwhile. Messages changed while it ran are queued again for Run, as the
+// new version may have missed their changes.
+func (si *SearchIndexer) Reindex(ctx context.Context) (int, error) {
+	startedAt := time.Now().Add(-reindexCatchUpMargin)
+
+	version, err := si.index.CreateVersion(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("create index version: %w", err)
+	}
+
+	var (
+		total int
+		after uuid.UUID
+	)
+	for {
+		docs, err := si.docs.ReadDocumentsAfter(ctx, after, searchIndexBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("read messages: %w", err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		if err = si.index.IndexVersion(ctx, version, docs); err != nil {
+			return total, fmt.Errorf("index messages: %w", err)
+		}
+
+		total += len(docs)
+		after = docs[len(docs)-1].ID
+
+		si.logger.Info(ctx, "reindexing messages", option.Any("indexed", total))
+	}
+
+	if err = si.index.ActivateVersion(ctx, version); err != nil {
+		return total, fmt.Errorf("activate index version: %w", err)
+	}
+
+	requeued, err := si.queue.EnqueueChangedSince(ctx, startedAt)
+	if err != nil {
+		return total, fmt.Errorf("queue messages changed during reindex: %w", err)
+	}
+
+	si.logger.Info(ctx, "queued messages changed during reindex", option.Any("queued", requeued))
+
+	return total, nil
+}
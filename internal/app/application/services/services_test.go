@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...option.LogOption) {}
+func (nopLogger) Info(context.Context, string, ...option.LogOption)  {}
+func (nopLogger) Warn(context.Context, string, ...option.LogOption)  {}
+func (nopLogger) Error(context.Context, string, ...option.LogOption) {}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	thumbnailBatchSize = 10
+	// thumbnailLease is how long a claimed image stays off limits to other
+	// workers. Generation failing on a transient error is retried after it.
+	thumbnailLease = 5 * time.Minute
+)
+
+type ThumbnailRepository interface {
+	ClaimPendingThumbnails(ctx context.Context, limit uint64, lease time.Duration) ([]entities.Attachment, error)
+	UpdatePreview(ctx context.Context, a entities.Attachment) error
+}
+
+// ThumbnailWorker generates previews for image attachments in the
+// background. Pending images are claimed through the database, so any
+// number of workers can run side by side.
+type ThumbnailWorker struct {
+	repo     ThumbnailRepository
+	store    BlobStore
+	images   ImageProcessor
+	interval time.Duration
+	wake     chan struct{}
+	logger   logger.Logger
+}
+
+// NewThumbnailWorker creates a worker that looks for pending images every
+// interval and whenever it is notified of a new one.
+func NewThumbnailWorker(repo ThumbnailRepository, store BlobStore, images ImageProcessor,
+	interval time.Duration, logger logger.Logger) *ThumbnailWorker {
+	return &ThumbnailWorker{
+		repo:     repo,
+		store:    store,
+		images:   images,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		logger:   logger,
+	}
+}
+
+// Notify wakes the worker up without waiting for the next tick.
+func (tw *ThumbnailWorker) Notify() {
+	select {
+	case tw.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes pending images until ctx is done.
+func (tw *ThumbnailWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(tw.interval)
+	defer ticker.Stop()
+
+	for {
+		tw.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-tw.wake:
+		}
+	}
+}
+
+// drain keeps claiming batches until none are left.
+func (tw *ThumbnailWorker) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		attachments, err := tw.repo.ClaimPendingThumbnails(ctx, thumbnailBatchSize, thumbnailLease)
+		if err != nil {
+			tw.logger.Error(ctx, "failed to claim pending thumbnails", option.Error(err))
+			return
+		}
+
+		for _, a := range attachments {
+			if err = tw.process(ctx, a); err != nil {
+				tw.logger.Warn(ctx, "failed to generate thumbnail",
+					option.Any("attachment_id", a.ID.String()),
+					option.Error(err),
+				)
+			}
+		}
+
+		if len(attachments) < thumbnailBatchSize {
+			return
+		}
+	}
+}
+
+// process renders the preview of a single image. An image that can't be
+// decoded is marked failed; storage errors leave it pending for a retry once
+// the lease runs out.
+func (tw *ThumbnailWorker) process(ctx context.Context, a entities.Attachment) error {
+	content, err := tw.store.Get(ctx, a.StorageKey)
+	if err != nil {
+		return fmt.Errorf("open original: %w", err)
+	}
+	if content == nil {
+		return tw.fail(ctx, a, "original is missing")
+	}
+
+	data, err := io.ReadAll(content)
+	_ = content.Close()
+	if err != nil {
+		return fmt.Errorf("read original: %w", err)
+	}
+
+	preview, err := tw.images.Preview(data)
+	if err != nil {
+		return tw.fail(ctx, a, err.Error())
+	}
+
+	key := a.ThumbnailStorageKey()
+	err = tw.store.Put(ctx, key, bytes.NewReader(preview.Thumbnail), int64(len(preview.Thumbnail)), "image/jpeg")
+	if err != nil {
+		return fmt.Errorf("store thumbnail: %w", err)
+	}
+
+	ready := entities.ThumbnailReady
+	a.Width = &preview.Width
+	a.Height = &preview.Height
+	a.Placeholder = &preview.Placeholder
+	a.ThumbnailKey = &key
+	a.ThumbnailStatus = &ready
+
+	if err = tw.repo.UpdatePreview(ctx, a); err != nil {
+		return fmt.Errorf("save preview: %w", err)
+	}
+
+	tw.logger.Debug(ctx, "thumbnail generated",
+		option.Any("attachment_id", a.ID.String()),
+		option.Any("width", preview.Width),
+		option.Any("height", preview.Height),
+	)
+
+	return nil
+}
+
+func (tw *ThumbnailWorker) fail(ctx context.Context, a entities.Attachment, reason string) error {
+	failed := entities.ThumbnailFailed
+	a.ThumbnailStatus = &failed
+
+	if err := tw.repo.UpdatePreview(ctx, a); err != nil {
+		return fmt.Errorf("mark thumbnail failed: %w", err)
+	}
+
+	tw.logger.Warn(ctx, "image preview can't be generated",
+		option.Any("attachment_id", a.ID.String()),
+		option.Any("reason", reason),
+	)
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+// maxUserSearchLen matches the longest tag or name an account can have.
+const maxUserSearchLen = 32
+
+var ErrInvalidUserSearch = errors.New("user search query must be 1 to 32 characters long")
+
+type UserDirectoryRepository interface {
+	Search(ctx context.Context, viewerID uuid.UUID, text string, limit, offset uint64) ([]entities.UserProfile, error)
+	UpdateDiscoverable(ctx context.Context, userID uuid.UUID, discoverable bool) error
+}
+
+// UserDirectoryService lets users find each other by tag or name. Users who
+// turned discoverability off are never listed.
+type UserDirectoryService struct {
+	repo   UserDirectoryRepository
+	logger logger.Logger
+}
+
+func NewUserDirectoryService(repo UserDirectoryRepository, logger logger.Logger) *UserDirectoryService {
+	return &UserDirectoryService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (uds *UserDirectoryService) Search(ctx context.Context, viewerID uuid.UUID, query string,
+	limit, offset int) (dtos.UserSearchResponse, error) {
+	query = strings.TrimPrefix(strings.TrimSpace(query), "@")
+	if query == "" || utf8.RuneCountInString(query) > maxUserSearchLen {
+		return dtos.UserSearchResponse{}, ErrInvalidUserSearch
+	}
+
+	limit, offset = normalizePage(limit, offset)
+
+	profiles, err := uds.repo.Search(ctx, viewerID, query, uint64(limit), uint64(offset))
+	if err != nil {
+		return dtos.UserSearchResponse{}, fmt.Errorf("search users: %w", err)
+	}
+
+	resp := dtos.UserSearchResponse{
+		Users:  make([]dtos.UserProfile, 0, len(profiles)),
+		Limit:  limit,
+		Offset: offset,
+	}
+	for _, profile := range profiles {
+		resp.Users = append(resp.Users, toUserProfileDto(profile))
+	}
+
+	return resp, nil
+}
+
+func (uds *UserDirectoryService) SetDiscoverable(ctx context.Context, userID uuid.UUID, discoverable bool) error {
+	if err := uds.repo.UpdateDiscoverable(ctx, userID, discoverable); err != nil {
+		return fmt.Errorf("update discoverability: %w", err)
+	}
+
+	uds.logger.Info(ctx, "discoverability changed",
+		option.Any("user_id", userID.String()),
+		option.Any("discoverable", discoverable),
+	)
+
+	return nil
+}
+
+func toUserProfileDto(profile entities.UserProfile) dtos.UserProfile {
+	return dtos.UserProfile{
+		ID:   profile.ID,
+		Tag:  profile.Tag,
+		Name: profile.Name,
+		Desc: profile.Desc,
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+	"github.com/renderview-inc/backend/internal/pkg/txhelper"
+)
+
+const (
+	webhookBatchSize = 20
+	// webhookLease keeps claimed deliveries off limits to other dispatchers
+	// while a batch is posted one by one.
+	webhookLease = 5 * time.Minute
+	// A failed delivery is retried after webhookRetryDelay, doubling with
+	// every attempt up to webhookMaxRetryDelay, and given up on after
+	// webhookMaxAttempts. Around an hour passes between the first attempt
+	// and the last.
+	webhookMaxAttempts    = 8
+	webhookRetryDelay     = 30 * time.Second
+	webhookMaxRetryDelay  = time.Hour
+	webhookDisableAfter   = 20
+	maxWebhookErrorLength = 500
+)
+
+// WebhookSender makes one attempt at a delivery. It returns the response
+// status, or 0 when there was no response.
+type WebhookSender interface {
+	Send(ctx context.Context, job entities.WebhookJob) (int, error)
+}
+
+// WebhookDispatcher posts chat events to the webhooks subscribed to them.
+// Deliveries are recorded in the transaction making the change; Run then
+// posts them in the background, retrying failures with exponential backoff.
+// A webhook is disabled after webhookDisableAfter failed attempts in a row.
+type WebhookDispatcher struct {
+	repo     WebhookRepository
+	chats    ChatTagReader
+	sender   WebhookSender
+	txHelper *txhelper.TxHelper
+	interval time.Duration
+	logger   logger.Logger
+}
+
+func NewWebhookDispatcher(repo WebhookRepository, chats ChatTagReader, sender WebhookSender,
+	txHelper *txhelper.TxHelper, interval time.Duration, logger logger.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo:     repo,
+		chats:    chats,
+		sender:   sender,
+		txHelper: txHelper,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (wd *WebhookDispatcher) HandleMessageEvent(ctx context.Context, tx pgx.Tx, event entities.MessageEvent) error {
+	chat, err := wd.chats.ReadByTag(ctx, event.Message.ChatTag)
+	if err != nil {
+		return fmt.Errorf("read chat: %w", err)
+	}
+	// Direct chats have no webhooks.
+	if chat == nil || chat.Kind == entities.ChatKindDirect {
+		return nil
+	}
+
+	msg := event.Message
+	mentions := make([]dtos.Mention, 0, len(event.Mentions))
+	for _, m := range event.Mentions {
+		mentions = append(mentions, dtos.Mention{UserID: m.UserID, Offset: m.Offset, Length: m.Length})
+	}
+
+	data := dtos.WebhookMessageData{
+		Message: dtos.WebhookMessage{
+			ID:        msg.ID,
+			ReplyToID: msg.ReplyToID,
+			UserID:    msg.UserID,
+			ChatTag:   msg.ChatTag,
+			Content:   msg.Content,
+			Mentions:  mentions,
+			CreatedAt: msg.CreatedAt,
+			EditedAt:  msg.EditedAt,
+			Deleted:   msg.DeletedAt != nil,
+		},
+		ActorID: event.ActorID,
+	}
+
+	return wd.enqueue(ctx, tx, chat.Id, entities.WebhookEventOfMessage(event.Kind), event.OccurredAt, data)
+}
+
+func (wd *WebhookDispatcher) HandleChatEvent(ctx context.Context, tx pgx.Tx, event entities.ChatEvent) error {
+	var data any
+	switch event.Kind {
+	case entities.ChatRenamed:
+		data = dtos.WebhookRenameData{Title: event.Title, ActorID: event.ActorID}
+	default:
+		data = dtos.WebhookMemberData{UserID: event.UserID, ActorID: event.ActorID}
+	}
+
+	return wd.enqueue(ctx, tx, event.ChatID, entities.WebhookEventOfChat(event.Kind), event.OccurredAt, data)
+}
+
+// enqueue records a delivery of the event for every webhook of the chat
+// subscribed to it.
+func (wd *WebhookDispatcher) enqueue(ctx context.Context, tx pgx.Tx, chatID uuid.UUID, event entities.WebhookEvent,
+	occurredAt time.Time, data any) error {
+	webhooks, err := wd.repo.ReadSubscribed(ctx, tx, chatID, event)
+	if err != nil {
+		return fmt.Errorf("read webhooks: %w", err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	deliveries := make([]entities.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		id := uuid.New()
+		payload, err := json.Marshal(dtos.WebhookPayload{
+			ID:         id,
+			Event:      string(event),
+			ChatID:     chatID,
+			OccurredAt: occurredAt,
+			Data:       data,
+		})
+		if err != nil {
+			return fmt.Errorf("encode webhook payload: %w", err)
+		}
+
+		deliveries = append(deliveries, entities.NewWebhookDelivery(id, webhook.ID, event, payload, now))
+	}
+
+	return wd.repo.CreateDeliveries(ctx, tx, deliveries)
+}
+
+// Run posts due deliveries every interval until ctx is done.
+func (wd *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(wd.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			jobs, err := wd.repo.ClaimDeliveries(ctx, webhookBatchSize, webhookLease)
+			if err != nil {
+				wd.logger.Error(ctx, "failed to claim webhook deliveries", option.Error(err))
+				break
+			}
+
+			for _, job := range jobs {
+				if err = wd.deliver(ctx, job); err != nil {
+					wd.logger.Error(ctx, "failed to record webhook delivery",
+						option.Any("delivery_id", job.Delivery.ID.String()),
+						option.Error(err),
+					)
+				}
+			}
+
+			if len(jobs) < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver makes an attempt at the delivery and records how it went. A
+// delivery whose outcome couldn't be recorded is attempted again once its
+// lease runs out.
+func (wd *WebhookDispatcher) deliver(ctx context.Context, job entities.WebhookJob) error {
+	status, sendErr := wd.sender.Send(ctx, job)
+	now := time.Now()
+
+	d := job.Delivery
+	d.Attempts++
+	d.ResponseStatus = nil
+	if status != 0 {
+		d.ResponseStatus = &status
+	}
+
+	if sendErr == nil {
+		d.Status = entities.WebhookDeliveryDelivered
+		d.DeliveredAt = &now
+		d.LastError = nil
+	} else {
+		reason := webhookErrorText(sendErr)
+		d.LastError = &reason
+		if d.Attempts >= webhookMaxAttempts {
+			d.Status = entities.WebhookDeliveryFailed
+		} else {
+			d.NextAttemptAt = now.Add(webhookBackoff(d.Attempts))
+		}
+	}
+
+	var disabled bool
+	err := wd.txHelper.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := wd.repo.UpdateDelivery(ctx, tx, d); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+
+		if sendErr == nil {
+			return wd.repo.ResetFailures(ctx, tx, d.WebhookID)
+		}
+
+		var err error
+		disabled, err = wd.repo.RecordFailure(ctx, tx, d.WebhookID, webhookDisableAfter, now)
+		if err != nil {
+			return fmt.Errorf("count failure: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if sendErr != nil {
+		wd.logger.Warn(ctx, "webhook delivery failed",
+			option.Any("delivery_id", d.ID.String()),
+			option.Any("webhook_id", d.WebhookID.String()),
+			option.Any("attempts", d.Attempts),
+			option.Any("status", string(d.Status)),
+			option.Error(sendErr),
+		)
+	} else {
+		wd.logger.Debug(ctx, "webhook delivered",
+			option.Any("delivery_id", d.ID.String()),
+			option.Any("webhook_id", d.WebhookID.String()),
+		)
+	}
+	if disabled {
+		wd.logger.Warn(ctx, "webhook disabled after repeated failures",
+			option.Any("webhook_id", d.WebhookID.String()),
+		)
+	}
+
+	return nil
+}
+
+// webhookBackoff is the delay before the attempt following the given number
+// of failed ones.
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookRetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, webhookMaxRetryDelay)
+}
+
+// webhookErrorText shortens the error for the delivery log and makes it safe
+// to store as text.
+func webhookErrorText(err error) string {
+	text := strings.ReplaceAll(strings.ToValidUTF8(err.Error(), ""), "\x00", "")
+	if runes := []rune(text); len(runes) > maxWebhookErrorLength {
+		text = string(runes[:maxWebhookErrorLength])
+	}
+
+	return text
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	webhookSecretLen = 32
+	maxWebhookURLLen = 2048
+)
+
+var (
+	ErrWebhookNotFound      = errors.New("webhook doesn't exist")
+	ErrInvalidWebhookURL    = errors.New("webhook URL must be an absolute http or https URL to a public host")
+	ErrInvalidWebhookEvents = errors.New("webhook must subscribe to at least one known event")
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook entities.Webhook) error
+	ReadByID(ctx context.Context, id uuid.UUID) (*entities.Webhook, error)
+	ReadByChat(ctx context.Context, chatID uuid.UUID) ([]entities.Webhook, error)
+	ReadSubscribed(ctx context.Context, tx pgx.Tx, chatID uuid.UUID,
+		event entities.WebhookEvent) ([]entities.Webhook, error)
+	Update(ctx context.Context, webhook entities.Webhook) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ResetFailures(ctx context.Context, tx pgx.Tx, id uuid.UUID) error
+	RecordFailure(ctx context.Context, tx pgx.Tx, id uuid.UUID, threshold int, at time.Time) (bool, error)
+	CreateDeliveries(ctx context.Context, tx pgx.Tx, deliveries []entities.WebhookDelivery) error
+	ReadDeliveries(ctx context.Context, webhookID uuid.UUID, limit, offset uint64) ([]entities.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit uint64, lease time.Duration) ([]entities.WebhookJob, error)
+	UpdateDelivery(ctx context.Context, tx pgx.Tx, delivery entities.WebhookDelivery) error
+}
+
+type ChatByIDReader interface {
+	ReadByID(ctx context.Context, id uuid.UUID) (*entities.Chat, error)
+}
+
+// WebhookService lets chat admins manage the webhooks of group chats and
+// look into their deliveries. Deliveries themselves are made by
+// WebhookDispatcher.
+type WebhookService struct {
+	repo        WebhookRepository
+	chats       ChatByIDReader
+	permissions ChatPermissionChecker
+	logger      logger.Logger
+}
+
+func NewWebhookService(repo WebhookRepository, chats ChatByIDReader, permissions ChatPermissionChecker,
+	logger logger.Logger) *WebhookService {
+	return &WebhookService{
+		repo:        repo,
+		chats:       chats,
+		permissions: permissions,
+		logger:      logger,
+	}
+}
+
+// Create subscribes a URL to the chat's events. The result carries the
+// secret deliveries are signed with; it isn't shown again.
+func (ws *WebhookService) Create(ctx context.Context, actorID uuid.UUID, req dtos.WebhookRequest) (dtos.Webhook, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return dtos.Webhook{}, err
+	}
+	events, err := parseWebhookEvents(req.Events)
+	if err != nil {
+		return dtos.Webhook{}, err
+	}
+
+	if err = ws.requireManager(ctx, req.ChatID, actorID); err != nil {
+		return dtos.Webhook{}, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return dtos.Webhook{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook := entities.NewWebhook(req.ChatID, actorID, req.URL, secret, events)
+	if err = ws.repo.Create(ctx, webhook); err != nil {
+		return dtos.Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	ws.logger.Info(ctx, "webhook created",
+		option.Any("webhook_id", webhook.ID.String()),
+		option.Any("chat_id", webhook.ChatID.String()),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	dto := toWebhookDto(webhook)
+	dto.Secret = secret
+
+	return dto, nil
+}
+
+func (ws *WebhookService) List(ctx context.Context, actorID uuid.UUID, chatID uuid.UUID) ([]dtos.Webhook, error) {
+	if err := ws.requireManager(ctx, chatID, actorID); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := ws.repo.ReadByChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+
+	result := make([]dtos.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		result = append(result, toWebhookDto(webhook))
+	}
+
+	return result, nil
+}
+
+// Update changes the webhook's URL, events or whether it is enabled.
+// Enabling it clears the failures that got it disabled, and its pending
+// deliveries go out again.
+func (ws *WebhookService) Update(ctx context.Context, actorID uuid.UUID, req dtos.WebhookRequest) (dtos.Webhook, error) {
+	webhook, err := ws.manageable(ctx, req.ID, actorID)
+	if err != nil {
+		return dtos.Webhook{}, err
+	}
+
+	if req.URL != "" {
+		if err = validateWebhookURL(req.URL); err != nil {
+			return dtos.Webhook{}, err
+		}
+		webhook.URL = req.URL
+	}
+	if req.Events != nil {
+		if webhook.Events, err = parseWebhookEvents(req.Events); err != nil {
+			return dtos.Webhook{}, err
+		}
+	}
+	if req.Enabled != nil && *req.Enabled != webhook.Enabled {
+		webhook.Enabled = *req.Enabled
+		if webhook.Enabled {
+			webhook.ConsecutiveFailures = 0
+			webhook.DisabledAt = nil
+		} else {
+			now := time.Now()
+			webhook.DisabledAt = &now
+		}
+	}
+
+	if err = ws.repo.Update(ctx, *webhook); err != nil {
+		return dtos.Webhook{}, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	ws.logger.Info(ctx, "webhook updated",
+		option.Any("webhook_id", webhook.ID.String()),
+		option.Any("enabled", webhook.Enabled),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return toWebhookDto(*webhook), nil
+}
+
+// Delete removes the webhook along with its delivery log.
+func (ws *WebhookService) Delete(ctx context.Context, actorID uuid.UUID, id uuid.UUID) error {
+	webhook, err := ws.manageable(ctx, id, actorID)
+	if err != nil {
+		return err
+	}
+
+	if err = ws.repo.Delete(ctx, webhook.ID); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	ws.logger.Info(ctx, "webhook deleted",
+		option.Any("webhook_id", webhook.ID.String()),
+		option.Any("chat_id", webhook.ChatID.String()),
+		option.Any("actor_id", actorID.String()),
+	)
+
+	return nil
+}
+
+// GetDeliveries returns the webhook's delivery log, newest first.
+func (ws *WebhookService) GetDeliveries(ctx context.Context, actorID uuid.UUID, id uuid.UUID,
+	limit, offset int) (dtos.WebhookDeliveriesResponse, error) {
+	webhook, err := ws.manageable(ctx, id, actorID)
+	if err != nil {
+		return dtos.WebhookDeliveriesResponse{}, err
+	}
+
+	limit, offset = normalizePage(limit, offset)
+
+	deliveries, err := ws.repo.ReadDeliveries(ctx, webhook.ID, uint64(limit), uint64(offset))
+	if err != nil {
+		return dtos.WebhookDeliveriesResponse{}, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+
+	result := make([]dtos.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		dto := dtos.WebhookDelivery{
+			ID:             d.ID,
+			Event:          string(d.Event),
+			Status:         string(d.Status),
+			Attempts:       d.Attempts,
+			ResponseStatus: d.ResponseStatus,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt,
+			DeliveredAt:    d.DeliveredAt,
+			Payload:        d.Payload,
+		}
+		if d.Status == entities.WebhookDeliveryPending {
+			dto.NextAttemptAt = &d.NextAttemptAt
+		}
+		result = append(result, dto)
+	}
+
+	return dtos.WebhookDeliveriesResponse{Deliveries: result, Limit: limit, Offset: offset}, nil
+}
+
+// manageable reads the webhook if the actor may manage the chat's webhooks.
+func (ws *WebhookService) manageable(ctx context.Context, id, actorID uuid.UUID) (*entities.Webhook, error) {
+	webhook, err := ws.repo.ReadByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook: %w", err)
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	if err = ws.permissions.CheckPermission(ctx, webhook.ChatID, actorID, entities.ChatPermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// requireManager checks that the chat is a group chat whose webhooks the
+// actor may manage.
+func (ws *WebhookService) requireManager(ctx context.Context, chatID, actorID uuid.UUID) error {
+	chat, err := ws.chats.ReadByID(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to check existence of chat: %w", err)
+	}
+	if chat == nil {
+		return ErrChatNotFound
+	}
+	if chat.Kind == entities.ChatKindDirect {
+		return ErrDirectChatRestricted
+	}
+
+	return ws.permissions.CheckPermission(ctx, chatID, actorID, entities.ChatPermissionManageWebhooks)
+}
+
+func validateWebhookURL(raw string) error {
+	if len(raw) > maxWebhookURLLen {
+		return ErrInvalidWebhookURL
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return ErrInvalidWebhookURL
+	}
+
+	// Names are checked again when the sender connects; this only turns
+	// away URLs that can never be delivered to.
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInvalidWebhookURL
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !entities.WebhookTargetAllowed(addr) {
+		return ErrInvalidWebhookURL
+	}
+
+	return nil
+}
+
+func parseWebhookEvents(names []string) ([]entities.WebhookEvent, error) {
+	events := make([]entities.WebhookEvent, 0, len(names))
+	for _, name := range names {
+		event := entities.WebhookEvent(name)
+		if !event.Valid() {
+			return nil, ErrInvalidWebhookEvents
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return nil, ErrInvalidWebhookEvents
+	}
+
+	return events, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	bytes := make([]byte, webhookSecretLen)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
+
+func toWebhookDto(w entities.Webhook) dtos.Webhook {
+	events := make([]string, 0, len(w.Events))
+	for _, e := range w.Events {
+		events = append(events, string(e))
+	}
+
+	return dtos.Webhook{
+		ID:                  w.ID,
+		ChatID:              w.ChatID,
+		URL:                 w.URL,
+		Events:              events,
+		CreatedBy:           w.CreatedBy,
+		CreatedAt:           w.CreatedAt,
+		Enabled:             w.Enabled,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		DisabledAt:          w.DisabledAt,
+	}
+}
@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ThumbnailStatus tracks preview generation for image attachments. Other
+// attachments have none.
+type ThumbnailStatus string
+
+const (
+	ThumbnailPending ThumbnailStatus = "pending"
+	ThumbnailReady   ThumbnailStatus = "ready"
+	ThumbnailFailed  ThumbnailStatus = "failed"
+)
+
+// Attachment describes an uploaded file. It belongs to a chat from the start
+// and to a message once it has been sent with one.
+type Attachment struct {
+	ID         uuid.UUID
+	ChatID     uuid.UUID
+	UploaderID uuid.UUID
+	MessageID  *uuid.UUID
+	FileName   string
+	MimeType   string
+	Size       int64
+	Checksum   string
+	StorageKey string
+	CreatedAt  time.Time
+
+	// Width, Height and Placeholder are filled in once an image's preview
+	// has been generated, together with the thumbnail's key.
+	Width           *int
+	Height          *int
+	Placeholder     *string
+	ThumbnailKey    *string
+	ThumbnailStatus *ThumbnailStatus
+}
+
+func NewAttachment(chatID, uploaderID uuid.UUID, fileName, mimeType string) Attachment {
+	id := uuid.New()
+
+	var status *ThumbnailStatus
+	if IsImageType(mimeType) {
+		pending := ThumbnailPending
+		status = &pending
+	}
+
+	return Attachment{
+		ID:         id,
+		ChatID:     chatID,
+		UploaderID: uploaderID,
+		FileName:   fileName,
+		MimeType:   mimeType,
+		StorageKey: "attachments/" + chatID.String() + "/" + id.String(),
+		CreatedAt:  time.Now(),
+
+		ThumbnailStatus: status,
+	}
+}
+
+// ThumbnailStorageKey is where the preview of the attachment is kept.
+func (a Attachment) ThumbnailStorageKey() string {
+	return a.StorageKey + ".thumb"
+}
+
+func IsImageType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "image/")
+}
@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BotScope grants an API key access to a group of endpoints. Bots can only
+// ever reach chats they were added to.
+type BotScope string
+
+const (
+	BotScopeReadChats     BotScope = "chats:read"
+	BotScopeReadMessages  BotScope = "messages:read"
+	BotScopeWriteMessages BotScope = "messages:write"
+	// BotScopeCommands lets a bot receive the slash commands registered for
+	// it and answer them.
+	BotScopeCommands BotScope = "commands"
+)
+
+func (s BotScope) Valid() bool {
+	switch s {
+	case BotScopeReadChats, BotScopeReadMessages, BotScopeWriteMessages, BotScopeCommands:
+		return true
+	default:
+		return false
+	}
+}
+
+// Bot is an account used by automation instead of a person. It has a user
+// account of its own, so it takes part in chats like anyone else, but it
+// can't log in; it authenticates with API keys issued by its owner.
+type Bot struct {
+	ID        uuid.UUID
+	OwnerID   uuid.UUID
+	Tag       string
+	Name      string
+	Desc      string
+	CreatedAt time.Time
+}
+
+func NewBot(ownerID uuid.UUID, tag, name, desc string) Bot {
+	return Bot{
+		ID:        uuid.New(),
+		OwnerID:   ownerID,
+		Tag:       tag,
+		Name:      name,
+		Desc:      desc,
+		CreatedAt: time.Now(),
+	}
+}
+
+// BotKey is an API key of a bot. Only the hash of the key is kept.
+type BotKey struct {
+	ID         uuid.UUID
+	BotID      uuid.UUID
+	Name       string
+	KeyHash    string
+	Scopes     []BotScope
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func NewBotKey(id, botID uuid.UUID, name, keyHash string, scopes []BotScope, expiresAt *time.Time) BotKey {
+	return BotKey{
+		ID:        id,
+		BotID:     botID,
+		Name:      name,
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+func (k BotKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// BotIdentity is who a request authenticated with an API key comes from.
+type BotIdentity struct {
+	BotID  uuid.UUID
+	KeyID  uuid.UUID
+	Scopes []BotScope
+}
+
+func (bi BotIdentity) Allows(scope BotScope) bool {
+	return slices.Contains(bi.Scopes, scope)
+}
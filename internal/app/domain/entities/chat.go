@@ -6,12 +6,20 @@ import (
 	"github.com/google/uuid"
 )
 
+type ChatKind string
+
+const (
+	ChatKindGroup  ChatKind = "group"
+	ChatKindDirect ChatKind = "direct"
+)
+
 type Chat struct {
 	Id        uuid.UUID
 	Tag       string
 	OwnerId   uuid.UUID
 	CreatedAt time.Time
 	Title     string
+	Kind      ChatKind
 }
 
 func NewChat(tag string, ownerId uuid.UUID, title string) Chat {
@@ -21,5 +29,58 @@ func NewChat(tag string, ownerId uuid.UUID, title string) Chat {
 		OwnerId:   ownerId,
 		CreatedAt: time.Now(),
 		Title:     title,
+		Kind:      ChatKindGroup,
+	}
+}
+
+// NewDirectChat creates a conversation between two users. Its tag is an
+// internal key for messages and its title is derived for each viewer.
+func NewDirectChat(tag string, initiatorID uuid.UUID) Chat {
+	return Chat{
+		Id:        uuid.New(),
+		Tag:       tag,
+		OwnerId:   initiatorID,
+		CreatedAt: time.Now(),
+		Kind:      ChatKindDirect,
+	}
+}
+
+// DirectChat links a direct conversation to its two users, stored in a fixed
+// order so that a pair maps to a single chat.
+type DirectChat struct {
+	ChatID   uuid.UUID
+	UserLow  uuid.UUID
+	UserHigh uuid.UUID
+}
+
+func NewDirectChatPair(chatID, firstUserID, secondUserID uuid.UUID) DirectChat {
+	low, high := OrderUserPair(firstUserID, secondUserID)
+
+	return DirectChat{
+		ChatID:   chatID,
+		UserLow:  low,
+		UserHigh: high,
 	}
 }
+
+// OrderUserPair returns the two IDs in the order direct chats are keyed by.
+func OrderUserPair(first, second uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if first.String() < second.String() {
+		return first, second
+	}
+
+	return second, first
+}
+
+// Other returns the user on the other side of the conversation.
+func (dc DirectChat) Other(userID uuid.UUID) uuid.UUID {
+	if dc.UserLow == userID {
+		return dc.UserHigh
+	}
+
+	return dc.UserLow
+}
+
+func (dc DirectChat) Has(userID uuid.UUID) bool {
+	return dc.UserLow == userID || dc.UserHigh == userID
+}
@@ -0,0 +1,126 @@
+package entities
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Commands every chat has. They are handled by the server itself and can't
+// be registered for a bot.
+const (
+	CommandHelp  = "help"
+	CommandTopic = "topic"
+)
+
+const maxCommandNameLen = 32
+
+// ChatCommand routes "/<name> ..." messages in a chat to a bot.
+type ChatCommand struct {
+	ChatID    uuid.UUID
+	Name      string
+	BotID     uuid.UUID
+	Desc      string
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+}
+
+func NewChatCommand(chatID uuid.UUID, name string, botID uuid.UUID, desc string, createdBy uuid.UUID) ChatCommand {
+	return ChatCommand{
+		ChatID:    chatID,
+		Name:      name,
+		BotID:     botID,
+		Desc:      desc,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+}
+
+// CommandInvocation is a command sent to a bot, waiting for the bot to pick
+// it up and answer it. ChatTag is filled in when invocations are claimed.
+type CommandInvocation struct {
+	ID        uuid.UUID
+	ChatID    uuid.UUID
+	ChatTag   string
+	BotID     uuid.UUID
+	UserID    uuid.UUID
+	Command   string
+	Args      string
+	CreatedAt time.Time
+	ClaimedAt *time.Time
+}
+
+func NewCommandInvocation(chatID, botID, userID uuid.UUID, command, args string) CommandInvocation {
+	return CommandInvocation{
+		ID:        uuid.New(),
+		ChatID:    chatID,
+		BotID:     botID,
+		UserID:    userID,
+		Command:   command,
+		Args:      args,
+		CreatedAt: time.Now(),
+	}
+}
+
+// CommandReply is an ephemeral answer to a command, visible only to the user
+// who invoked it. Command and ChatID are filled in when replies are read.
+type CommandReply struct {
+	ID           uuid.UUID
+	InvocationID uuid.UUID
+	Command      string
+	ChatID       uuid.UUID
+	Content      string
+	CreatedAt    time.Time
+}
+
+func NewCommandReply(invocationID uuid.UUID, content string) CommandReply {
+	return CommandReply{
+		ID:           uuid.New(),
+		InvocationID: invocationID,
+		Content:      content,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// ParseCommand splits "/<name> <args>" into the command name, in lower case,
+// and its arguments. Text that doesn't start with a valid command name, such
+// as "/etc/hosts", isn't a command.
+func ParseCommand(text string) (string, string, bool) {
+	rest, found := strings.CutPrefix(text, "/")
+	if !found {
+		return "", "", false
+	}
+
+	name, args := rest, ""
+	if i := strings.IndexFunc(rest, unicode.IsSpace); i >= 0 {
+		name, args = rest[:i], strings.TrimSpace(rest[i:])
+	}
+
+	name = strings.ToLower(name)
+	if !ValidCommandName(name) {
+		return "", "", false
+	}
+
+	return name, args, true
+}
+
+// ValidCommandName reports whether the name is 1 to 32 lower case letters,
+// digits or '_'.
+func ValidCommandName(name string) bool {
+	if name == "" || len(name) > maxCommandNameLen {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func IsBuiltinCommand(name string) bool {
+	return name == CommandHelp || name == CommandTopic
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChatEventKind string
+
+const (
+	ChatMemberJoined ChatEventKind = "member_joined"
+	ChatMemberLeft   ChatEventKind = "member_left"
+	ChatRenamed      ChatEventKind = "renamed"
+)
+
+// ChatEvent describes a change to a chat made by ActorID. UserID is the
+// participant who joined or left; Title is the new title of a renamed chat.
+type ChatEvent struct {
+	Kind       ChatEventKind
+	ChatID     uuid.UUID
+	UserID     uuid.UUID
+	ActorID    uuid.UUID
+	Title      string
+	OccurredAt time.Time
+}
+
+func NewChatEvent(kind ChatEventKind, chatID, userID, actorID uuid.UUID) ChatEvent {
+	return ChatEvent{
+		Kind:       kind,
+		ChatID:     chatID,
+		UserID:     userID,
+		ActorID:    actorID,
+		OccurredAt: time.Now(),
+	}
+}
@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChatInviteEventType string
+
+const (
+	ChatInviteCreated ChatInviteEventType = "created"
+	ChatInviteUsed    ChatInviteEventType = "used"
+	ChatInviteRevoked ChatInviteEventType = "revoked"
+)
+
+type ChatInvite struct {
+	ID        uuid.UUID
+	ChatID    uuid.UUID
+	Code      string
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	MaxUses   *int
+	Uses      int
+	RevokedAt *time.Time
+}
+
+func NewChatInvite(chatID uuid.UUID, code string, createdBy uuid.UUID, expiresAt *time.Time, maxUses *int) ChatInvite {
+	return ChatInvite{
+		ID:        uuid.New(),
+		ChatID:    chatID,
+		Code:      code,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+		MaxUses:   maxUses,
+	}
+}
+
+// Usable reports whether the invite can still be used to join the chat.
+func (ci ChatInvite) Usable(now time.Time) bool {
+	if ci.RevokedAt != nil {
+		return false
+	}
+	if ci.ExpiresAt != nil && !now.Before(*ci.ExpiresAt) {
+		return false
+	}
+
+	return ci.MaxUses == nil || ci.Uses < *ci.MaxUses
+}
+
+type ChatInviteEvent struct {
+	ID         uuid.UUID
+	InviteID   uuid.UUID
+	UserID     uuid.UUID
+	Event      ChatInviteEventType
+	OccurredAt time.Time
+}
+
+func NewChatInviteEvent(inviteID, userID uuid.UUID, event ChatInviteEventType) ChatInviteEvent {
+	return ChatInviteEvent{
+		ID:         uuid.New(),
+		InviteID:   inviteID,
+		UserID:     userID,
+		Event:      event,
+		OccurredAt: time.Now(),
+	}
+}
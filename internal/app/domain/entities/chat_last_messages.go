@@ -1,21 +1,24 @@
 package entities
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// ChatLastMessages is a chat as listed in a user's inbox: the chat, the
+// user's read marker and preferences in it and the latest message, if any.
 type ChatLastMessages struct {
-	ID        uuid.UUID
-	ChatID    uuid.UUID
-	UserID    uuid.UUID
-	Message   string
-	Timestamp int64
+	ChatID      uuid.UUID
+	ChatTag     string
+	Title       string
+	Kind        ChatKind
+	Role        ChatRole
+	LastReadAt  *time.Time
+	MutedUntil  *time.Time
+	NotifyLevel NotifyLevel
+	Archived    bool
+	UnreadCount int
+	ActivityAt  time.Time
+	LastMessage *Message
 }
-
-func NewChatLastMessages(id, chatID, userID uuid.UUID, message string, timestamp int64) *ChatLastMessages {
-	return &ChatLastMessages{
-		ID:        id,
-		ChatID:    chatID,
-		UserID:    userID,
-		Message:   message,
-		Timestamp: timestamp,
-	}
-}
\ No newline at end of file
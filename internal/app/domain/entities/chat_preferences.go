@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotifyLevel decides which messages of a chat a participant is notified
+// about.
+type NotifyLevel string
+
+const (
+	NotifyAll      NotifyLevel = "all"
+	NotifyMentions NotifyLevel = "mentions"
+)
+
+func (l NotifyLevel) Valid() bool {
+	return l == NotifyAll || l == NotifyMentions
+}
+
+// ChatPreferences are a participant's own settings for a chat.
+type ChatPreferences struct {
+	ChatID      uuid.UUID
+	UserID      uuid.UUID
+	MutedUntil  *time.Time
+	NotifyLevel NotifyLevel
+	Archived    bool
+}
+
+func (p ChatPreferences) Muted(now time.Time) bool {
+	return p.MutedUntil != nil && now.Before(*p.MutedUntil)
+}
+
+// Notifies tells whether a new message should be pushed to the participant,
+// given whether it mentions them. A mention always gets through, even to a
+// muted chat.
+func (p ChatPreferences) Notifies(now time.Time, mentioned bool) bool {
+	if mentioned {
+		return true
+	}
+
+	return !p.Muted(now) && p.NotifyLevel != NotifyMentions
+}
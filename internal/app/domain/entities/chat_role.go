@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChatRole string
+
+const (
+	ChatRoleOwner    ChatRole = "owner"
+	ChatRoleAdmin    ChatRole = "admin"
+	ChatRoleMember   ChatRole = "member"
+	ChatRoleReadOnly ChatRole = "read_only"
+)
+
+type ChatPermission int
+
+const (
+	ChatPermissionReadMessages ChatPermission = iota
+	ChatPermissionPostMessage
+	ChatPermissionRename
+	ChatPermissionManageMembers
+	ChatPermissionDeleteOthersMessages
+	ChatPermissionPinMessages
+	ChatPermissionDeleteChat
+	ChatPermissionTransferOwnership
+	ChatPermissionManageWebhooks
+	ChatPermissionManageCommands
+)
+
+func (r ChatRole) Valid() bool {
+	switch r {
+	case ChatRoleOwner, ChatRoleAdmin, ChatRoleMember, ChatRoleReadOnly:
+		return true
+	default:
+		return false
+	}
+}
+
+// Can reports whether the role grants the permission.
+//
+//	                        owner  admin  member  read_only
+//	read messages             +      +      +        +
+//	post message              +      +      +
+//	rename                    +      +
+//	add/remove members        +      +
+//	delete others' messages   +      +
+//	pin messages              +      +
+//	manage webhooks           +      +
+//	manage commands           +      +
+//	delete chat               +
+//	transfer ownership        +
+func (r ChatRole) Can(permission ChatPermission) bool {
+	switch permission {
+	case ChatPermissionReadMessages:
+		return r.Valid()
+	case ChatPermissionPostMessage:
+		return r == ChatRoleOwner || r == ChatRoleAdmin || r == ChatRoleMember
+	case ChatPermissionRename, ChatPermissionManageMembers, ChatPermissionDeleteOthersMessages,
+		ChatPermissionPinMessages, ChatPermissionManageWebhooks, ChatPermissionManageCommands:
+		return r == ChatRoleOwner || r == ChatRoleAdmin
+	case ChatPermissionDeleteChat, ChatPermissionTransferOwnership:
+		return r == ChatRoleOwner
+	default:
+		return false
+	}
+}
+
+// Outranks reports whether a participant with this role may manage a
+// participant with the other role, e.g. remove them or change their role.
+func (r ChatRole) Outranks(other ChatRole) bool {
+	return r.rank() > other.rank()
+}
+
+func (r ChatRole) rank() int {
+	switch r {
+	case ChatRoleOwner:
+		return 3
+	case ChatRoleAdmin:
+		return 2
+	case ChatRoleMember:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type ChatRoleChange struct {
+	ID        uuid.UUID
+	ChatID    uuid.UUID
+	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	OldRole   *ChatRole
+	NewRole   *ChatRole
+	ChangedAt time.Time
+}
+
+func NewChatRoleChange(chatID, userID, actorID uuid.UUID, oldRole, newRole *ChatRole) ChatRoleChange {
+	return ChatRoleChange{
+		ID:        uuid.New(),
+		ChatID:    chatID,
+		UserID:    userID,
+		ActorID:   actorID,
+		OldRole:   oldRole,
+		NewRole:   newRole,
+		ChangedAt: time.Now(),
+	}
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+type Contact struct {
+	Profile UserProfile
+	AddedAt time.Time
+}
+
+type BlockedUser struct {
+	Profile   UserProfile
+	BlockedAt time.Time
+}
@@ -0,0 +1,102 @@
+package entities
+
+const (
+	zeroWidthJoiner    = '\u200D'
+	textPresentation   = '\uFE0E'
+	emojiPresentation  = '\uFE0F'
+	combiningKeycap    = '\u20E3'
+	regionalIndicatorA = '\U0001F1E6'
+	regionalIndicatorZ = '\U0001F1FF'
+	skinToneLight      = '\U0001F3FB'
+	skinToneDark       = '\U0001F3FF'
+	tagSpace           = '\U000E0020'
+	tagTilde           = '\U000E007E'
+	cancelTag          = '\U000E007F'
+)
+
+// emojiElement reads the emoji starting at runes[i], as one element of a
+// ZWJ sequence, and returns the index right after it.
+func emojiElement(runes []rune, i int) (int, bool) {
+	if i >= len(runes) {
+		return i, false
+	}
+
+	switch r := runes[i]; {
+	case isRegionalIndicator(r):
+		// Flags are pairs of regional indicators.
+		if i+1 < len(runes) && isRegionalIndicator(runes[i+1]) {
+			return i + 2, true
+		}
+		return i, false
+	case r >= '0' && r <= '9' || r == '#' || r == '*':
+		i++
+		if i < len(runes) && runes[i] == emojiPresentation {
+			i++
+		}
+		if i < len(runes) && runes[i] == combiningKeycap {
+			return i + 1, true
+		}
+		return i, false
+	case isExtendedPictographic(r):
+		i++
+	default:
+		return i, false
+	}
+
+	if i < len(runes) && (runes[i] == emojiPresentation || runes[i] == textPresentation ||
+		runes[i] >= skinToneLight && runes[i] <= skinToneDark) {
+		i++
+	}
+
+	// Subdivision flags such as Scotland's are a black flag followed by
+	// tags and a cancel tag.
+	if i < len(runes) && runes[i] >= tagSpace && runes[i] <= tagTilde {
+		for i < len(runes) && runes[i] >= tagSpace && runes[i] <= tagTilde {
+			i++
+		}
+		if i == len(runes) || runes[i] != cancelTag {
+			return i, false
+		}
+		i++
+	}
+
+	return i, true
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorA && r <= regionalIndicatorZ
+}
+
+// isExtendedPictographic reports whether r has the Extended_Pictographic
+// property of Unicode's emoji data, which covers every emoji base as well
+// as code points reserved for future emoji.
+func isExtendedPictographic(r rune) bool {
+	switch {
+	case r < 0x00A9:
+		return false
+	case r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139,
+		r >= 0x2194 && r <= 0x2199, r >= 0x21A9 && r <= 0x21AA, r >= 0x231A && r <= 0x231B,
+		r == 0x2328, r == 0x2388, r == 0x23CF, r >= 0x23E9 && r <= 0x23F3, r >= 0x23F8 && r <= 0x23FA,
+		r == 0x24C2, r >= 0x25AA && r <= 0x25AB, r == 0x25B6, r == 0x25C0, r >= 0x25FB && r <= 0x25FE,
+		r >= 0x2600 && r <= 0x2605, r >= 0x2607 && r <= 0x2612, r >= 0x2614 && r <= 0x2685,
+		r >= 0x2690 && r <= 0x2705, r >= 0x2708 && r <= 0x2712, r == 0x2714, r == 0x2716, r == 0x271D,
+		r == 0x2721, r == 0x2728, r >= 0x2733 && r <= 0x2734, r == 0x2744, r == 0x2747, r == 0x274C,
+		r == 0x274E, r >= 0x2753 && r <= 0x2755, r == 0x2757, r >= 0x2763 && r <= 0x2767,
+		r >= 0x2795 && r <= 0x2797, r == 0x27A1, r == 0x27B0, r == 0x27BF, r >= 0x2934 && r <= 0x2935,
+		r >= 0x2B05 && r <= 0x2B07, r >= 0x2B1B && r <= 0x2B1C, r == 0x2B50, r == 0x2B55, r == 0x3030,
+		r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	case r >= 0x1F000 && r <= 0x1F0FF, r >= 0x1F10D && r <= 0x1F10F, r == 0x1F12F,
+		r >= 0x1F16C && r <= 0x1F171, r >= 0x1F17E && r <= 0x1F17F, r == 0x1F18E,
+		r >= 0x1F191 && r <= 0x1F19A, r >= 0x1F1AD && r <= 0x1F1E5, r >= 0x1F201 && r <= 0x1F20F,
+		r == 0x1F21A, r == 0x1F22F, r >= 0x1F232 && r <= 0x1F23A, r >= 0x1F23C && r <= 0x1F23F,
+		r >= 0x1F249 && r <= 0x1F3FA, r >= 0x1F400 && r <= 0x1F53D, r >= 0x1F546 && r <= 0x1F64F,
+		r >= 0x1F680 && r <= 0x1F6FF, r >= 0x1F774 && r <= 0x1F77F, r >= 0x1F7D5 && r <= 0x1F7FF,
+		r >= 0x1F80C && r <= 0x1F80F, r >= 0x1F848 && r <= 0x1F84F, r >= 0x1F85A && r <= 0x1F85F,
+		r >= 0x1F888 && r <= 0x1F88F, r >= 0x1F8AE && r <= 0x1F8FF, r >= 0x1F90C && r <= 0x1F93A,
+		r >= 0x1F93C && r <= 0x1F945, r >= 0x1F947 && r <= 0x1FAFF, r >= 0x1FC00 && r <= 0x1FFFD:
+		return true
+	default:
+		return false
+	}
+}
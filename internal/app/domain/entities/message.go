@@ -13,6 +13,10 @@ type Message struct {
 	ChatTag   string
 	Content   string
 	CreatedAt time.Time
+	EditedAt  *time.Time
+	// DeletedAt marks a tombstone: the row stays so replies keep pointing
+	// at it, but its content is gone.
+	DeletedAt *time.Time
 }
 
 func NewMessage(id, replyToID, userID uuid.UUID, chatTag, content string, createdAt time.Time) *Message {
@@ -25,3 +29,20 @@ func NewMessage(id, replyToID, userID uuid.UUID, chatTag, content string, create
 		CreatedAt: createdAt,
 	}
 }
+
+// MessageRevision keeps a message's content as it was before an edit.
+type MessageRevision struct {
+	ID        uuid.UUID
+	MessageID uuid.UUID
+	Content   string
+	EditedAt  time.Time
+}
+
+func NewMessageRevision(messageID uuid.UUID, content string, editedAt time.Time) MessageRevision {
+	return MessageRevision{
+		ID:        uuid.New(),
+		MessageID: messageID,
+		Content:   content,
+		EditedAt:  editedAt,
+	}
+}
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MessageEventKind string
+
+const (
+	MessageCreated MessageEventKind = "created"
+	MessageUpdated MessageEventKind = "updated"
+	MessageDeleted MessageEventKind = "deleted"
+)
+
+// MessageEvent describes a change to a message, carrying the message as it
+// is after the change. Mentions are those in the new content of a created or
+// edited message.
+type MessageEvent struct {
+	Kind       MessageEventKind
+	Message    Message
+	ActorID    uuid.UUID
+	Mentions   []MessageMention
+	OccurredAt time.Time
+}
+
+func NewMessageEvent(kind MessageEventKind, msg Message, actorID uuid.UUID) MessageEvent {
+	return MessageEvent{
+		Kind:       kind,
+		Message:    msg,
+		ActorID:    actorID,
+		OccurredAt: time.Now(),
+	}
+}
@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Account tags are 3 to 12 letters, digits, '_' or '.'.
+const (
+	minMentionTagLen = 3
+	maxMentionTagLen = 12
+)
+
+// MessageMention is an @tag in a message's content that refers to a chat
+// participant. Offset and Length count characters (Unicode code points) and
+// cover the leading '@'.
+type MessageMention struct {
+	MessageID uuid.UUID
+	UserID    uuid.UUID
+	Offset    int
+	Length    int
+}
+
+// MentionCandidate is an @tag found in text, not yet resolved to a user.
+type MentionCandidate struct {
+	Tag    string
+	Offset int
+	Length int
+}
+
+// MentionFeedEntry is a message mentioning a user, as listed in their
+// mentions feed.
+type MentionFeedEntry struct {
+	MessageID uuid.UUID
+	ChatID    uuid.UUID
+	CreatedAt time.Time
+}
+
+// ParseMentions finds the @tags in the text. An '@' counts only at the start
+// of the text or after a character that can't be part of a tag, so e-mail
+// addresses aren't taken for mentions; a trailing '.' ends a sentence rather
+// than the tag.
+func ParseMentions(text string) []MentionCandidate {
+	runes := []rune(text)
+
+	var mentions []MentionCandidate
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '@') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isTagRune(runes[end]) {
+			end++
+		}
+		tagEnd := end
+		for tagEnd > i+1 && runes[tagEnd-1] == '.' {
+			tagEnd--
+		}
+
+		if n := tagEnd - i - 1; n >= minMentionTagLen && n <= maxMentionTagLen {
+			mentions = append(mentions, MentionCandidate{
+				Tag:    string(runes[i+1 : tagEnd]),
+				Offset: i,
+				Length: tagEnd - i,
+			})
+		}
+		i = end - 1
+	}
+
+	return mentions
+}
+
+// MentionTags returns the distinct tags of the candidates in lower case.
+func MentionTags(candidates []MentionCandidate) []string {
+	seen := make(map[string]bool, len(candidates))
+	tags := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		tag := strings.ToLower(c.Tag)
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func isTagRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.'
+}
@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []MentionCandidate
+	}{
+		{"start of text", "@alice hi", []MentionCandidate{{"alice", 0, 6}}},
+		{"multibyte text before", "привет, @bob!", []MentionCandidate{{"bob", 8, 4}}},
+		{"emoji before", "\U0001F44B @carol", []MentionCandidate{{"carol", 2, 6}}},
+		{"email address", "write to mail@host.com", nil},
+		{"double at", "@@alice", nil},
+		{"trailing period", "thanks @alice.", []MentionCandidate{{"alice", 7, 6}}},
+		{"trailing punctuation", "@alice, @bob? (@carol)", []MentionCandidate{
+			{"alice", 0, 6}, {"bob", 8, 4}, {"carol", 15, 6},
+		}},
+		{"dot inside tag", "@john.doe.", []MentionCandidate{{"john.doe", 0, 9}}},
+		{"repeated tag", "@alice and @Alice", []MentionCandidate{{"alice", 0, 6}, {"Alice", 11, 6}}},
+		{"maximum length", "@abcdefghijkl", []MentionCandidate{{"abcdefghijkl", 0, 13}}},
+		{"over maximum length", "@abcdefghijklm", nil},
+		{"minimum length", "@abc", []MentionCandidate{{"abc", 0, 4}}},
+		{"under minimum length", "@ab", nil},
+		{"bare at", "@ alone", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseMentions(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentionTagsDropsDuplicates(t *testing.T) {
+	got := MentionTags(ParseMentions("@alice @Bob @ALICE @bob"))
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("MentionTags() = %v, want %v", got, want)
+	}
+}
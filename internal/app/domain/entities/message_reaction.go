@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxEmojiLen bounds a reaction in bytes. It fits the longest ZWJ sequences
+// such as family emoji.
+const maxEmojiLen = 64
+
+type MessageReaction struct {
+	MessageID uuid.UUID
+	UserID    uuid.UUID
+	Emoji     string
+	CreatedAt time.Time
+}
+
+func NewMessageReaction(messageID, userID uuid.UUID, emoji string) MessageReaction {
+	return MessageReaction{
+		MessageID: messageID,
+		UserID:    userID,
+		Emoji:     emoji,
+		CreatedAt: time.Now(),
+	}
+}
+
+// ReactionCount aggregates the reactions with one emoji on a message.
+type ReactionCount struct {
+	MessageID   uuid.UUID
+	Emoji       string
+	Count       int
+	ReactedByMe bool
+}
+
+// ValidEmoji reports whether the text is a single emoji: one pictographic
+// character, flag or keycap, optionally with a variation selector, a skin
+// tone or subdivision tags, or several of those joined by zero-width
+// joiners.
+func ValidEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > maxEmojiLen || !utf8.ValidString(emoji) {
+		return false
+	}
+
+	runes := []rune(emoji)
+	i, ok := emojiElement(runes, 0)
+	for ok && i < len(runes) && runes[i] == zeroWidthJoiner {
+		i, ok = emojiElement(runes, i+1)
+	}
+
+	return ok && i == len(runes)
+}
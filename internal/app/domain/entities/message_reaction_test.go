@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidEmoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji string
+		want  bool
+	}{
+		{"single code point", "\U0001F44D", true},
+		{"symbol with emoji presentation", "❤\uFE0F", true},
+		{"symbol with text presentation", "❤\uFE0E", true},
+		{"bare symbol", "❤", true},
+		{"copyright sign", "©\uFE0F", true},
+		{"skin tone", "\U0001F44D\U0001F3FD", true},
+		{"family", "\U0001F468\u200D\U0001F469\u200D\U0001F467\u200D\U0001F466", true},
+		{"profession with skin tone", "\U0001F469\U0001F3FE\u200D\U0001F4BB", true},
+		{"rainbow flag", "\U0001F3F3\uFE0F\u200D\U0001F308", true},
+		{"flag", "\U0001F1E9\U0001F1EA", true},
+		{"subdivision flag", "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", true},
+		{"keycap", "1\uFE0F\u20E3", true},
+		{"keycap without selector", "#\u20E3", true},
+
+		{"empty", "", false},
+		{"two emoji", "\U0001F44D\U0001F44D", false},
+		{"emoji and text", "\U0001F44D ok", false},
+		{"text and emoji", "ok\U0001F44D", false},
+		{"letter", "a", false},
+		{"digit", "1", false},
+		{"punctuation", "!", false},
+		{"arrow outside emoji", "→", false},
+		{"bare zwj", "\u200D", false},
+		{"leading zwj", "\u200D\U0001F44D", false},
+		{"trailing zwj", "\U0001F44D\u200D", false},
+		{"lone regional indicator", "\U0001F1E9", false},
+		{"three regional indicators", "\U0001F1E9\U0001F1EA\U0001F1EB", false},
+		{"lone skin tone", "\U0001F3FB", false},
+		{"tags without cancel tag", "\U0001F3F4\U000E0067\U000E0062", false},
+		{"keycap mark alone", "\u20E3", false},
+		{"invalid utf-8", "\xF0\x9F\x91", false},
+		{"too long", strings.Repeat("\U0001F468\u200D", 10) + "\U0001F468", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidEmoji(tt.emoji); got != tt.want {
+				t.Fatalf("ValidEmoji(%q) = %v, want %v", tt.emoji, got, tt.want)
+			}
+		})
+	}
+}
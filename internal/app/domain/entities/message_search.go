@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Highlighted words in a search snippet are wrapped in these private-use
+// characters rather than markup, so the snippet can be escaped for display
+// before the highlighting is put in.
+const (
+	SearchHighlightStart = "\uE000"
+	SearchHighlightStop  = "\uE001"
+)
+
+// MessageSearchQuery looks for messages matching Text in the chats ViewerID
+// participates in. Nil filters are not applied; From is inclusive and To
+// exclusive.
+type MessageSearchQuery struct {
+	ViewerID uuid.UUID
+	Text     string
+	ChatID   *uuid.UUID
+	AuthorID *uuid.UUID
+	From     *time.Time
+	To       *time.Time
+	Limit    uint64
+	Offset   uint64
+}
+
+type MessageSearchHit struct {
+	MessageID uuid.UUID
+	ChatID    uuid.UUID
+	Rank      float64
+	Snippet   string
+}
+
+// MessageDocument is what a search index keeps of a live message.
+type MessageDocument struct {
+	ID        uuid.UUID
+	ChatID    uuid.UUID
+	UserID    uuid.UUID
+	Content   string
+	CreatedAt time.Time
+}
+
+// SearchQueueEntry is a message waiting to be brought up to date in the
+// search index. QueuedAt tells a repeated change apart from the one being
+// processed.
+type SearchQueueEntry struct {
+	MessageID uuid.UUID
+	QueuedAt  time.Time
+}
@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PinnedMessage struct {
+	ChatID   uuid.UUID
+	Message  Message
+	PinnedBy uuid.UUID
+	PinnedAt time.Time
+}
+
+func NewPinnedMessage(chatID uuid.UUID, msg Message, pinnedBy uuid.UUID) PinnedMessage {
+	return PinnedMessage{
+		ChatID:   chatID,
+		Message:  msg,
+		PinnedBy: pinnedBy,
+		PinnedAt: time.Now(),
+	}
+}
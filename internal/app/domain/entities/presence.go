@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LastSeenVisibility is a user's privacy setting deciding who may see when
+// they were last online.
+type LastSeenVisibility string
+
+const (
+	LastSeenEveryone LastSeenVisibility = "everyone"
+	LastSeenNobody   LastSeenVisibility = "nobody"
+)
+
+func (v LastSeenVisibility) Valid() bool {
+	return v == LastSeenEveryone || v == LastSeenNobody
+}
+
+type LastSeen struct {
+	UserID     uuid.UUID
+	At         *time.Time
+	Visibility LastSeenVisibility
+}
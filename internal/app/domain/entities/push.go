@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PushPlatform is the push service a device token belongs to.
+type PushPlatform string
+
+const (
+	PushPlatformFCM  PushPlatform = "fcm"
+	PushPlatformAPNs PushPlatform = "apns"
+)
+
+func (p PushPlatform) Valid() bool {
+	return p == PushPlatformFCM || p == PushPlatformAPNs
+}
+
+type DeviceToken struct {
+	Token        string
+	UserID       uuid.UUID
+	Platform     PushPlatform
+	RegisteredAt time.Time
+}
+
+func NewDeviceToken(token string, userID uuid.UUID, platform PushPlatform) DeviceToken {
+	return DeviceToken{
+		Token:        token,
+		UserID:       userID,
+		Platform:     platform,
+		RegisteredAt: time.Now(),
+	}
+}
+
+// PushNotification is a notification of a message addressed to a single
+// device. Its ID tells its outcome apart from those of other notifications
+// sent in the same batch, which may well go to the same device.
+type PushNotification struct {
+	ID        uuid.UUID
+	MessageID uuid.UUID
+	Token     string
+	Platform  PushPlatform
+	UserID    uuid.UUID
+	Title     string
+	Body      string
+	Data      map[string]string
+}
+
+type PushOutcome string
+
+const (
+	PushDelivered PushOutcome = "delivered"
+	// PushInvalidToken means the device is gone for good, e.g. the app was
+	// uninstalled; the token should be forgotten.
+	PushInvalidToken PushOutcome = "invalid_token"
+	// PushFailed means delivery may succeed if retried.
+	PushFailed PushOutcome = "failed"
+)
+
+type PushResult struct {
+	NotificationID uuid.UUID
+	Outcome        PushOutcome
+}
+
+// PushQueueItem is a queued message whose notifications are due. A retry
+// carries the tokens of the devices that failed last time and only goes to
+// those; the first attempt has none and goes to every device.
+type PushQueueItem struct {
+	MessageID uuid.UUID
+	Attempts  int
+	Tokens    []string
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "github.com/google/uuid"
+
+// MessageReceipts tells which of a message's recipients, i.e. the chat
+// participants except its author, have read it.
+type MessageReceipts struct {
+	ChatKind       ChatKind
+	RecipientCount int
+	Readers        []uuid.UUID
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "github.com/google/uuid"
+
+// UserProfile is the part of an account anyone may see.
+type UserProfile struct {
+	ID   uuid.UUID
+	Tag  string
+	Name string
+	Desc string
+}
@@ -0,0 +1,133 @@
+package entities
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookMessageCreated WebhookEvent = "message.created"
+	WebhookMessageUpdated WebhookEvent = "message.updated"
+	WebhookMessageDeleted WebhookEvent = "message.deleted"
+	WebhookMemberJoined   WebhookEvent = "member.joined"
+	WebhookMemberLeft     WebhookEvent = "member.left"
+	WebhookChatRenamed    WebhookEvent = "chat.renamed"
+)
+
+func (e WebhookEvent) Valid() bool {
+	switch e {
+	case WebhookMessageCreated, WebhookMessageUpdated, WebhookMessageDeleted,
+		WebhookMemberJoined, WebhookMemberLeft, WebhookChatRenamed:
+		return true
+	default:
+		return false
+	}
+}
+
+// WebhookEventOfMessage names the webhook event of a message change.
+func WebhookEventOfMessage(kind MessageEventKind) WebhookEvent {
+	return WebhookEvent("message." + string(kind))
+}
+
+// WebhookEventOfChat names the webhook event of a chat change.
+func WebhookEventOfChat(kind ChatEventKind) WebhookEvent {
+	switch kind {
+	case ChatMemberJoined:
+		return WebhookMemberJoined
+	case ChatMemberLeft:
+		return WebhookMemberLeft
+	default:
+		return WebhookChatRenamed
+	}
+}
+
+// WebhookTargetAllowed reports whether webhooks may be delivered to the
+// address. Loopback, private, link-local, multicast and unspecified
+// addresses are off limits, so a webhook can't be used to reach the
+// server's own network.
+func WebhookTargetAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// Webhook posts the chat's events it subscribes to to URL, signed with
+// Secret. It is disabled on its own once deliveries keep failing;
+// ConsecutiveFailures counts failed attempts since the last success.
+type Webhook struct {
+	ID                  uuid.UUID
+	ChatID              uuid.UUID
+	URL                 string
+	Secret              string
+	Events              []WebhookEvent
+	CreatedBy           uuid.UUID
+	CreatedAt           time.Time
+	Enabled             bool
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+}
+
+func NewWebhook(chatID, createdBy uuid.UUID, url, secret string, events []WebhookEvent) Webhook {
+	return Webhook{
+		ID:        uuid.New(),
+		ChatID:    chatID,
+		URL:       url,
+		Secret:    secret,
+		Events:    events,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		Enabled:   true,
+	}
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event to be posted to a webhook, along with how
+// posting it went so far. A pending delivery is attempted again at
+// NextAttemptAt.
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	Event          WebhookEvent
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseStatus *int
+	LastError      *string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+func NewWebhookDelivery(id, webhookID uuid.UUID, event WebhookEvent, payload []byte,
+	createdAt time.Time) WebhookDelivery {
+	return WebhookDelivery{
+		ID:            id,
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       payload,
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+	}
+}
+
+// WebhookJob is a claimed delivery together with where it goes and how it
+// is signed.
+type WebhookJob struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+}
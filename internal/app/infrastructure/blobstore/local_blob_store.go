@@ -0,0 +1,105 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	dirMode  = 0o755
+	fileMode = 0o644
+)
+
+var ErrInvalidKey = errors.New("blob key escapes the store root")
+
+// LocalBlobStore keeps blobs as files under a root directory, one file per
+// key.
+type LocalBlobStore struct {
+	root string
+}
+
+func NewLocalBlobStore(root string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(root, dirMode); err != nil {
+		return nil, fmt.Errorf("create blob dir: %w", err)
+	}
+
+	return &LocalBlobStore{root: root}, nil
+}
+
+// Put writes the blob to a temporary file first, so readers never see a
+// partially written blob.
+func (lbs *LocalBlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	path, err := lbs.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(fileMode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the blob. A missing blob yields a nil reader.
+func (lbs *LocalBlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := lbs.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (lbs *LocalBlobStore) Delete(_ context.Context, key string) error {
+	path, err := lbs.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (lbs *LocalBlobStore) path(key string) (string, error) {
+	path := filepath.Join(lbs.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(lbs.root)+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+
+	return path, nil
+}
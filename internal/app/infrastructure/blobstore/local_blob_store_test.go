@@ -0,0 +1,135 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalBlobStoreRejectsEscapingKeys(t *testing.T) {
+	parent := t.TempDir()
+	store, err := NewLocalBlobStore(filepath.Join(parent, "blobs"))
+	if err != nil {
+		t.Fatalf("NewLocalBlobStore() error = %v", err)
+	}
+
+	keys := []string{"", ".", "..", "../escaped", "a/../../escaped", "../blobs-sibling/x", "a/../.."}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := store.Put(ctx, key, strings.NewReader("data"), 4, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Put() error = %v, want %v", err, ErrInvalidKey)
+			}
+			if _, err := store.Get(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Get() error = %v, want %v", err, ErrInvalidKey)
+			}
+			if err := store.Delete(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Delete() error = %v, want %v", err, ErrInvalidKey)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatalf("read parent dir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "blobs" {
+		t.Fatalf("files were written outside the store root: %v", entries)
+	}
+}
+
+func TestLocalBlobStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalBlobStore() error = %v", err)
+	}
+
+	if err = store.Put(ctx, "chats/1/file", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got := readBlob(t, store, "chats/1/file"); got != "hello" {
+		t.Fatalf("Get() = %q, want %q", got, "hello")
+	}
+
+	if err = store.Delete(ctx, "chats/1/file"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if content, err := store.Get(ctx, "chats/1/file"); content != nil || err != nil {
+		t.Fatalf("Get() after Delete() = %v, %v; want nil, nil", content, err)
+	}
+	if err = store.Delete(ctx, "chats/1/file"); err != nil {
+		t.Fatalf("Delete() of a missing blob error = %v", err)
+	}
+}
+
+func TestLocalBlobStorePutIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocalBlobStore(root)
+	if err != nil {
+		t.Fatalf("NewLocalBlobStore() error = %v", err)
+	}
+
+	failing := io.MultiReader(strings.NewReader("partial"), errReader{})
+	if err = store.Put(ctx, "new", failing, 100, "text/plain"); err == nil {
+		t.Fatal("Put() with a failing reader succeeded")
+	}
+	if content, err := store.Get(ctx, "new"); content != nil || err != nil {
+		t.Fatalf("Get() of a failed upload = %v, %v; want nil, nil", content, err)
+	}
+
+	if err = store.Put(ctx, "existing", strings.NewReader("original"), 8, "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	failing = io.MultiReader(strings.NewReader("partial"), errReader{})
+	if err = store.Put(ctx, "existing", failing, 100, "text/plain"); err == nil {
+		t.Fatal("Put() with a failing reader succeeded")
+	}
+	if got := readBlob(t, store, "existing"); got != "original" {
+		t.Fatalf("Get() after a failed overwrite = %q, want %q", got, "original")
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".upload-") {
+			t.Fatalf("temporary file %s was left behind", entry.Name())
+		}
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func readBlob(t *testing.T, store interface {
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}, key string) string {
+	t.Helper()
+
+	content, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q) error = %v", key, err)
+	}
+	if content == nil {
+		t.Fatalf("Get(%q) found nothing", key)
+	}
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatalf("read %q: %v", key, err)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,204 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat   = "20060102T150405Z"
+	amzDayFormat    = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	maxErrorBody    = 1024
+)
+
+type S3Options struct {
+	// Endpoint is the base URL of the service, e.g. http://minio:9000.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3BlobStore talks to an S3-compatible service such as MinIO using
+// path-style URLs and Signature Version 4. Payloads are streamed unsigned.
+type S3BlobStore struct {
+	opts   S3Options
+	client *http.Client
+}
+
+func NewS3BlobStore(opts S3Options) *S3BlobStore {
+	opts.Endpoint = strings.TrimRight(opts.Endpoint, "/")
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+
+	return &S3BlobStore{
+		opts:   opts,
+		client: &http.Client{},
+	}
+}
+
+// EnsureBucket creates the bucket unless it already exists.
+func (s *S3BlobStore) EnsureBucket(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodPut, "", nil, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusConflict {
+		return nil
+	}
+
+	return responseError(resp)
+}
+
+func (s *S3BlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// Get opens the object. A missing object yields a nil reader.
+func (s *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, nil
+	default:
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+func (s *S3BlobStore) do(ctx context.Context, method, key string, body io.Reader, size int64,
+	contentType string) (*http.Response, error) {
+	path := "/" + s.opts.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.opts.Endpoint+escapePath(path), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	s.sign(req, escapePath(path), time.Now().UTC())
+
+	return s.client.Do(req)
+}
+
+// sign adds a Signature Version 4 Authorization header covering the host,
+// date and payload hash headers.
+func (s *S3BlobStore) sign(req *http.Request, canonicalURI string, now time.Time) {
+	amzDate := now.Format(amzDateFormat)
+	scope := strings.Join([]string{now.Format(amzDayFormat), s.opts.Region, "s3", "aws4_request"}, "/")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + unsignedPayload + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), now.Format(amzDayFormat))
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// escapePath percent-encodes everything but unreserved characters and '/',
+// which is what S3 expects in the canonical request.
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	return fmt.Errorf("s3 %s %s: %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, body)
+}
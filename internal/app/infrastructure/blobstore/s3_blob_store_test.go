@@ -0,0 +1,245 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-central-1"
+	testBucket    = "attachments"
+)
+
+// fakeS3 is a single-bucket S3 that checks every request's signature.
+type fakeS3 struct {
+	t *testing.T
+
+	mu           sync.Mutex
+	bucketExists bool
+	objects      map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3BlobStore) {
+	fake := &fakeS3{t: t, objects: make(map[string]fakeObject)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store := NewS3BlobStore(S3Options{
+		Endpoint:  srv.URL + "/",
+		Region:    testRegion,
+		Bucket:    testBucket,
+		AccessKey: testAccessKey,
+		SecretKey: testSecretKey,
+	})
+
+	return fake, store
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.checkSignature(r); err != "" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, err)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/"+testBucket {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if f.bucketExists {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.bucketExists = true
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+testBucket+"/")
+	if !ok || !f.bucketExists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if int64(len(data)) != r.ContentLength {
+			f.t.Errorf("PUT %s: got %d bytes, Content-Length %d", key, len(data), r.ContentLength)
+		}
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		_, _ = w.Write(obj.data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) object(key string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, ok := f.objects[key]
+
+	return obj, ok
+}
+
+// checkSignature recomputes the Signature Version 4 of the request the way
+// S3 does and compares it with the Authorization header. It returns what is
+// wrong with the request, if anything.
+func (f *fakeS3) checkSignature(r *http.Request) string {
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil {
+		return "bad X-Amz-Date " + amzDate
+	}
+	if d := time.Since(signedAt); d < -time.Minute || d > time.Minute {
+		return "X-Amz-Date is off by " + d.String()
+	}
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != unsignedPayload {
+		return "X-Amz-Content-Sha256 is " + got
+	}
+
+	day := signedAt.Format(amzDayFormat)
+	scope := day + "/" + testRegion + "/s3/aws4_request"
+	canonicalRequest := r.Method + "\n" +
+		r.URL.EscapedPath() + "\n" +
+		r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + r.Header.Get("X-Amz-Content-Sha256") + "\n" +
+		"x-amz-date:" + amzDate + "\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		unsignedPayload
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+testSecretKey), day)
+	key = hmacSHA256(key, testRegion)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	want := "AWS4-HMAC-SHA256 Credential=" + testAccessKey + "/" + scope +
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" +
+		hex.EncodeToString(hmacSHA256(key, stringToSign))
+	if got := r.Header.Get("Authorization"); got != want {
+		return "Authorization is " + got + ", want " + want
+	}
+
+	return ""
+}
+
+func TestS3BlobStoreEnsureBucket(t *testing.T) {
+	ctx := context.Background()
+	fake, store := newFakeS3(t)
+
+	if err := store.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket() error = %v", err)
+	}
+	if err := store.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket() of an existing bucket error = %v", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !fake.bucketExists {
+		t.Fatal("bucket wasn't created")
+	}
+}
+
+func TestS3BlobStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fake, store := newFakeS3(t)
+	if err := store.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket() error = %v", err)
+	}
+
+	keys := []string{"attachments/chat/file", "attachments/chat/with space+plus~ünïcode.txt"}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			err := store.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain")
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if obj, _ := fake.object(key); string(obj.data) != "hello" || obj.contentType != "text/plain" {
+				t.Fatalf("stored object = %q (%s), want %q (text/plain)", obj.data, obj.contentType, "hello")
+			}
+
+			if got := readBlob(t, store, key); got != "hello" {
+				t.Fatalf("Get() = %q, want %q", got, "hello")
+			}
+
+			if err = store.Delete(ctx, key); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, ok := fake.object(key); ok {
+				t.Fatal("object wasn't deleted")
+			}
+		})
+	}
+}
+
+func TestS3BlobStoreMissingKey(t *testing.T) {
+	ctx := context.Background()
+	_, store := newFakeS3(t)
+	if err := store.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket() error = %v", err)
+	}
+
+	content, err := store.Get(ctx, "attachments/missing")
+	if content != nil || err != nil {
+		t.Fatalf("Get() = %v, %v; want nil, nil", content, err)
+	}
+	if err = store.Delete(ctx, "attachments/missing"); err != nil {
+		t.Fatalf("Delete() of a missing object error = %v", err)
+	}
+}
+
+func TestS3BlobStoreRejectedSignature(t *testing.T) {
+	ctx := context.Background()
+	_, store := newFakeS3(t)
+	store.opts.SecretKey = "wrong"
+
+	if err := store.EnsureBucket(ctx); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("EnsureBucket() error = %v, want a 403", err)
+	}
+	if err := store.Put(ctx, "file", strings.NewReader("hello"), 5, "text/plain"); err == nil {
+		t.Fatal("Put() with a bad signature succeeded")
+	}
+	if _, err := store.Get(ctx, "file"); err == nil {
+		t.Fatal("Get() with a bad signature succeeded")
+	}
+	if err := store.Delete(ctx, "file"); err == nil {
+		t.Fatal("Delete() with a bad signature succeeded")
+	}
+}
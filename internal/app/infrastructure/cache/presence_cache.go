@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	onlineKeyPrefix = "presence:online:"
+	typingKeyPrefix = "presence:typing:"
+	heartbeatsKey   = "presence:heartbeats"
+
+	// claimExpiredScript pops the heartbeats scored up to ARGV[1], at most
+	// ARGV[2] of them, so that only one caller gets each.
+	claimExpiredScript = `
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, ARGV[2])
+for i = 1, #expired, 2 do
+	redis.call('ZREM', KEYS[1], expired[i])
+end
+return expired`
+)
+
+// PresenceCache keeps short-lived presence state. A user is online while
+// their key lives; typing users of a chat are kept in a sorted set scored by
+// the moment their typing state expires. The last heartbeat of every online
+// user is kept in another sorted set until it is claimed as expired or the
+// user goes offline.
+type PresenceCache struct {
+	client *redis.Client
+}
+
+func NewPresenceCache(redisAddr string, password string, db int) *PresenceCache {
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: password,
+		DB:       db,
+	})
+
+	return &PresenceCache{
+		client: client,
+	}
+}
+
+func (pc *PresenceCache) MarkOnline(ctx context.Context, userID uuid.UUID, ttl time.Duration) error {
+	_, err := pc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, onlineKeyPrefix+userID.String(), 1, ttl)
+		pipe.ZAdd(ctx, heartbeatsKey, redis.Z{Score: float64(time.Now().UnixMilli()), Member: userID.String()})
+		return nil
+	})
+
+	return err
+}
+
+func (pc *PresenceCache) MarkOffline(ctx context.Context, userID uuid.UUID) error {
+	_, err := pc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, onlineKeyPrefix+userID.String())
+		pipe.ZRem(ctx, heartbeatsKey, userID.String())
+		return nil
+	})
+
+	return err
+}
+
+// ReadHeartbeats returns when the given users last sent a heartbeat, for
+// those whose heartbeat hasn't been claimed yet.
+func (pc *PresenceCache) ReadHeartbeats(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	heartbeats := make(map[uuid.UUID]time.Time, len(userIDs))
+	if len(userIDs) == 0 {
+		return heartbeats, nil
+	}
+
+	members := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		members = append(members, id.String())
+	}
+
+	scores, err := pc.client.ZMScore(ctx, heartbeatsKey, members...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// Users without a heartbeat come back as 0.
+	for i, score := range scores {
+		if score != 0 {
+			heartbeats[userIDs[i]] = time.UnixMilli(int64(score))
+		}
+	}
+
+	return heartbeats, nil
+}
+
+// ClaimExpiredHeartbeats removes up to limit heartbeats sent before the
+// given time and returns when each of those users sent theirs.
+func (pc *PresenceCache) ClaimExpiredHeartbeats(ctx context.Context, before time.Time,
+	limit int) (map[uuid.UUID]time.Time, error) {
+	result, err := pc.client.Eval(ctx, claimExpiredScript, []string{heartbeatsKey},
+		before.UnixMilli(), limit).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeats := make(map[uuid.UUID]time.Time, len(result)/2)
+	for i := 0; i+1 < len(result); i += 2 {
+		userID, err := uuid.Parse(result[i])
+		if err != nil {
+			continue
+		}
+		score, err := strconv.ParseFloat(result[i+1], 64)
+		if err != nil {
+			continue
+		}
+		heartbeats[userID] = time.UnixMilli(int64(score))
+	}
+
+	return heartbeats, nil
+}
+
+// ReadOnline returns the subset of the given users that are online.
+func (pc *PresenceCache) ReadOnline(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	online := make(map[uuid.UUID]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return online, nil
+	}
+
+	keys := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		keys = append(keys, onlineKeyPrefix+id.String())
+	}
+
+	values, err := pc.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		if value != nil {
+			online[userIDs[i]] = true
+		}
+	}
+
+	return online, nil
+}
+
+func (pc *PresenceCache) SetTyping(ctx context.Context, chatID, userID uuid.UUID, ttl time.Duration) error {
+	key := typingKeyPrefix + chatID.String()
+	expiresAt := time.Now().Add(ttl)
+
+	_, err := pc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(expiresAt.UnixMilli()), Member: userID.String()})
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+
+	return err
+}
+
+func (pc *PresenceCache) ClearTyping(ctx context.Context, chatID, userID uuid.UUID) error {
+	return pc.client.ZRem(ctx, typingKeyPrefix+chatID.String(), userID.String()).Err()
+}
+
+// ReadTyping returns users whose typing state in the chat hasn't expired yet.
+func (pc *PresenceCache) ReadTyping(ctx context.Context, chatID uuid.UUID) ([]uuid.UUID, error) {
+	members, err := pc.client.ZRangeByScore(ctx, typingKeyPrefix+chatID.String(), &redis.ZRangeBy{
+		Min: strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		userID, err := uuid.Parse(member)
+		if err != nil {
+			continue
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, nil
+}
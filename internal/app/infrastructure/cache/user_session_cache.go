@@ -4,9 +4,16 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
+// sessionKeyPrefix versions the keys of cached tokens. Tokens cached before
+// they held their owner's ID were stored under the bare token; those are
+// no longer read, so their access tokens are refused and their refresh
+// tokens are checked against the database instead.
+const sessionKeyPrefix = "session:v2:"
+
 type UserSessionCache struct {
 	client *redis.Client
 }
@@ -23,12 +30,12 @@ func NewUserSessionCache(redisAddr string, password string, db int) *UserSession
 	}
 }
 
-func (usc *UserSessionCache) SaveToken(ctx context.Context, token string, ttl time.Duration) error {
-	return usc.client.SetArgs(ctx, token, "", redis.SetArgs{TTL: ttl, Mode: "NX"}).Err()
+func (usc *UserSessionCache) SaveToken(ctx context.Context, token string, userID uuid.UUID, ttl time.Duration) error {
+	return usc.client.SetArgs(ctx, sessionKeyPrefix+token, userID.String(), redis.SetArgs{TTL: ttl, Mode: "NX"}).Err()
 }
 
 func (usc *UserSessionCache) CheckToken(ctx context.Context, token string) (bool, error) {
-	err := usc.client.Get(ctx, token).Err()
+	err := usc.client.Get(ctx, sessionKeyPrefix+token).Err()
 	if err != nil {
 		if err == redis.Nil {
 			return false, nil
@@ -39,6 +46,23 @@ func (usc *UserSessionCache) CheckToken(ctx context.Context, token string) (bool
 	return true, nil
 }
 
+func (usc *UserSessionCache) ReadTokenOwner(ctx context.Context, token string) (*uuid.UUID, error) {
+	value, err := usc.client.Get(ctx, sessionKeyPrefix+token).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		return nil, nil
+	}
+
+	return &userID, nil
+}
+
 func (usc *UserSessionCache) RevokeToken(ctx context.Context, token string) error {
-	return usc.client.Del(ctx, token).Err()
+	return usc.client.Del(ctx, sessionKeyPrefix+token).Err()
 }
@@ -0,0 +1,60 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	dirMode  = 0o755
+	fileMode = 0o644
+)
+
+// FileProvider appends notifications to a file as JSON lines instead of
+// sending them, for development and tests. Every notification counts as
+// delivered.
+type FileProvider struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+		return nil, fmt.Errorf("create push log dir: %w", err)
+	}
+
+	return &FileProvider{path: path}, nil
+}
+
+func (fp *FileProvider) Send(_ context.Context,
+	notifications []entities.PushNotification) ([]entities.PushResult, error) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	f, err := os.OpenFile(fp.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, fileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := json.NewEncoder(f)
+	results := make([]entities.PushResult, 0, len(notifications))
+	for _, n := range notifications {
+		if err = enc.Encode(newEnvelope(n)); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		results = append(results, entities.PushResult{NotificationID: n.ID, Outcome: entities.PushDelivered})
+	}
+
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
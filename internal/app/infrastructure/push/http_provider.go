@@ -0,0 +1,96 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const maxErrorBody = 1024
+
+// HTTPProvider posts batches of notifications to a push gateway, or to a
+// mock of one in tests. The request body is
+//
+//	{"notifications": [{"id": "...", "platform": "fcm", "token": "...", "payload": {...}}]}
+//
+// and a 2xx response lists an outcome per notification ID:
+//
+//	{"results": [{"id": "...", "status": "delivered"}]}
+//
+// where status is "delivered", "invalid_token" or "failed". Notifications
+// missing from the response count as failed.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type gatewayRequest struct {
+	Notifications []envelope `json:"notifications"`
+}
+
+type gatewayResponse struct {
+	Results []struct {
+		ID     uuid.UUID            `json:"id"`
+		Status entities.PushOutcome `json:"status"`
+	} `json:"results"`
+}
+
+func (hp *HTTPProvider) Send(ctx context.Context,
+	notifications []entities.PushNotification) ([]entities.PushResult, error) {
+	req := gatewayRequest{Notifications: make([]envelope, 0, len(notifications))}
+	for _, n := range notifications {
+		req.Notifications = append(req.Notifications, newEnvelope(n))
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, hp.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := hp.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("push gateway: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("push gateway: %s: %s", resp.Status, msg)
+	}
+
+	var decoded gatewayResponse
+	if err = json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("decode push gateway response: %w", err)
+	}
+
+	results := make([]entities.PushResult, 0, len(decoded.Results))
+	for _, r := range decoded.Results {
+		outcome := r.Status
+		if outcome != entities.PushDelivered && outcome != entities.PushInvalidToken {
+			outcome = entities.PushFailed
+		}
+		results = append(results, entities.PushResult{NotificationID: r.ID, Outcome: outcome})
+	}
+
+	return results, nil
+}
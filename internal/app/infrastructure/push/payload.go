@@ -0,0 +1,79 @@
+// Package push delivers push notifications. Notifications are shaped the way
+// FCM and APNs expect them, so a gateway forwarding them needs no knowledge
+// of this service.
+package push
+
+import (
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+// envelope is a notification as handed to a gateway: its ID, the device it
+// is for and the body the platform's push service expects.
+type envelope struct {
+	ID       uuid.UUID             `json:"id"`
+	Platform entities.PushPlatform `json:"platform"`
+	Token    string                `json:"token"`
+	Payload  any                   `json:"payload"`
+}
+
+type fcmMessage struct {
+	Message fcmBody `json:"message"`
+}
+
+type fcmBody struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      fcmAndroid        `json:"android"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroid struct {
+	Priority string `json:"priority"`
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type apnsAps struct {
+	Alert    apnsAlert `json:"alert"`
+	Sound    string    `json:"sound"`
+	ThreadID string    `json:"thread-id,omitempty"`
+}
+
+// Payload returns the body of an FCM HTTP v1 send request or of an APNs
+// request for the notification, depending on its platform.
+func Payload(n entities.PushNotification) any {
+	if n.Platform == entities.PushPlatformAPNs {
+		// APNs takes custom data as top-level keys next to "aps".
+		payload := make(map[string]any, len(n.Data)+1)
+		for k, v := range n.Data {
+			payload[k] = v
+		}
+		payload["aps"] = apnsAps{
+			Alert:    apnsAlert{Title: n.Title, Body: n.Body},
+			Sound:    "default",
+			ThreadID: n.Data["chat_id"],
+		}
+
+		return payload
+	}
+
+	return fcmMessage{Message: fcmBody{
+		Token:        n.Token,
+		Notification: fcmNotification{Title: n.Title, Body: n.Body},
+		Data:         n.Data,
+		Android:      fcmAndroid{Priority: "high"},
+	}}
+}
+
+func newEnvelope(n entities.PushNotification) envelope {
+	return envelope{ID: n.ID, Platform: n.Platform, Token: n.Token, Payload: Payload(n)}
+}
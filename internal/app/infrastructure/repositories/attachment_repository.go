@@ -0,0 +1,261 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type AttachmentRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewAttachmentRepository(pool *pgxpool.Pool) *AttachmentRepository {
+	return &AttachmentRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (ar *AttachmentRepository) Create(ctx context.Context, a entities.Attachment) error {
+	sql, args, err := ar.builder.Insert("attachments").
+		Columns("id", "chat_id", "uploader_id", "file_name", "mime_type", "size", "checksum", "storage_key", "created_at",
+			"thumbnail_status").
+		Values(a.ID, a.ChatID, a.UploaderID, a.FileName, a.MimeType, a.Size, a.Checksum, a.StorageKey, a.CreatedAt,
+			a.ThumbnailStatus).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = ar.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (ar *AttachmentRepository) ReadByID(ctx context.Context, id uuid.UUID) (*entities.Attachment, error) {
+	sql, args, err := ar.selectAttachments().Where(sq.Eq{"id": id}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := scanAttachment(ar.pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (ar *AttachmentRepository) ReadByMessageIDs(ctx context.Context, messageIDs []uuid.UUID) ([]entities.Attachment, error) {
+	sql, args, err := ar.selectAttachments().
+		Where(sq.Eq{"message_id": messageIDs}).
+		OrderBy("created_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ar.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []entities.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// Attach links the author's unsent attachments in the message's chat to the
+// message and returns how many were linked. Attachments that don't qualify
+// are left alone.
+func (ar *AttachmentRepository) Attach(ctx context.Context, tx pgx.Tx, ids []uuid.UUID,
+	msg *entities.Message) (int64, error) {
+	sql, args, err := ar.builder.Update("attachments").
+		Set("message_id", msg.ID).
+		Where(sq.Eq{"id": ids, "uploader_id": msg.UserID, "message_id": nil}).
+		Where("chat_id = (SELECT id FROM chats WHERE tag = ?)", msg.ChatTag).
+		ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ClaimPendingThumbnails leases up to limit attachments waiting for a
+// preview, oldest first. A lease keeps other workers off an attachment until
+// it runs out, so work abandoned by a crashed worker is picked up again.
+func (ar *AttachmentRepository) ClaimPendingThumbnails(ctx context.Context, limit uint64,
+	lease time.Duration) ([]entities.Attachment, error) {
+	now := time.Now()
+
+	pending := sq.Select("id").
+		From("attachments").
+		Where(sq.Eq{"thumbnail_status": entities.ThumbnailPending}).
+		Where(sq.Or{sq.Eq{"thumbnail_locked_until": nil}, sq.Lt{"thumbnail_locked_until": now}}).
+		OrderBy("created_at").
+		Limit(limit)
+
+	orphaned := pending
+
+	sql, args, err := ar.builder.Update("attachments").
+		Set("thumbnail_locked_until", now.Add(lease)).
+		Where(claimable("id", orphaned)).
+		Suffix("RETURNING " + attachmentColumns).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ar.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []entities.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// UpdatePreview stores the outcome of preview generation and releases the
+// attachment's lease.
+func (ar *AttachmentRepository) UpdatePreview(ctx context.Context, a entities.Attachment) error {
+	sql, args, err := ar.builder.Update("attachments").
+		Set("width", a.Width).
+		Set("height", a.Height).
+		Set("placeholder", a.Placeholder).
+		Set("thumbnail_key", a.ThumbnailKey).
+		Set("thumbnail_status", a.ThumbnailStatus).
+		Set("thumbnail_locked_until", nil).
+		Where(sq.Eq{"id": a.ID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = ar.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// DeleteOrphaned removes up to limit attachments nobody can open any more:
+// uploads still unsent at unsentBefore and attachments of deleted messages.
+// The removed rows are returned so their blobs can be deleted too. Images
+// leased by a thumbnail worker are left for a later run.
+func (ar *AttachmentRepository) DeleteOrphaned(ctx context.Context, unsentBefore time.Time,
+	limit uint64) ([]entities.Attachment, error) {
+	sql, args, err := ar.builder.Delete("attachments").
+		Where(claimable("id", sq.Select("id").
+			From("attachments").
+			Where(sq.Or{
+				sq.And{sq.Eq{"message_id": nil}, sq.Lt{"created_at": unsentBefore}},
+				sq.Expr("EXISTS (SELECT 1 FROM messages WHERE messages.id = attachments.message_id " +
+					"AND messages.deleted_at IS NOT NULL)"),
+			}).
+			Where(sq.Or{sq.Eq{"thumbnail_locked_until": nil}, sq.Lt{"thumbnail_locked_until": time.Now()}}).
+			Limit(limit))).
+		Suffix("RETURNING " + attachmentColumns).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ar.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []entities.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// DeleteByChat deletes the attachments of a chat about to be deleted and
+// returns them, so their blobs can be removed once tx commits.
+func (ar *AttachmentRepository) DeleteByChat(ctx context.Context, tx pgx.Tx,
+	chatID uuid.UUID) ([]entities.Attachment, error) {
+	sql, args, err := ar.builder.Delete("attachments").
+		Where(sq.Eq{"chat_id": chatID}).
+		Suffix("RETURNING " + attachmentColumns).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []entities.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+const attachmentColumns = "id, chat_id, uploader_id, message_id, file_name, mime_type, size, checksum, " +
+	"storage_key, created_at, width, height, placeholder, thumbnail_key, thumbnail_status"
+
+func (ar *AttachmentRepository) selectAttachments() sq.SelectBuilder {
+	return ar.builder.Select(attachmentColumns).From("attachments")
+}
+
+func scanAttachment(row pgx.Row) (entities.Attachment, error) {
+	var a entities.Attachment
+	err := row.Scan(&a.ID, &a.ChatID, &a.UploaderID, &a.MessageID, &a.FileName, &a.MimeType,
+		&a.Size, &a.Checksum, &a.StorageKey, &a.CreatedAt,
+		&a.Width, &a.Height, &a.Placeholder, &a.ThumbnailKey, &a.ThumbnailStatus)
+
+	return a, err
+}
@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const botKeyColumns = "id, bot_id, name, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+type BotRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewBotRepository(pool *pgxpool.Pool) *BotRepository {
+	return &BotRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// Create adds the bot along with its user account. The account has no
+// password, email or phone, so nobody can log in as the bot.
+func (br *BotRepository) Create(ctx context.Context, tx pgx.Tx, bot entities.Bot) error {
+	sql, args, err := br.builder.Insert("user_accounts").
+		Columns("id", "tag", "name", "\"desc\"", "password_hash", "email", "phone").
+		Values(bot.ID, bot.Tag, bot.Name, bot.Desc, "", nil, nil).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return err
+	}
+
+	sql, args, err = br.builder.Insert("bots").
+		Columns("user_id", "owner_id", "created_at").
+		Values(bot.ID, bot.OwnerID, bot.CreatedAt).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+func (br *BotRepository) ReadByID(ctx context.Context, id uuid.UUID) (*entities.Bot, error) {
+	sql, args, err := br.selectBots().Where(sq.Eq{"b.user_id": id}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var bot entities.Bot
+	err = br.pool.QueryRow(ctx, sql, args...).Scan(&bot.ID, &bot.OwnerID, &bot.Tag, &bot.Name, &bot.Desc,
+		&bot.CreatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &bot, nil
+}
+
+func (br *BotRepository) ReadByOwner(ctx context.Context, ownerID uuid.UUID) ([]entities.Bot, error) {
+	sql, args, err := br.selectBots().
+		Where(sq.Eq{"b.owner_id": ownerID}).
+		OrderBy("b.created_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := br.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bots []entities.Bot
+	for rows.Next() {
+		var bot entities.Bot
+		if err := rows.Scan(&bot.ID, &bot.OwnerID, &bot.Tag, &bot.Name, &bot.Desc, &bot.CreatedAt); err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+
+	return bots, rows.Err()
+}
+
+func (br *BotRepository) CreateKey(ctx context.Context, key entities.BotKey) error {
+	sql, args, err := br.builder.Insert("bot_api_keys").
+		Columns(botKeyColumns).
+		Values(key.ID, key.BotID, key.Name, key.KeyHash, scopeNames(key.Scopes), key.CreatedAt, key.ExpiresAt,
+			key.LastUsedAt, key.RevokedAt).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = br.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (br *BotRepository) ReadKey(ctx context.Context, id uuid.UUID) (*entities.BotKey, error) {
+	sql, args, err := br.builder.Select(botKeyColumns).
+		From("bot_api_keys").
+		Where(sq.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scanBotKey(br.pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// ReadKeys returns the bot's keys, revoked ones included, newest first.
+func (br *BotRepository) ReadKeys(ctx context.Context, botID uuid.UUID) ([]entities.BotKey, error) {
+	sql, args, err := br.builder.Select(botKeyColumns).
+		From("bot_api_keys").
+		Where(sq.Eq{"bot_id": botID}).
+		OrderBy("created_at DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := br.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []entities.BotKey
+	for rows.Next() {
+		key, err := scanBotKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (br *BotRepository) RevokeKey(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	sql, args, err := br.builder.Update("bot_api_keys").
+		Set("revoked_at", revokedAt).
+		Where(sq.Eq{"id": id, "revoked_at": nil}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = br.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// TouchKey records that the key was used. To spare a write on every request
+// it is only recorded once per interval.
+func (br *BotRepository) TouchKey(ctx context.Context, id uuid.UUID, usedAt time.Time, interval time.Duration) error {
+	sql, args, err := br.builder.Update("bot_api_keys").
+		Set("last_used_at", usedAt).
+		Where(sq.Eq{"id": id}).
+		Where(sq.Or{sq.Eq{"last_used_at": nil}, sq.Lt{"last_used_at": usedAt.Add(-interval)}}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = br.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (br *BotRepository) selectBots() sq.SelectBuilder {
+	return br.builder.Select("b.user_id", "b.owner_id", "u.tag", "u.name", "u.\"desc\"", "b.created_at").
+		From("bots b").
+		Join("user_accounts u ON u.id = b.user_id")
+}
+
+func scanBotKey(row pgx.Row) (entities.BotKey, error) {
+	var (
+		k      entities.BotKey
+		scopes []string
+	)
+	err := row.Scan(&k.ID, &k.BotID, &k.Name, &k.KeyHash, &scopes, &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt,
+		&k.RevokedAt)
+	if err != nil {
+		return entities.BotKey{}, err
+	}
+
+	k.Scopes = make([]entities.BotScope, 0, len(scopes))
+	for _, s := range scopes {
+		k.Scopes = append(k.Scopes, entities.BotScope(s))
+	}
+
+	return k, nil
+}
+
+func scopeNames(scopes []entities.BotScope) []string {
+	names := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		names = append(names, string(s))
+	}
+
+	return names
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type ChatInviteRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewChatInviteRepository(pool *pgxpool.Pool) *ChatInviteRepository {
+	return &ChatInviteRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (cir *ChatInviteRepository) Create(ctx context.Context, tx pgx.Tx, invite entities.ChatInvite) error {
+	sql, args, err := cir.builder.Insert("chat_invites").
+		Columns("id", "chat_id", "code", "created_by", "created_at", "expires_at", "max_uses", "uses").
+		Values(invite.ID, invite.ChatID, invite.Code, invite.CreatedBy, invite.CreatedAt, invite.ExpiresAt,
+			invite.MaxUses, invite.Uses).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+func (cir *ChatInviteRepository) ReadByCode(ctx context.Context, code string) (*entities.ChatInvite, error) {
+	sql, args, err := cir.builder.
+		Select("id", "chat_id", "code", "created_by", "created_at", "expires_at", "max_uses", "uses", "revoked_at").
+		From("chat_invites").
+		Where(sq.Eq{"code": code}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var invite entities.ChatInvite
+	err = cir.pool.QueryRow(ctx, sql, args...).Scan(&invite.ID, &invite.ChatID, &invite.Code, &invite.CreatedBy,
+		&invite.CreatedAt, &invite.ExpiresAt, &invite.MaxUses, &invite.Uses, &invite.RevokedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+func (cir *ChatInviteRepository) ReadActiveByChatID(ctx context.Context, chatID uuid.UUID) ([]entities.ChatInvite, error) {
+	sql, args, err := cir.builder.
+		Select("id", "chat_id", "code", "created_by", "created_at", "expires_at", "max_uses", "uses", "revoked_at").
+		From("chat_invites").
+		Where(sq.Eq{"chat_id": chatID, "revoked_at": nil}).
+		Where(sq.Or{sq.Eq{"expires_at": nil}, sq.Expr("expires_at > now()")}).
+		Where(sq.Or{sq.Eq{"max_uses": nil}, sq.Expr("uses < max_uses")}).
+		OrderBy("created_at DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cir.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []entities.ChatInvite
+	for rows.Next() {
+		var invite entities.ChatInvite
+		err := rows.Scan(&invite.ID, &invite.ChatID, &invite.Code, &invite.CreatedBy, &invite.CreatedAt,
+			&invite.ExpiresAt, &invite.MaxUses, &invite.Uses, &invite.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+
+	return invites, rows.Err()
+}
+
+// Use counts one more use of the invite if it is still usable. It reports
+// false if the invite was revoked, expired or used up, which keeps concurrent
+// joins from exceeding the limit.
+func (cir *ChatInviteRepository) Use(ctx context.Context, tx pgx.Tx, id uuid.UUID) (bool, error) {
+	sql, args, err := cir.builder.Update("chat_invites").
+		Set("uses", sq.Expr("uses + 1")).
+		Where(sq.Eq{"id": id, "revoked_at": nil}).
+		Where(sq.Or{sq.Eq{"expires_at": nil}, sq.Expr("expires_at > now()")}).
+		Where(sq.Or{sq.Eq{"max_uses": nil}, sq.Expr("uses < max_uses")}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (cir *ChatInviteRepository) Revoke(ctx context.Context, tx pgx.Tx, id uuid.UUID, revokedAt time.Time) error {
+	sql, args, err := cir.builder.Update("chat_invites").
+		Set("revoked_at", revokedAt).
+		Where(sq.Eq{"id": id, "revoked_at": nil}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+func (cir *ChatInviteRepository) CreateEvent(ctx context.Context, tx pgx.Tx, event entities.ChatInviteEvent) error {
+	sql, args, err := cir.builder.Insert("chat_invite_events").
+		Columns("id", "invite_id", "user_id", "event", "occurred_at").
+		Values(event.ID, event.InviteID, event.UserID, event.Event, event.OccurredAt).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
@@ -2,31 +2,239 @@ package repositories
 
 import (
 	"context"
-	"log"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 	"github.com/renderview-inc/backend/internal/app/domain/entities"
 )
 
 type ChatRepository struct {
 	pool    *pgxpool.Pool
 	builder sq.StatementBuilderType
+	logger  logger.Logger
 }
 
-func NewChatRepository(pool *pgxpool.Pool) *ChatRepository {
+func NewChatRepository(pool *pgxpool.Pool, logger logger.Logger) *ChatRepository {
 	return &ChatRepository{
 		pool:    pool,
 		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		logger:  logger,
 	}
 }
 
-func (cr *ChatRepository) Create(ctx context.Context, chat entities.Chat) error {
+func (cr *ChatRepository) Create(ctx context.Context, tx pgx.Tx, chat entities.Chat) error {
 	sql, args, err := cr.builder.Insert("chats").
-		Columns("id", "tag", "owner_id", "created_at", "title").
-		Values(chat.Id, chat.Tag, chat.OwnerId, chat.CreatedAt, chat.Title).
+		Columns("id", "tag", "owner_id", "created_at", "title", "kind").
+		Values(chat.Id, chat.Tag, chat.OwnerId, chat.CreatedAt, chat.Title, chat.Kind).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	cr.logger.Info(ctx, "chat created",
+		option.Any("chat_id", chat.Id.String()),
+		option.Any("chat_tag", chat.Tag),
+	)
+
+	return nil
+}
+
+// CreateDirect saves a direct chat and links it to its pair of users. It
+// reports false, leaving the transaction to be rolled back, if the pair
+// already has a direct chat.
+func (cr *ChatRepository) CreateDirect(ctx context.Context, tx pgx.Tx, chat entities.Chat,
+	pair entities.DirectChat) (bool, error) {
+	sql, args, err := cr.builder.Insert("chats").
+		Columns("id", "tag", "owner_id", "created_at", "title", "kind").
+		Values(chat.Id, chat.Tag, chat.OwnerId, chat.CreatedAt, chat.Title, chat.Kind).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return false, err
+	}
+
+	sql, args, err = cr.builder.Insert("direct_chats").
+		Columns("chat_id", "user_low", "user_high").
+		Values(pair.ChatID, pair.UserLow, pair.UserHigh).
+		Suffix("ON CONFLICT (user_low, user_high) DO NOTHING").
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (cr *ChatRepository) ReadDirectByUsers(ctx context.Context, userLow, userHigh uuid.UUID) (*entities.Chat, error) {
+	sql, args, err := cr.builder.Select("c.id", "c.tag", "c.owner_id", "c.created_at", "c.title", "c.kind").
+		From("direct_chats d").
+		Join("chats c ON c.id = d.chat_id").
+		Where(sq.Eq{"d.user_low": userLow, "d.user_high": userHigh}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var chat entities.Chat
+	err = cr.pool.QueryRow(ctx, sql, args...).Scan(&chat.Id, &chat.Tag, &chat.OwnerId, &chat.CreatedAt, &chat.Title,
+		&chat.Kind)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &chat, nil
+}
+
+func (cr *ChatRepository) ReadDirectPair(ctx context.Context, chatID uuid.UUID) (*entities.DirectChat, error) {
+	sql, args, err := cr.builder.Select("chat_id", "user_low", "user_high").
+		From("direct_chats").
+		Where(sq.Eq{"chat_id": chatID}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var pair entities.DirectChat
+	err = cr.pool.QueryRow(ctx, sql, args...).Scan(&pair.ChatID, &pair.UserLow, &pair.UserHigh)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &pair, nil
+}
+
+func (cr *ChatRepository) AddParticipant(ctx context.Context, tx pgx.Tx, chatID, userID uuid.UUID,
+	role entities.ChatRole) error {
+	sql, args, err := cr.builder.Insert("chat_participants").
+		Columns("chat_id", "user_id", "role").
+		Values(chatID, userID, role).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+func (cr *ChatRepository) ReadParticipantRole(ctx context.Context, chatID, userID uuid.UUID) (*entities.ChatRole, error) {
+	sql, args, err := cr.builder.Select("role").
+		From("chat_participants").
+		Where(sq.Eq{"chat_id": chatID, "user_id": userID}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var role entities.ChatRole
+	err = cr.pool.QueryRow(ctx, sql, args...).Scan(&role)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// ReadPreferences returns the participant's preferences for the chat, or nil
+// if they don't participate in it.
+func (cr *ChatRepository) ReadPreferences(ctx context.Context, chatID, userID uuid.UUID) (*entities.ChatPreferences, error) {
+	sql, args, err := cr.builder.Select("chat_id", "user_id", "muted_until", "notify_level", "archived").
+		From("chat_participants").
+		Where(sq.Eq{"chat_id": chatID, "user_id": userID}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var prefs entities.ChatPreferences
+	err = cr.pool.QueryRow(ctx, sql, args...).
+		Scan(&prefs.ChatID, &prefs.UserID, &prefs.MutedUntil, &prefs.NotifyLevel, &prefs.Archived)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &prefs, nil
+}
+
+// ReadPreferencesByChat returns the preferences of every participant of the
+// chat.
+func (cr *ChatRepository) ReadPreferencesByChat(ctx context.Context, chatID uuid.UUID) ([]entities.ChatPreferences, error) {
+	sql, args, err := cr.builder.Select("chat_id", "user_id", "muted_until", "notify_level", "archived").
+		From("chat_participants").
+		Where(sq.Eq{"chat_id": chatID}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []entities.ChatPreferences
+	for rows.Next() {
+		var prefs entities.ChatPreferences
+		err := rows.Scan(&prefs.ChatID, &prefs.UserID, &prefs.MutedUntil, &prefs.NotifyLevel, &prefs.Archived)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, prefs)
+	}
+
+	return result, rows.Err()
+}
+
+func (cr *ChatRepository) UpdatePreferences(ctx context.Context, prefs entities.ChatPreferences) error {
+	sql, args, err := cr.builder.Update("chat_participants").
+		Set("muted_until", prefs.MutedUntil).
+		Set("notify_level", prefs.NotifyLevel).
+		Set("archived", prefs.Archived).
+		Where(sq.Eq{"chat_id": prefs.ChatID, "user_id": prefs.UserID}).
 		ToSql()
 
 	if err != nil {
@@ -34,27 +242,83 @@ func (cr *ChatRepository) Create(ctx context.Context, chat entities.Chat) error
 	}
 
 	_, err = cr.pool.Exec(ctx, sql, args...)
-	log.Printf("Chat created: %v", chat.Tag)
+	return err
+}
+
+// ReadChatIDsByUser returns the IDs of every chat the user participates in.
+func (cr *ChatRepository) ReadChatIDsByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	sql, args, err := cr.builder.Select("chat_id").
+		From("chat_participants").
+		Where(sq.Eq{"user_id": userID}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (cr *ChatRepository) UpdateParticipantRole(ctx context.Context, tx pgx.Tx, chatID, userID uuid.UUID,
+	role entities.ChatRole) error {
+	sql, args, err := cr.builder.Update("chat_participants").
+		Set("role", role).
+		Where(sq.Eq{"chat_id": chatID, "user_id": userID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
 
+func (cr *ChatRepository) UpdateOwner(ctx context.Context, tx pgx.Tx, chatID, ownerID uuid.UUID) error {
+	sql, args, err := cr.builder.Update("chats").
+		Set("owner_id", ownerID).
+		Where(sq.Eq{"id": chatID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
 	return err
 }
 
-func (cr *ChatRepository) AddParticipant(ctx context.Context, chatID, userID uuid.UUID) error {
-    sql, args, err := cr.builder.Insert("chat_participants").
-        Columns("chat_id", "user_id").
-        Values(chatID, userID).
-        ToSql()
+func (cr *ChatRepository) CreateRoleChange(ctx context.Context, tx pgx.Tx, change entities.ChatRoleChange) error {
+	sql, args, err := cr.builder.Insert("chat_role_changes").
+		Columns("id", "chat_id", "user_id", "actor_id", "old_role", "new_role", "changed_at").
+		Values(change.ID, change.ChatID, change.UserID, change.ActorID, change.OldRole, change.NewRole, change.ChangedAt).
+		ToSql()
 
-    if err != nil {
-        return err
-    }
+	if err != nil {
+		return err
+	}
 
-    _, err = cr.pool.Exec(ctx, sql, args...)
-    return err
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
 }
 
 func (cr *ChatRepository) ReadByTag(ctx context.Context, tag string) (*entities.Chat, error) {
-	sql, args, err := cr.builder.Select("id", "owner_id", "created_at", "title").
+	sql, args, err := cr.builder.Select("id", "owner_id", "created_at", "title", "kind").
 		From("chats").Where(sq.Eq{"tag": tag}).ToSql()
 
 	if err != nil {
@@ -62,7 +326,7 @@ func (cr *ChatRepository) ReadByTag(ctx context.Context, tag string) (*entities.
 	}
 
 	var chat entities.Chat
-	err = cr.pool.QueryRow(ctx, sql, args...).Scan(&chat.Id, &chat.OwnerId, &chat.CreatedAt, &chat.Title)
+	err = cr.pool.QueryRow(ctx, sql, args...).Scan(&chat.Id, &chat.OwnerId, &chat.CreatedAt, &chat.Title, &chat.Kind)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -76,13 +340,31 @@ func (cr *ChatRepository) ReadByTag(ctx context.Context, tag string) (*entities.
 	return &chat, nil
 }
 
-func (cr *ChatRepository) GetChatsWithLastMessages(ctx context.Context) ([]entities.ChatLastMessages, error) {
+// GetChatsWithLastMessages returns the chats the user participates in, most
+// recently active first.
+func (cr *ChatRepository) GetChatsWithLastMessages(ctx context.Context, userID uuid.UUID, archived bool,
+	limit, offset uint64) ([]entities.ChatLastMessages, error) {
 	sql, args, err := cr.builder.
-        Select("DISTINCT ON (c.id) c.id AS chat_id, m.id AS message_id, m.user_id, m.content, EXTRACT(EPOCH FROM m.created_at)::BIGINT AS timestamp").
-        From("chats c").
-        LeftJoin("messages m ON m.chat_tag = c.tag").
-        OrderBy("c.id", "m.created_at DESC").
-        ToSql()
+		Select(
+			"c.id", "c.tag",
+			"CASE WHEN c.kind = 'direct' THEN COALESCE(NULLIF(ou.name, ''), ou.tag, '') ELSE c.title END",
+			"c.kind", "cp.role", "cp.last_read_at", "cp.muted_until", "cp.notify_level", "cp.archived",
+			"lm.id", "lm.user_id", "lm.content", "lm.created_at",
+			"COALESCE(lm.created_at, c.created_at) AS activity_at",
+		).
+		Column(sq.Expr("(SELECT count(*) FROM messages um WHERE um.chat_tag = c.tag AND um.user_id <> ? "+
+			"AND um.deleted_at IS NULL AND (cp.last_read_at IS NULL OR um.created_at > cp.last_read_at))", userID)).
+		From("chat_participants cp").
+		Join("chats c ON c.id = cp.chat_id").
+		LeftJoin("LATERAL (SELECT m.id, m.user_id, m.content, m.created_at FROM messages m "+
+			"WHERE m.chat_tag = c.tag AND m.deleted_at IS NULL ORDER BY m.created_at DESC LIMIT 1) lm ON true").
+		LeftJoin("direct_chats d ON d.chat_id = c.id").
+		LeftJoin("user_accounts ou ON ou.id = CASE WHEN d.user_low = ? THEN d.user_high ELSE d.user_low END", userID).
+		Where(sq.Eq{"cp.user_id": userID, "cp.archived": archived}).
+		OrderBy("activity_at DESC", "c.id").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
 
 	if err != nil {
 		return nil, err
@@ -96,25 +378,54 @@ func (cr *ChatRepository) GetChatsWithLastMessages(ctx context.Context) ([]entit
 
 	var result []entities.ChatLastMessages
 	for rows.Next() {
-		var msg entities.ChatLastMessages
+		var (
+			entry     entities.ChatLastMessages
+			msgID     *uuid.UUID
+			msgUserID *uuid.UUID
+			content   *string
+			createdAt *time.Time
+		)
 		err := rows.Scan(
-			&msg.ChatID,
-			&msg.ID,
-			&msg.UserID,
-			&msg.Message,
-			&msg.Timestamp,
+			&entry.ChatID,
+			&entry.ChatTag,
+			&entry.Title,
+			&entry.Kind,
+			&entry.Role,
+			&entry.LastReadAt,
+			&entry.MutedUntil,
+			&entry.NotifyLevel,
+			&entry.Archived,
+			&msgID,
+			&msgUserID,
+			&content,
+			&createdAt,
+			&entry.ActivityAt,
+			&entry.UnreadCount,
 		)
 		if err != nil {
 			return nil, err
 		}
-		result = append(result, msg)
+
+		if msgID != nil {
+			entry.LastMessage = &entities.Message{
+				ID:        *msgID,
+				ChatTag:   entry.ChatTag,
+				Content:   *content,
+				CreatedAt: *createdAt,
+			}
+			if msgUserID != nil {
+				entry.LastMessage.UserID = *msgUserID
+			}
+		}
+
+		result = append(result, entry)
 	}
-	return result, nil
-}
 
+	return result, rows.Err()
+}
 
 func (cr *ChatRepository) ReadByID(ctx context.Context, id uuid.UUID) (*entities.Chat, error) {
-	sql, args, err := cr.builder.Select("tag", "owner_id", "created_at", "title").
+	sql, args, err := cr.builder.Select("tag", "owner_id", "created_at", "title", "kind").
 		From("chats").Where(sq.Eq{"id": id}).ToSql()
 
 	if err != nil {
@@ -122,7 +433,7 @@ func (cr *ChatRepository) ReadByID(ctx context.Context, id uuid.UUID) (*entities
 	}
 
 	var chat entities.Chat
-	err = cr.pool.QueryRow(ctx, sql, args...).Scan(&chat.Tag, &chat.OwnerId, &chat.CreatedAt, &chat.Title)
+	err = cr.pool.QueryRow(ctx, sql, args...).Scan(&chat.Tag, &chat.OwnerId, &chat.CreatedAt, &chat.Title, &chat.Kind)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -136,7 +447,7 @@ func (cr *ChatRepository) ReadByID(ctx context.Context, id uuid.UUID) (*entities
 	return &chat, nil
 }
 
-func (cr *ChatRepository) Update(ctx context.Context, chat entities.Chat) error {
+func (cr *ChatRepository) Update(ctx context.Context, tx pgx.Tx, chat entities.Chat) error {
 	sql, args, err :=
 		cr.builder.Update("chats").
 			Set("tag", chat.Tag).
@@ -150,7 +461,7 @@ func (cr *ChatRepository) Update(ctx context.Context, chat entities.Chat) error
 		return err
 	}
 
-	_, err = cr.pool.Exec(ctx, sql, args...)
+	_, err = tx.Exec(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
@@ -158,43 +469,41 @@ func (cr *ChatRepository) Update(ctx context.Context, chat entities.Chat) error
 	return nil
 }
 
-func (cr *ChatRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	sql, args, err := cr.builder.Delete("chats").Where(sq.Eq{"id": id}).ToSql()
-
-	if err != nil {
-		return err
+// Delete deletes the chat with its participants and messages. Other rows
+// tied to the chat or its messages go with them by cascade.
+func (cr *ChatRepository) Delete(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	const chatMessages = "(SELECT m.id FROM messages m JOIN chats c ON c.tag = m.chat_tag WHERE c.id = ?)"
+
+	deletes := []sq.DeleteBuilder{
+		cr.builder.Delete("message_reactions").Where("message_id IN "+chatMessages, id),
+		cr.builder.Delete("message_revisions").Where("message_id IN "+chatMessages, id),
+		cr.builder.Delete("messages").Where("id IN "+chatMessages, id),
+		cr.builder.Delete("chat_participants").Where(sq.Eq{"chat_id": id}),
+		cr.builder.Delete("chats").Where(sq.Eq{"id": id}),
 	}
+	for _, del := range deletes {
+		sql, args, err := del.ToSql()
+		if err != nil {
+			return err
+		}
 
-	_, err = cr.pool.Exec(ctx, sql, args...)
-	if err != nil {
-		return err
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (cr *ChatRepository) RemoveParticipant(ctx context.Context, chatID, userID uuid.UUID) error {
-    sql, args, err := cr.builder.Delete("chat_participants").
-        Where(sq.Eq{"chat_id": chatID, "user_id": userID}).
-        ToSql()
+func (cr *ChatRepository) RemoveParticipant(ctx context.Context, tx pgx.Tx, chatID, userID uuid.UUID) error {
+	sql, args, err := cr.builder.Delete("chat_participants").
+		Where(sq.Eq{"chat_id": chatID, "user_id": userID}).
+		ToSql()
 
-    if err != nil {
-        return err
-    }
+	if err != nil {
+		return err
+	}
 
-    _, err = cr.pool.Exec(ctx, sql, args...)
-    return err
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
 }
-
-func (cr *ChatRepository) RemoveAllParticipants(ctx context.Context, chatID uuid.UUID) error {
-    sql, args, err := cr.builder.Delete("chat_participants").
-        Where(sq.Eq{"chat_id": chatID}).
-        ToSql()
-
-    if err != nil {
-        return err
-    }
-
-    _, err = cr.pool.Exec(ctx, sql, args...)
-    return err
-}
\ No newline at end of file
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// claimable matches column against the rows a claim query selects and locks
+// them, skipping rows another transaction holds, so concurrent workers never
+// claim the same row. The subquery is built with ? placeholders whatever its
+// builder; they are numbered along with the outer statement's. lockOf
+// narrows the lock to some of the subquery's tables.
+func claimable(column string, rows sq.SelectBuilder, lockOf ...string) sq.Sqlizer {
+	lock := "FOR UPDATE"
+	if len(lockOf) > 0 {
+		lock += " OF " + strings.Join(lockOf, ", ")
+	}
+
+	return claimableRows{column: column, rows: rows.PlaceholderFormat(sq.Question).Suffix(lock + " SKIP LOCKED")}
+}
+
+type claimableRows struct {
+	column string
+	rows   sq.SelectBuilder
+}
+
+func (c claimableRows) ToSql() (string, []any, error) {
+	sql, args, err := c.rows.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return c.column + " IN (" + sql + ")", args, nil
+}
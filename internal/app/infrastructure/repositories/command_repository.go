@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+// commandColumns and invocationColumns are listed in the order
+// scanChatCommand and scanInvocation read them.
+const (
+	commandColumns    = "chat_id, name, bot_id, description, created_by, created_at"
+	invocationColumns = "command_invocations.id, chat_id, chats.tag, bot_id, user_id, command, args, " +
+		"command_invocations.created_at, claimed_at"
+)
+
+type CommandRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewCommandRepository(pool *pgxpool.Pool) *CommandRepository {
+	return &CommandRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (cr *CommandRepository) Create(ctx context.Context, cmd entities.ChatCommand) error {
+	sql, args, err := cr.builder.Insert("chat_commands").
+		Columns(commandColumns).
+		Values(cmd.ChatID, cmd.Name, cmd.BotID, cmd.Desc, cmd.CreatedBy, cmd.CreatedAt).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (cr *CommandRepository) ReadCommand(ctx context.Context, chatID uuid.UUID,
+	name string) (*entities.ChatCommand, error) {
+	sql, args, err := cr.builder.Select(commandColumns).
+		From("chat_commands").
+		Where(sq.Eq{"chat_id": chatID, "name": name}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := scanChatCommand(cr.pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &cmd, nil
+}
+
+// ReadCommands returns the commands registered in the chat by name.
+func (cr *CommandRepository) ReadCommands(ctx context.Context, chatID uuid.UUID) ([]entities.ChatCommand, error) {
+	sql, args, err := cr.builder.Select(commandColumns).
+		From("chat_commands").
+		Where(sq.Eq{"chat_id": chatID}).
+		OrderBy("name").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commands []entities.ChatCommand
+	for rows.Next() {
+		cmd, err := scanChatCommand(rows)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	return commands, rows.Err()
+}
+
+func (cr *CommandRepository) Delete(ctx context.Context, chatID uuid.UUID, name string) error {
+	sql, args, err := cr.builder.Delete("chat_commands").
+		Where(sq.Eq{"chat_id": chatID, "name": name}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (cr *CommandRepository) CreateInvocation(ctx context.Context, inv entities.CommandInvocation) error {
+	sql, args, err := cr.builder.Insert("command_invocations").
+		Columns("id", "chat_id", "bot_id", "user_id", "command", "args", "created_at").
+		Values(inv.ID, inv.ChatID, inv.BotID, inv.UserID, inv.Command, inv.Args, inv.CreatedAt).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (cr *CommandRepository) ReadInvocation(ctx context.Context, id uuid.UUID) (*entities.CommandInvocation, error) {
+	sql, args, err := cr.builder.Select(invocationColumns).
+		From("command_invocations").
+		Join("chats ON chats.id = command_invocations.chat_id").
+		Where(sq.Eq{"command_invocations.id": id}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := scanInvocation(cr.pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &inv, nil
+}
+
+// ClaimInvocations marks up to limit of the bot's unclaimed invocations made
+// after since as claimed and returns them, so each is handed out only once.
+func (cr *CommandRepository) ClaimInvocations(ctx context.Context, botID uuid.UUID, since time.Time,
+	limit uint64) ([]entities.CommandInvocation, error) {
+	due := sq.Select("id").
+		From("command_invocations").
+		Where(sq.Eq{"bot_id": botID, "claimed_at": nil}).
+		Where(sq.Gt{"created_at": since}).
+		OrderBy("created_at").
+		Limit(limit)
+
+	sql, args, err := cr.builder.Update("command_invocations").
+		Set("claimed_at", time.Now()).
+		From("chats").
+		Where("chats.id = command_invocations.chat_id").
+		Where(claimable("command_invocations.id", due)).
+		Suffix("RETURNING " + invocationColumns).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invocations []entities.CommandInvocation
+	for rows.Next() {
+		inv, err := scanInvocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invocations = append(invocations, inv)
+	}
+
+	return invocations, rows.Err()
+}
+
+func (cr *CommandRepository) CreateReply(ctx context.Context, reply entities.CommandReply) error {
+	sql, args, err := cr.builder.Insert("command_replies").
+		Columns("id", "invocation_id", "content", "created_at").
+		Values(reply.ID, reply.InvocationID, reply.Content, reply.CreatedAt).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// ReadReplies returns the ephemeral replies to the user's commands in the
+// chat made after since, oldest first.
+func (cr *CommandRepository) ReadReplies(ctx context.Context, userID, chatID uuid.UUID,
+	since time.Time) ([]entities.CommandReply, error) {
+	sql, args, err := cr.builder.Select("r.id", "r.invocation_id", "i.command", "i.chat_id", "r.content",
+		"r.created_at").
+		From("command_replies r").
+		Join("command_invocations i ON i.id = r.invocation_id").
+		Where(sq.Eq{"i.user_id": userID, "i.chat_id": chatID}).
+		Where(sq.Gt{"r.created_at": since}).
+		OrderBy("r.created_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var replies []entities.CommandReply
+	for rows.Next() {
+		var r entities.CommandReply
+		if err := rows.Scan(&r.ID, &r.InvocationID, &r.Command, &r.ChatID, &r.Content, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		replies = append(replies, r)
+	}
+
+	return replies, rows.Err()
+}
+
+func scanChatCommand(row pgx.Row) (entities.ChatCommand, error) {
+	var c entities.ChatCommand
+	err := row.Scan(&c.ChatID, &c.Name, &c.BotID, &c.Desc, &c.CreatedBy, &c.CreatedAt)
+
+	return c, err
+}
+
+func scanInvocation(row pgx.Row) (entities.CommandInvocation, error) {
+	var i entities.CommandInvocation
+	err := row.Scan(&i.ID, &i.ChatID, &i.ChatTag, &i.BotID, &i.UserID, &i.Command, &i.Args, &i.CreatedAt,
+		&i.ClaimedAt)
+
+	return i, err
+}
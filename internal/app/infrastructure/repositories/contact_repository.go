@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type ContactRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewContactRepository(pool *pgxpool.Pool) *ContactRepository {
+	return &ContactRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// AddContact adds contactID to the user's contacts unless it is already
+// there.
+func (cr *ContactRepository) AddContact(ctx context.Context, userID, contactID uuid.UUID, at time.Time) error {
+	sql, args, err := cr.builder.Insert("user_contacts").
+		Columns("user_id", "contact_id", "added_at").
+		Values(userID, contactID, at).
+		Suffix("ON CONFLICT (user_id, contact_id) DO NOTHING").
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (cr *ContactRepository) RemoveContact(ctx context.Context, userID, contactID uuid.UUID) error {
+	sql, args, err := cr.builder.Delete("user_contacts").
+		Where(sq.Eq{"user_id": userID, "contact_id": contactID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// ReadContacts returns a page of the user's contacts ordered by tag.
+func (cr *ContactRepository) ReadContacts(ctx context.Context, userID uuid.UUID,
+	limit, offset uint64) ([]entities.Contact, error) {
+	sql, args, err := cr.builder.Select("u.id", "u.tag", "u.name", "u.\"desc\"", "c.added_at").
+		From("user_contacts c").
+		Join("user_accounts u ON u.id = c.contact_id").
+		Where(sq.Eq{"c.user_id": userID}).
+		OrderBy("u.tag").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []entities.Contact
+	for rows.Next() {
+		var c entities.Contact
+		if err := rows.Scan(&c.Profile.ID, &c.Profile.Tag, &c.Profile.Name, &c.Profile.Desc, &c.AddedAt); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, c)
+	}
+
+	return contacts, rows.Err()
+}
+
+// Block adds blockedID to the user's block list unless it is already there.
+func (cr *ContactRepository) Block(ctx context.Context, userID, blockedID uuid.UUID, at time.Time) error {
+	sql, args, err := cr.builder.Insert("user_blocks").
+		Columns("user_id", "blocked_id", "blocked_at").
+		Values(userID, blockedID, at).
+		Suffix("ON CONFLICT (user_id, blocked_id) DO NOTHING").
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (cr *ContactRepository) Unblock(ctx context.Context, userID, blockedID uuid.UUID) error {
+	sql, args, err := cr.builder.Delete("user_blocks").
+		Where(sq.Eq{"user_id": userID, "blocked_id": blockedID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// ReadBlocked returns a page of the users the user has blocked, most
+// recently blocked first.
+func (cr *ContactRepository) ReadBlocked(ctx context.Context, userID uuid.UUID,
+	limit, offset uint64) ([]entities.BlockedUser, error) {
+	sql, args, err := cr.builder.Select("u.id", "u.tag", "u.name", "u.\"desc\"", "b.blocked_at").
+		From("user_blocks b").
+		Join("user_accounts u ON u.id = b.blocked_id").
+		Where(sq.Eq{"b.user_id": userID}).
+		OrderBy("b.blocked_at DESC", "u.tag").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocked []entities.BlockedUser
+	for rows.Next() {
+		var b entities.BlockedUser
+		if err := rows.Scan(&b.Profile.ID, &b.Profile.Tag, &b.Profile.Name, &b.Profile.Desc, &b.BlockedAt); err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, b)
+	}
+
+	return blocked, rows.Err()
+}
+
+// IsBlocked reports whether userID has blocked blockedID.
+func (cr *ContactRepository) IsBlocked(ctx context.Context, userID, blockedID uuid.UUID) (bool, error) {
+	sql, args, err := cr.builder.Select("1").
+		Prefix("SELECT EXISTS (").
+		From("user_blocks").
+		Where(sq.Eq{"user_id": userID, "blocked_id": blockedID}).
+		Suffix(")").
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var blocked bool
+	err = cr.pool.QueryRow(ctx, sql, args...).Scan(&blocked)
+
+	return blocked, err
+}
+
+// ReadBlockers returns those of userIDs who have blocked blockedID.
+func (cr *ContactRepository) ReadBlockers(ctx context.Context, blockedID uuid.UUID,
+	userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	sql, args, err := cr.builder.Select("user_id").
+		From("user_blocks").
+		Where(sq.Eq{"blocked_id": blockedID, "user_id": userIDs}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blockers []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		blockers = append(blockers, id)
+	}
+
+	return blockers, rows.Err()
+}
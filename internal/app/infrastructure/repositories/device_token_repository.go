@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type DeviceTokenRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewDeviceTokenRepository(pool *pgxpool.Pool) *DeviceTokenRepository {
+	return &DeviceTokenRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// Upsert registers the token. A token registered before, possibly by another
+// user on the same device, now belongs to the given one.
+func (dtr *DeviceTokenRepository) Upsert(ctx context.Context, token entities.DeviceToken) error {
+	sql, args, err := dtr.builder.Insert("device_tokens").
+		Columns("token", "user_id", "platform", "registered_at").
+		Values(token.Token, token.UserID, token.Platform, token.RegisteredAt).
+		Suffix("ON CONFLICT (token) DO UPDATE SET user_id = EXCLUDED.user_id, platform = EXCLUDED.platform, " +
+			"registered_at = EXCLUDED.registered_at").
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = dtr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// Delete unregisters the user's token. Tokens of other users are left alone.
+func (dtr *DeviceTokenRepository) Delete(ctx context.Context, userID uuid.UUID, token string) error {
+	sql, args, err := dtr.builder.Delete("device_tokens").
+		Where(sq.Eq{"token": token, "user_id": userID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = dtr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// DeleteTokens forgets the tokens whoever they belong to.
+func (dtr *DeviceTokenRepository) DeleteTokens(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	sql, args, err := dtr.builder.Delete("device_tokens").
+		Where(sq.Eq{"token": tokens}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = dtr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (dtr *DeviceTokenRepository) ReadByUsers(ctx context.Context, userIDs []uuid.UUID) ([]entities.DeviceToken, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	sql, args, err := dtr.builder.Select("token", "user_id", "platform", "registered_at").
+		From("device_tokens").
+		Where(sq.Eq{"user_id": userIDs}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := dtr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []entities.DeviceToken
+	for rows.Next() {
+		var t entities.DeviceToken
+		if err := rows.Scan(&t.Token, &t.UserID, &t.Platform, &t.RegisteredAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
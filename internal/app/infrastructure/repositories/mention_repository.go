@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type MentionRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewMentionRepository(pool *pgxpool.Pool) *MentionRepository {
+	return &MentionRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// ResolveParticipants maps those of the lower-case tags that belong to
+// participants of the chat to their user IDs.
+func (mr *MentionRepository) ResolveParticipants(ctx context.Context, chatTag string,
+	tags []string) (map[string]uuid.UUID, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	sql, args, err := mr.builder.Select("lower(u.tag)", "u.id").
+		From("chat_participants cp").
+		Join("chats c ON c.id = cp.chat_id").
+		Join("user_accounts u ON u.id = cp.user_id").
+		Where(sq.Eq{"c.tag": chatTag, "lower(u.tag)": tags}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := mr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]uuid.UUID, len(tags))
+	for rows.Next() {
+		var (
+			tag string
+			id  uuid.UUID
+		)
+		if err := rows.Scan(&tag, &id); err != nil {
+			return nil, err
+		}
+		users[tag] = id
+	}
+
+	return users, rows.Err()
+}
+
+// Create stores the mentions of a message posted to the chat at createdAt.
+func (mr *MentionRepository) Create(ctx context.Context, tx pgx.Tx, chatTag string, createdAt time.Time,
+	mentions []entities.MessageMention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	insert := mr.builder.Insert("message_mentions").
+		Columns("message_id", "\"offset\"", "\"length\"", "user_id", "chat_id", "created_at")
+	for _, m := range mentions {
+		insert = insert.Values(m.MessageID, m.Offset, m.Length, m.UserID,
+			sq.Expr("(SELECT id FROM chats WHERE tag = ?)", chatTag), createdAt)
+	}
+
+	sql, args, err := insert.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+func (mr *MentionRepository) DeleteByMessage(ctx context.Context, tx pgx.Tx, messageID uuid.UUID) error {
+	sql, args, err := mr.builder.Delete("message_mentions").
+		Where(sq.Eq{"message_id": messageID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+// ReadByMessageIDs returns the mentions in the messages, each message's in
+// the order they appear.
+func (mr *MentionRepository) ReadByMessageIDs(ctx context.Context,
+	ids []uuid.UUID) ([]entities.MessageMention, error) {
+	sql, args, err := mr.builder.Select("message_id", "user_id", "\"offset\"", "\"length\"").
+		From("message_mentions").
+		Where(sq.Eq{"message_id": ids}).
+		OrderBy("message_id", "\"offset\"").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := mr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mentions []entities.MessageMention
+	for rows.Next() {
+		var m entities.MessageMention
+		if err := rows.Scan(&m.MessageID, &m.UserID, &m.Offset, &m.Length); err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, m)
+	}
+
+	return mentions, rows.Err()
+}
+
+// ReadFeed returns a page of the live messages mentioning the user in chats
+// they still participate in, newest first.
+func (mr *MentionRepository) ReadFeed(ctx context.Context, userID uuid.UUID,
+	limit, offset uint64) ([]entities.MentionFeedEntry, error) {
+	sql, args, err := mr.builder.Select("mm.message_id", "mm.chat_id", "mm.created_at").
+		Distinct().
+		From("message_mentions mm").
+		Join("messages m ON m.id = mm.message_id").
+		Join("chat_participants cp ON cp.chat_id = mm.chat_id AND cp.user_id = mm.user_id").
+		Where(sq.Eq{"mm.user_id": userID, "m.deleted_at": nil}).
+		OrderBy("mm.created_at DESC", "mm.message_id").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := mr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feed []entities.MentionFeedEntry
+	for rows.Next() {
+		var entry entities.MentionFeedEntry
+		if err := rows.Scan(&entry.MessageID, &entry.ChatID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		feed = append(feed, entry)
+	}
+
+	return feed, rows.Err()
+}
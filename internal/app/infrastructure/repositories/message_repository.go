@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -22,7 +23,7 @@ func NewMessageRepository(pool *pgxpool.Pool) *MessageRepository {
 	}
 }
 
-func (mr *MessageRepository) Create(ctx context.Context, msg *entities.Message) error {
+func (mr *MessageRepository) Create(ctx context.Context, tx pgx.Tx, msg *entities.Message) error {
 	columns := []string{"id", "user_id", "chat_tag", "content", "created_at"}
 	values := []any{msg.ID, msg.UserID, msg.ChatTag, msg.Content, msg.CreatedAt}
 
@@ -40,46 +41,129 @@ func (mr *MessageRepository) Create(ctx context.Context, msg *entities.Message)
 		return err
 	}
 
-	_, err = mr.pool.Exec(ctx, sql, args...)
+	_, err = tx.Exec(ctx, sql, args...)
 	return err
 }
 
 func (mr *MessageRepository) ReadByID(ctx context.Context, id uuid.UUID) (*entities.Message, error) {
-	sql, args, err := mr.builder.Select("id", "reply_to", "user_id", "chat_tag", "content", "created_at").
-		From("messages").Where(sq.Eq{"id": id}).ToSql()
+	sql, args, err := mr.selectMessages().Where(sq.Eq{"id": id}).ToSql()
 
 	if err != nil {
 		return nil, err
 	}
 
-	var msg entities.Message
-	err = mr.pool.QueryRow(ctx, sql, args...).Scan(&msg.ID, &msg.UserID, &msg.ChatTag, &msg.Content, &msg.CreatedAt)
+	return mr.scanMessage(mr.pool.QueryRow(ctx, sql, args...))
+}
+
+func (mr *MessageRepository) GetLastByChatTag(ctx context.Context, chatTag string) (*entities.Message, error) {
+	sql, args, err := mr.selectMessages().
+		Where(sq.Eq{"chat_tag": chatTag, "deleted_at": nil}).
+		OrderBy("created_at DESC").
+		Limit(1).
+		ToSql()
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	return &msg, nil
+	return mr.scanMessage(mr.pool.QueryRow(ctx, sql, args...))
 }
 
-func (mr *MessageRepository) GetLastByChatTag(ctx context.Context, chatTag string) (*entities.Message, error) {
-	sql, args, err := mr.builder.
-		Select("id", "reply_to", "user_id", "chat_tag", "content", "created_at").
+func (mr *MessageRepository) ReadByIDs(ctx context.Context, ids []uuid.UUID) ([]entities.Message, error) {
+	sql, args, err := mr.selectMessages().Where(sq.Eq{"id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	return mr.queryMessages(ctx, sql, args)
+}
+
+// ReadReplies returns direct replies to the message, oldest first. Deleted
+// replies are kept as tombstones so the thread keeps its shape.
+func (mr *MessageRepository) ReadReplies(ctx context.Context, parentID uuid.UUID,
+	limit, offset uint64) ([]entities.Message, error) {
+	sql, args, err := mr.selectMessages().
+		Where(sq.Eq{"reply_to": parentID}).
+		OrderBy("created_at", "id").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mr.queryMessages(ctx, sql, args)
+}
+
+// CountReplies returns the number of live direct replies to each of the
+// messages. Messages without replies are absent from the result.
+func (mr *MessageRepository) CountReplies(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]int, error) {
+	sql, args, err := mr.builder.Select("reply_to", "count(*)").
 		From("messages").
-		Where(sq.Eq{"chat_tag": chatTag}).
-		OrderBy("created_at DESC").
-		Limit(1).
+		Where(sq.Eq{"reply_to": ids, "deleted_at": nil}).
+		GroupBy("reply_to").
 		ToSql()
 
 	if err != nil {
 		return nil, err
 	}
 
-	var msg entities.Message
-	err = mr.pool.QueryRow(ctx, sql, args...).Scan(&msg.ID, &msg.UserID, &msg.ChatTag, &msg.Content, &msg.CreatedAt)
+	rows, err := mr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]int, len(ids))
+	for rows.Next() {
+		var (
+			id    uuid.UUID
+			count int
+		)
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func (mr *MessageRepository) queryMessages(ctx context.Context, sql string, args []any) ([]entities.Message, error) {
+	rows, err := mr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []entities.Message
+	for rows.Next() {
+		msg, err := mr.scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, *msg)
+	}
+
+	return msgs, rows.Err()
+}
+
+// selectMessages starts a query for rows that scanMessage can read.
+func (mr *MessageRepository) selectMessages() sq.SelectBuilder {
+	return mr.builder.Select("id", "reply_to", "user_id", "chat_tag", "content", "created_at", "edited_at", "deleted_at").
+		From("messages")
+}
+
+// scanMessage reads a row selected by selectMessages. A missing row yields a
+// nil message.
+func (mr *MessageRepository) scanMessage(row pgx.Row) (*entities.Message, error) {
+	var (
+		msg     entities.Message
+		replyTo *uuid.UUID
+	)
+	err := row.Scan(&msg.ID, &replyTo, &msg.UserID, &msg.ChatTag, &msg.Content, &msg.CreatedAt,
+		&msg.EditedAt, &msg.DeletedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -87,15 +171,18 @@ func (mr *MessageRepository) GetLastByChatTag(ctx context.Context, chatTag strin
 		return nil, err
 	}
 
+	if replyTo != nil {
+		msg.ReplyToID = *replyTo
+	}
+
 	return &msg, nil
 }
 
-func (mr *MessageRepository) Update(ctx context.Context, msg *entities.Message) error {
+// Update writes the message's content and edit marker.
+func (mr *MessageRepository) Update(ctx context.Context, tx pgx.Tx, msg *entities.Message) error {
 	sql, args, err := mr.builder.Update("messages").
-		Set("user_id", msg.UserID).
-		Set("chat_tag", msg.ChatTag).
 		Set("content", msg.Content).
-		Set("created_at", msg.CreatedAt).
+		Set("edited_at", msg.EditedAt).
 		Where(sq.Eq{"id": msg.ID}).
 		ToSql()
 
@@ -103,17 +190,79 @@ func (mr *MessageRepository) Update(ctx context.Context, msg *entities.Message)
 		return err
 	}
 
-	_, err = mr.pool.Exec(ctx, sql, args...)
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+// Delete turns the message into a tombstone: its content, edit history,
+// reactions and pins are dropped, the row itself stays for replies to point
+// at.
+func (mr *MessageRepository) Delete(ctx context.Context, tx pgx.Tx, id uuid.UUID, deletedAt time.Time) error {
+	for _, table := range []string{"message_revisions", "message_reactions", "pinned_messages"} {
+		sql, args, err := mr.builder.Delete(table).Where(sq.Eq{"message_id": id}).ToSql()
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return err
+		}
+	}
+
+	sql, args, err := mr.builder.Update("messages").
+		Set("content", "").
+		Set("deleted_at", deletedAt).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
 	return err
 }
 
-func (mr *MessageRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	sql, args, err := mr.builder.Delete("messages").Where(sq.Eq{"id": id}).ToSql()
+func (mr *MessageRepository) CreateRevision(ctx context.Context, tx pgx.Tx, rev entities.MessageRevision) error {
+	sql, args, err := mr.builder.Insert("message_revisions").
+		Columns("id", "message_id", "content", "edited_at").
+		Values(rev.ID, rev.MessageID, rev.Content, rev.EditedAt).
+		ToSql()
 
 	if err != nil {
 		return err
 	}
 
-	_, err = mr.pool.Exec(ctx, sql, args...)
+	_, err = tx.Exec(ctx, sql, args...)
 	return err
 }
+
+// ReadRevisions returns the message's earlier contents, oldest first.
+func (mr *MessageRepository) ReadRevisions(ctx context.Context, messageID uuid.UUID) ([]entities.MessageRevision, error) {
+	sql, args, err := mr.builder.Select("id", "message_id", "content", "edited_at").
+		From("message_revisions").
+		Where(sq.Eq{"message_id": messageID}).
+		OrderBy("edited_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := mr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []entities.MessageRevision
+	for rows.Next() {
+		var rev entities.MessageRevision
+		if err := rows.Scan(&rev.ID, &rev.MessageID, &rev.Content, &rev.EditedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
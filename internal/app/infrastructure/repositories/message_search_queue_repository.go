@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+// MessageSearchQueueRepository keeps the messages whose search index entry
+// is out of date. A message is queued at most once; queuing it again only
+// moves its queued_at.
+type MessageSearchQueueRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewMessageSearchQueueRepository(pool *pgxpool.Pool) *MessageSearchQueueRepository {
+	return &MessageSearchQueueRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (mqr *MessageSearchQueueRepository) Enqueue(ctx context.Context, tx pgx.Tx, messageID uuid.UUID,
+	queuedAt time.Time) error {
+	sql, args, err := mqr.builder.Insert("message_search_queue").
+		Columns("message_id", "queued_at").
+		Values(messageID, queuedAt).
+		Suffix("ON CONFLICT (message_id) DO UPDATE SET queued_at = EXCLUDED.queued_at, locked_until = NULL").
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+// EnqueueChangedSince queues every message created, edited or deleted since
+// the given time and returns how many were queued.
+func (mqr *MessageSearchQueueRepository) EnqueueChangedSince(ctx context.Context, since time.Time) (int64, error) {
+	changed := mqr.builder.Select("id").
+		Column(sq.Expr("?::timestamptz", time.Now())).
+		From("messages").
+		Where(sq.Or{sq.GtOrEq{"created_at": since}, sq.GtOrEq{"edited_at": since}, sq.GtOrEq{"deleted_at": since}})
+
+	sql, args, err := mqr.builder.Insert("message_search_queue").
+		Columns("message_id", "queued_at").
+		Select(changed).
+		Suffix("ON CONFLICT (message_id) DO UPDATE SET queued_at = EXCLUDED.queued_at, locked_until = NULL").
+		ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := mqr.pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Claim leases up to limit queued messages, oldest first. Entries whose
+// lease ran out are claimed again.
+func (mqr *MessageSearchQueueRepository) Claim(ctx context.Context, limit uint64,
+	lease time.Duration) ([]entities.SearchQueueEntry, error) {
+	now := time.Now()
+
+	queued := sq.Select("message_id").
+		From("message_search_queue").
+		Where(sq.Or{sq.Eq{"locked_until": nil}, sq.Lt{"locked_until": now}}).
+		OrderBy("queued_at").
+		Limit(limit)
+
+	sql, args, err := mqr.builder.Update("message_search_queue").
+		Set("locked_until", now.Add(lease)).
+		Where(claimable("message_id", queued)).
+		Suffix("RETURNING message_id, queued_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := mqr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []entities.SearchQueueEntry
+	for rows.Next() {
+		var entry entities.SearchQueueEntry
+		if err := rows.Scan(&entry.MessageID, &entry.QueuedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Remove drops processed entries. An entry queued again since it was
+// claimed is kept, so the newer change gets indexed as well.
+func (mqr *MessageSearchQueueRepository) Remove(ctx context.Context, entries []entities.SearchQueueEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	processed := make(sq.Or, 0, len(entries))
+	for _, entry := range entries {
+		processed = append(processed, sq.Eq{"message_id": entry.MessageID, "queued_at": entry.QueuedAt})
+	}
+
+	sql, args, err := mqr.builder.Delete("message_search_queue").Where(processed).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = mqr.pool.Exec(ctx, sql, args...)
+	return err
+}
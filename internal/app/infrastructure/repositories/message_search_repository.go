@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+// headlineOptions makes ts_headline return up to two short fragments with the
+// matched words wrapped in the entities' highlight markers.
+const headlineOptions = "StartSel=" + entities.SearchHighlightStart +
+	", StopSel=" + entities.SearchHighlightStop +
+	", MaxWords=20, MinWords=5, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// MessageSearchRepository runs full-text search over messages.search_vector
+// and reads the messages other search indexes are built from.
+type MessageSearchRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewMessageSearchRepository(pool *pgxpool.Pool) *MessageSearchRepository {
+	return &MessageSearchRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// Search returns live messages matching the query in chats the viewer
+// participates in, best matches first. The text is read with websearch
+// syntax: quoted phrases, OR and a leading minus are understood.
+func (msr *MessageSearchRepository) Search(ctx context.Context,
+	query entities.MessageSearchQuery) ([]entities.MessageSearchHit, error) {
+	builder := msr.builder.Select("m.id", "c.id", "ts_rank_cd(m.search_vector, q) AS rank").
+		Column("ts_headline('simple', m.content, q, ?)", headlineOptions).
+		From("messages m").
+		Join("chats c ON c.tag = m.chat_tag").
+		Join("chat_participants cp ON cp.chat_id = c.id AND cp.user_id = ?", query.ViewerID).
+		JoinClause("CROSS JOIN websearch_to_tsquery('simple', ?) q", query.Text).
+		Where("m.search_vector @@ q").
+		Where(sq.Eq{"m.deleted_at": nil})
+
+	if query.ChatID != nil {
+		builder = builder.Where(sq.Eq{"c.id": *query.ChatID})
+	}
+	if query.AuthorID != nil {
+		builder = builder.Where(sq.Eq{"m.user_id": *query.AuthorID})
+	}
+	if query.From != nil {
+		builder = builder.Where(sq.GtOrEq{"m.created_at": *query.From})
+	}
+	if query.To != nil {
+		builder = builder.Where(sq.Lt{"m.created_at": *query.To})
+	}
+
+	sql, args, err := builder.
+		OrderBy("rank DESC", "m.created_at DESC", "m.id").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := msr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []entities.MessageSearchHit
+	for rows.Next() {
+		var hit entities.MessageSearchHit
+		if err := rows.Scan(&hit.MessageID, &hit.ChatID, &hit.Rank, &hit.Snippet); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, rows.Err()
+}
+
+// Index does nothing: search_vector is a generated column, so Postgres keeps
+// itself up to date.
+func (msr *MessageSearchRepository) Index(_ context.Context, _ []entities.MessageDocument) error {
+	return nil
+}
+
+// Delete does nothing; deleted messages are filtered out by Search.
+func (msr *MessageSearchRepository) Delete(_ context.Context, _ []uuid.UUID) error {
+	return nil
+}
+
+// CreateVersion does nothing; there is no separate index to rebuild.
+func (msr *MessageSearchRepository) CreateVersion(_ context.Context) (string, error) {
+	return "", nil
+}
+
+// IndexVersion does nothing, like Index.
+func (msr *MessageSearchRepository) IndexVersion(_ context.Context, _ string, _ []entities.MessageDocument) error {
+	return nil
+}
+
+// ActivateVersion does nothing; there is no separate index to rebuild.
+func (msr *MessageSearchRepository) ActivateVersion(_ context.Context, _ string) error {
+	return nil
+}
+
+// ReadDocuments returns the live messages among ids as search documents.
+// Deleted and unknown messages are absent from the result.
+func (msr *MessageSearchRepository) ReadDocuments(ctx context.Context,
+	ids []uuid.UUID) ([]entities.MessageDocument, error) {
+	sql, args, err := msr.selectDocuments().
+		Where(sq.Eq{"m.id": ids}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return msr.queryDocuments(ctx, sql, args)
+}
+
+// ReadDocumentsAfter pages through all live messages in ID order, starting
+// after the given ID. uuid.Nil starts from the beginning.
+func (msr *MessageSearchRepository) ReadDocumentsAfter(ctx context.Context, after uuid.UUID,
+	limit uint64) ([]entities.MessageDocument, error) {
+	sql, args, err := msr.selectDocuments().
+		Where(sq.Gt{"m.id": after}).
+		OrderBy("m.id").
+		Limit(limit).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return msr.queryDocuments(ctx, sql, args)
+}
+
+func (msr *MessageSearchRepository) selectDocuments() sq.SelectBuilder {
+	return msr.builder.Select("m.id", "c.id", "m.user_id", "m.content", "m.created_at").
+		From("messages m").
+		Join("chats c ON c.tag = m.chat_tag").
+		Where(sq.Eq{"m.deleted_at": nil})
+}
+
+func (msr *MessageSearchRepository) queryDocuments(ctx context.Context, sql string,
+	args []any) ([]entities.MessageDocument, error) {
+	rows, err := msr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []entities.MessageDocument
+	for rows.Next() {
+		var doc entities.MessageDocument
+		if err := rows.Scan(&doc.ID, &doc.ChatID, &doc.UserID, &doc.Content, &doc.CreatedAt); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type PinnedMessageRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewPinnedMessageRepository(pool *pgxpool.Pool) *PinnedMessageRepository {
+	return &PinnedMessageRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// CountForUpdate locks the chat row, so that concurrent pins of one chat are
+// counted one after another, and returns the number of its pinned messages.
+func (pmr *PinnedMessageRepository) CountForUpdate(ctx context.Context, tx pgx.Tx, chatID uuid.UUID) (int, error) {
+	sql, args, err := pmr.builder.Select("1").From("chats").Where(sq.Eq{"id": chatID}).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return 0, err
+	}
+
+	sql, args, err = pmr.builder.Select("count(*)").From("pinned_messages").Where(sq.Eq{"chat_id": chatID}).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = tx.QueryRow(ctx, sql, args...).Scan(&count)
+
+	return count, err
+}
+
+func (pmr *PinnedMessageRepository) Exists(ctx context.Context, tx pgx.Tx, chatID, messageID uuid.UUID) (bool, error) {
+	sql, args, err := pmr.builder.Select("1").
+		Prefix("SELECT EXISTS (").
+		From("pinned_messages").
+		Where(sq.Eq{"chat_id": chatID, "message_id": messageID}).
+		Suffix(")").
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, sql, args...).Scan(&exists)
+
+	return exists, err
+}
+
+func (pmr *PinnedMessageRepository) Create(ctx context.Context, tx pgx.Tx, pin entities.PinnedMessage) error {
+	sql, args, err := pmr.builder.Insert("pinned_messages").
+		Columns("chat_id", "message_id", "pinned_by", "pinned_at").
+		Values(pin.ChatID, pin.Message.ID, pin.PinnedBy, pin.PinnedAt).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+func (pmr *PinnedMessageRepository) Delete(ctx context.Context, chatID, messageID uuid.UUID) error {
+	sql, args, err := pmr.builder.Delete("pinned_messages").
+		Where(sq.Eq{"chat_id": chatID, "message_id": messageID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = pmr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// ReadByChatID returns the chat's pinned messages in the order they were
+// pinned.
+func (pmr *PinnedMessageRepository) ReadByChatID(ctx context.Context, chatID uuid.UUID) ([]entities.PinnedMessage, error) {
+	sql, args, err := pmr.builder.Select(
+		"p.chat_id", "p.pinned_by", "p.pinned_at",
+		"m.id", "m.reply_to", "m.user_id", "m.chat_tag", "m.content", "m.created_at", "m.edited_at",
+	).
+		From("pinned_messages p").
+		Join("messages m ON m.id = p.message_id").
+		Where(sq.Eq{"p.chat_id": chatID}).
+		OrderBy("p.pinned_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pmr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pins []entities.PinnedMessage
+	for rows.Next() {
+		var (
+			pin     entities.PinnedMessage
+			replyTo *uuid.UUID
+		)
+		err := rows.Scan(&pin.ChatID, &pin.PinnedBy, &pin.PinnedAt,
+			&pin.Message.ID, &replyTo, &pin.Message.UserID, &pin.Message.ChatTag, &pin.Message.Content,
+			&pin.Message.CreatedAt, &pin.Message.EditedAt)
+		if err != nil {
+			return nil, err
+		}
+		if replyTo != nil {
+			pin.Message.ReplyToID = *replyTo
+		}
+		pins = append(pins, pin)
+	}
+
+	return pins, rows.Err()
+}
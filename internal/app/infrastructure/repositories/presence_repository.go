@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type PresenceRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewPresenceRepository(pool *pgxpool.Pool) *PresenceRepository {
+	return &PresenceRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (pr *PresenceRepository) UpdateLastSeen(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	sql, args, err := pr.builder.Update("user_accounts").
+		Set("last_seen_at", at).
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = pr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (pr *PresenceRepository) UpdateLastSeenVisibility(ctx context.Context, userID uuid.UUID,
+	visibility entities.LastSeenVisibility) error {
+	sql, args, err := pr.builder.Update("user_accounts").
+		Set("last_seen_visibility", visibility).
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = pr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// ReadLastSeen returns last-seen records of the given users. Unknown IDs are
+// skipped.
+func (pr *PresenceRepository) ReadLastSeen(ctx context.Context, userIDs []uuid.UUID) ([]entities.LastSeen, error) {
+	sql, args, err := pr.builder.Select("id", "last_seen_at", "last_seen_visibility").
+		From("user_accounts").
+		Where(sq.Eq{"id": userIDs}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []entities.LastSeen
+	for rows.Next() {
+		var lastSeen entities.LastSeen
+		if err := rows.Scan(&lastSeen.UserID, &lastSeen.At, &lastSeen.Visibility); err != nil {
+			return nil, err
+		}
+		result = append(result, lastSeen)
+	}
+
+	return result, rows.Err()
+}
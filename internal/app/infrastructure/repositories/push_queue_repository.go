@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+// PushQueueRepository keeps new messages whose push notifications haven't
+// been sent yet.
+type PushQueueRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewPushQueueRepository(pool *pgxpool.Pool) *PushQueueRepository {
+	return &PushQueueRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (pqr *PushQueueRepository) Enqueue(ctx context.Context, tx pgx.Tx, messageID uuid.UUID, queuedAt time.Time) error {
+	sql, args, err := pqr.builder.Insert("push_queue").
+		Columns("message_id", "queued_at", "next_attempt_at").
+		Values(messageID, queuedAt, queuedAt).
+		Suffix("ON CONFLICT (message_id) DO NOTHING").
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+// Claim leases up to limit queued messages that are due, longest waiting
+// first. Messages whose lease ran out are claimed again.
+func (pqr *PushQueueRepository) Claim(ctx context.Context, limit uint64,
+	lease time.Duration) ([]entities.PushQueueItem, error) {
+	now := time.Now()
+
+	queued := sq.Select("message_id").
+		From("push_queue").
+		Where(sq.LtOrEq{"next_attempt_at": now}).
+		Where(sq.Or{sq.Eq{"locked_until": nil}, sq.Lt{"locked_until": now}}).
+		OrderBy("next_attempt_at").
+		Limit(limit)
+
+	sql, args, err := pqr.builder.Update("push_queue").
+		Set("locked_until", now.Add(lease)).
+		Where(claimable("message_id", queued)).
+		Suffix("RETURNING message_id, attempts, tokens").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pqr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []entities.PushQueueItem
+	for rows.Next() {
+		var item entities.PushQueueItem
+		if err := rows.Scan(&item.MessageID, &item.Attempts, &item.Tokens); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Retry puts the message back in the queue until nextAttemptAt, recording
+// the attempts made so far and the devices still to be notified.
+func (pqr *PushQueueRepository) Retry(ctx context.Context, item entities.PushQueueItem, nextAttemptAt time.Time) error {
+	sql, args, err := pqr.builder.Update("push_queue").
+		Set("attempts", item.Attempts).
+		Set("tokens", item.Tokens).
+		Set("next_attempt_at", nextAttemptAt).
+		Set("locked_until", nil).
+		Where(sq.Eq{"message_id": item.MessageID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = pqr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (pqr *PushQueueRepository) Remove(ctx context.Context, messageIDs []uuid.UUID) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	sql, args, err := pqr.builder.Delete("push_queue").Where(sq.Eq{"message_id": messageIDs}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = pqr.pool.Exec(ctx, sql, args...)
+	return err
+}
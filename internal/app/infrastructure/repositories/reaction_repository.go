@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type ReactionRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewReactionRepository(pool *pgxpool.Pool) *ReactionRepository {
+	return &ReactionRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// Create adds the reaction. Adding the same reaction twice is a no-op.
+func (rr *ReactionRepository) Create(ctx context.Context, reaction entities.MessageReaction) error {
+	sql, args, err := rr.builder.Insert("message_reactions").
+		Columns("message_id", "user_id", "emoji", "created_at").
+		Values(reaction.MessageID, reaction.UserID, reaction.Emoji, reaction.CreatedAt).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = rr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (rr *ReactionRepository) Delete(ctx context.Context, messageID, userID uuid.UUID, emoji string) error {
+	sql, args, err := rr.builder.Delete("message_reactions").
+		Where(sq.Eq{"message_id": messageID, "user_id": userID, "emoji": emoji}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = rr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// CountByMessages aggregates reactions on the messages per emoji, in the
+// order each emoji first appeared on a message.
+func (rr *ReactionRepository) CountByMessages(ctx context.Context, messageIDs []uuid.UUID,
+	viewerID uuid.UUID) ([]entities.ReactionCount, error) {
+	sql, args, err := rr.builder.Select("message_id", "emoji", "count(*)").
+		Column(sq.Expr("bool_or(user_id = ?)", viewerID)).
+		From("message_reactions").
+		Where(sq.Eq{"message_id": messageIDs}).
+		GroupBy("message_id", "emoji").
+		OrderBy("message_id", "min(created_at)").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := rr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []entities.ReactionCount
+	for rows.Next() {
+		var count entities.ReactionCount
+		if err := rows.Scan(&count.MessageID, &count.Emoji, &count.Count, &count.ReactedByMe); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type ReadReceiptRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewReadReceiptRepository(pool *pgxpool.Pool) *ReadReceiptRepository {
+	return &ReadReceiptRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// MarkRead moves the user's read marker in the message's chat to the message.
+// The marker only moves forward, so marking an older message is a no-op.
+func (rrr *ReadReceiptRepository) MarkRead(ctx context.Context, userID uuid.UUID, msg *entities.Message) error {
+	sql, args, err := rrr.builder.Update("chat_participants cp").
+		Set("last_read_message_id", msg.ID).
+		Set("last_read_at", msg.CreatedAt).
+		From("chats c").
+		Where("c.id = cp.chat_id").
+		Where(sq.Eq{"c.tag": msg.ChatTag, "cp.user_id": userID}).
+		Where(sq.Or{sq.Eq{"cp.last_read_at": nil}, sq.Lt{"cp.last_read_at": msg.CreatedAt}}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = rrr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (rrr *ReadReceiptRepository) ReadMessageReceipts(ctx context.Context, msg *entities.Message) (entities.MessageReceipts, error) {
+	sql, args, err := rrr.builder.Select("c.kind", "cp.user_id").
+		Column(sq.Expr("cp.last_read_at IS NOT NULL AND cp.last_read_at >= ?", msg.CreatedAt)).
+		From("chat_participants cp").
+		Join("chats c ON c.id = cp.chat_id").
+		Where(sq.Eq{"c.tag": msg.ChatTag}).
+		Where(sq.NotEq{"cp.user_id": msg.UserID}).
+		OrderBy("cp.last_read_at").
+		ToSql()
+
+	if err != nil {
+		return entities.MessageReceipts{}, err
+	}
+
+	rows, err := rrr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return entities.MessageReceipts{}, err
+	}
+	defer rows.Close()
+
+	var receipts entities.MessageReceipts
+	for rows.Next() {
+		var (
+			userID uuid.UUID
+			read   bool
+		)
+		if err := rows.Scan(&receipts.ChatKind, &userID, &read); err != nil {
+			return entities.MessageReceipts{}, err
+		}
+
+		receipts.RecipientCount++
+		if read {
+			receipts.Readers = append(receipts.Readers, userID)
+		}
+	}
+
+	return receipts, rows.Err()
+}
@@ -2,24 +2,27 @@ package repositories
 
 import (
 	"context"
-	"log"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 	"github.com/renderview-inc/backend/internal/app/domain/entities"
 )
 
 type UserAccountRepository struct {
 	pool    *pgxpool.Pool
 	builder sq.StatementBuilderType
+	logger  logger.Logger
 }
 
-func NewUserAccountRepository(pool *pgxpool.Pool) *UserAccountRepository {
+func NewUserAccountRepository(pool *pgxpool.Pool, logger logger.Logger) *UserAccountRepository {
 	return &UserAccountRepository{
 		pool:    pool,
 		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		logger:  logger,
 	}
 }
 
@@ -34,12 +37,18 @@ func (uar *UserAccountRepository) Create(ctx context.Context, uacc *entities.Use
 	}
 
 	_, err = uar.pool.Exec(ctx, sql, args...)
-	log.Printf("UserAccount created: %v", uacc.Id)
-	return err
+	if err != nil {
+		return err
+	}
+
+	uar.logger.Info(ctx, "user account created", option.Any("user_id", uacc.Id.String()))
+
+	return nil
 }
 
 func (uar *UserAccountRepository) ReadById(ctx context.Context, accID uuid.UUID) (*entities.UserAccount, error) {
-	sql, args, err := uar.builder.Select("id", "tag", "name", "\"desc\"", "password_hash", "email", "phone").
+	sql, args, err := uar.builder.Select("id", "tag", "name", "\"desc\"", "password_hash",
+		"COALESCE(email, '')", "COALESCE(phone, '')").
 		From("user_accounts").Where(sq.Eq{"id": accID}).ToSql()
 
 	if err != nil {
@@ -60,7 +69,8 @@ func (uar *UserAccountRepository) ReadById(ctx context.Context, accID uuid.UUID)
 }
 
 func (uar *UserAccountRepository) ReadByTag(ctx context.Context, accTag string) (*entities.UserAccount, error) {
-	sql, args, err := uar.builder.Select("id", "tag", "name", "\"desc\"", "password_hash", "email", "phone").
+	sql, args, err := uar.builder.Select("id", "tag", "name", "\"desc\"", "password_hash",
+		"COALESCE(email, '')", "COALESCE(phone, '')").
 		From("user_accounts").Where(sq.Eq{"tag": accTag}).ToSql()
 
 	if err != nil {
@@ -81,7 +91,8 @@ func (uar *UserAccountRepository) ReadByTag(ctx context.Context, accTag string)
 }
 
 func (uar *UserAccountRepository) ReadByEmail(ctx context.Context, email string) (*entities.UserAccount, error) {
-	sql, args, err := uar.builder.Select("id", "tag", "name", "\"desc\"", "password_hash", "email", "phone").
+	sql, args, err := uar.builder.Select("id", "tag", "name", "\"desc\"", "password_hash",
+		"COALESCE(email, '')", "COALESCE(phone, '')").
 		From("user_accounts").Where(sq.Eq{"email": email}).ToSql()
 
 	if err != nil {
@@ -102,7 +113,8 @@ func (uar *UserAccountRepository) ReadByEmail(ctx context.Context, email string)
 }
 
 func (uar *UserAccountRepository) ReadByPhone(ctx context.Context, phone string) (*entities.UserAccount, error) {
-	sql, args, err := uar.builder.Select("id", "tag", "name", "\"desc\"", "password_hash", "email", "phone").
+	sql, args, err := uar.builder.Select("id", "tag", "name", "\"desc\"", "password_hash",
+		"COALESCE(email, '')", "COALESCE(phone, '')").
 		From("user_accounts").Where(sq.Eq{"phone": phone}).ToSql()
 
 	if err != nil {
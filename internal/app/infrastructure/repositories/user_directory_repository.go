@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type UserDirectoryRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewUserDirectoryRepository(pool *pgxpool.Pool) *UserDirectoryRepository {
+	return &UserDirectoryRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// Search finds discoverable users other than the viewer whose tag or name
+// starts with the text or is similar to it by trigrams. An exact tag comes
+// first, then prefix matches, then the most similar.
+func (udr *UserDirectoryRepository) Search(ctx context.Context, viewerID uuid.UUID, text string,
+	limit, offset uint64) ([]entities.UserProfile, error) {
+	text = strings.ToLower(text)
+	prefix := likeEscaper().Replace(text) + "%"
+
+	sql, args, err := udr.builder.Select("id", "tag", "name", "\"desc\"").
+		From("user_accounts").
+		Where(sq.Eq{"discoverable": true}).
+		Where(sq.NotEq{"id": viewerID}).
+		Where("(lower(tag) LIKE ? OR lower(name) LIKE ? OR lower(tag) % ? OR lower(name) % ?)",
+			prefix, prefix, text, text).
+		OrderByClause("lower(tag) = ? DESC", text).
+		OrderByClause("(lower(tag) LIKE ? OR lower(name) LIKE ?) DESC", prefix, prefix).
+		OrderByClause("greatest(similarity(lower(tag), ?), similarity(lower(name), ?)) DESC", text, text).
+		OrderBy("tag").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := udr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []entities.UserProfile
+	for rows.Next() {
+		var profile entities.UserProfile
+		if err := rows.Scan(&profile.ID, &profile.Tag, &profile.Name, &profile.Desc); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+func (udr *UserDirectoryRepository) UpdateDiscoverable(ctx context.Context, userID uuid.UUID,
+	discoverable bool) error {
+	sql, args, err := udr.builder.Update("user_accounts").
+		Set("discoverable", discoverable).
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = udr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// likeEscaper escapes the LIKE wildcards and the default escape character.
+func likeEscaper() *strings.Replacer {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+}
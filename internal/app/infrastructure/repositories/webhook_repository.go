@@ -0,0 +1,360 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+// webhookColumns and deliveryColumns are listed in the order scanWebhook and
+// scanDelivery read them.
+const (
+	webhookColumns = "id, chat_id, url, secret, events, created_by, created_at, enabled, consecutive_failures, " +
+		"disabled_at"
+	deliveryColumns = "id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, " +
+		"last_error, created_at, delivered_at"
+	claimedDeliveryColumns = "webhook_deliveries.id, webhook_id, event, payload, status, attempts, " +
+		"next_attempt_at, response_status, last_error, webhook_deliveries.created_at, delivered_at, url, secret"
+)
+
+type WebhookRepository struct {
+	pool    *pgxpool.Pool
+	builder sq.StatementBuilderType
+}
+
+func NewWebhookRepository(pool *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{
+		pool:    pool,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (wr *WebhookRepository) Create(ctx context.Context, webhook entities.Webhook) error {
+	sql, args, err := wr.builder.Insert("webhooks").
+		Columns(webhookColumns).
+		Values(webhook.ID, webhook.ChatID, webhook.URL, webhook.Secret, eventNames(webhook.Events),
+			webhook.CreatedBy, webhook.CreatedAt, webhook.Enabled, webhook.ConsecutiveFailures, webhook.DisabledAt).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = wr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (wr *WebhookRepository) ReadByID(ctx context.Context, id uuid.UUID) (*entities.Webhook, error) {
+	sql, args, err := wr.builder.Select(webhookColumns).
+		From("webhooks").
+		Where(sq.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := scanWebhook(wr.pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (wr *WebhookRepository) ReadByChat(ctx context.Context, chatID uuid.UUID) ([]entities.Webhook, error) {
+	sql, args, err := wr.builder.Select(webhookColumns).
+		From("webhooks").
+		Where(sq.Eq{"chat_id": chatID}).
+		OrderBy("created_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return wr.queryWebhooks(ctx, wr.pool, sql, args)
+}
+
+// ReadSubscribed returns the chat's enabled webhooks subscribed to the event.
+func (wr *WebhookRepository) ReadSubscribed(ctx context.Context, tx pgx.Tx, chatID uuid.UUID,
+	event entities.WebhookEvent) ([]entities.Webhook, error) {
+	sql, args, err := wr.builder.Select(webhookColumns).
+		From("webhooks").
+		Where(sq.Eq{"chat_id": chatID, "enabled": true}).
+		Where("? = ANY(events)", string(event)).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return wr.queryWebhooks(ctx, tx, sql, args)
+}
+
+func (wr *WebhookRepository) Update(ctx context.Context, webhook entities.Webhook) error {
+	sql, args, err := wr.builder.Update("webhooks").
+		Set("url", webhook.URL).
+		Set("events", eventNames(webhook.Events)).
+		Set("enabled", webhook.Enabled).
+		Set("consecutive_failures", webhook.ConsecutiveFailures).
+		Set("disabled_at", webhook.DisabledAt).
+		Where(sq.Eq{"id": webhook.ID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = wr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (wr *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	sql, args, err := wr.builder.Delete("webhooks").Where(sq.Eq{"id": id}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = wr.pool.Exec(ctx, sql, args...)
+	return err
+}
+
+// ResetFailures clears the failure count after a successful delivery.
+func (wr *WebhookRepository) ResetFailures(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	sql, args, err := wr.builder.Update("webhooks").
+		Set("consecutive_failures", 0).
+		Where(sq.Eq{"id": id}).
+		Where(sq.NotEq{"consecutive_failures": 0}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+// RecordFailure counts a failed attempt and disables the webhook once
+// threshold attempts in a row have failed. It reports whether this attempt
+// disabled it.
+func (wr *WebhookRepository) RecordFailure(ctx context.Context, tx pgx.Tx, id uuid.UUID, threshold int,
+	at time.Time) (bool, error) {
+	reached := sq.Expr("consecutive_failures + 1 >= ?", threshold)
+
+	sql, args, err := wr.builder.Update("webhooks").
+		Set("consecutive_failures", sq.Expr("consecutive_failures + 1")).
+		Set("disabled_at", sq.Case().When(sq.And{sq.Eq{"enabled": true}, reached}, sq.Expr("?", at)).
+			Else("disabled_at")).
+		Set("enabled", sq.Case().When(reached, "FALSE").Else("enabled")).
+		Where(sq.Eq{"id": id}).
+		Suffix("RETURNING enabled, COALESCE(disabled_at = ?, FALSE)", at).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var enabled, disabledNow bool
+	err = tx.QueryRow(ctx, sql, args...).Scan(&enabled, &disabledNow)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return !enabled && disabledNow, nil
+}
+
+func (wr *WebhookRepository) CreateDeliveries(ctx context.Context, tx pgx.Tx,
+	deliveries []entities.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	query := wr.builder.Insert("webhook_deliveries").Columns(deliveryColumns)
+	for _, d := range deliveries {
+		query = query.Values(d.ID, d.WebhookID, d.Event, d.Payload, d.Status, d.Attempts, d.NextAttemptAt,
+			d.ResponseStatus, d.LastError, d.CreatedAt, d.DeliveredAt)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+// ReadDeliveries returns the webhook's deliveries, newest first.
+func (wr *WebhookRepository) ReadDeliveries(ctx context.Context, webhookID uuid.UUID,
+	limit, offset uint64) ([]entities.WebhookDelivery, error) {
+	sql, args, err := wr.builder.Select(deliveryColumns).
+		From("webhook_deliveries").
+		Where(sq.Eq{"webhook_id": webhookID}).
+		OrderBy("created_at DESC", "id").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := wr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []entities.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// ClaimDeliveries leases up to limit pending deliveries that are due, oldest
+// first. Deliveries of disabled webhooks wait until they are enabled again.
+func (wr *WebhookRepository) ClaimDeliveries(ctx context.Context, limit uint64,
+	lease time.Duration) ([]entities.WebhookJob, error) {
+	now := time.Now()
+
+	due := sq.Select("d.id").
+		From("webhook_deliveries d").
+		Join("webhooks w ON w.id = d.webhook_id").
+		Where(sq.Eq{"d.status": entities.WebhookDeliveryPending, "w.enabled": true}).
+		Where(sq.LtOrEq{"d.next_attempt_at": now}).
+		Where(sq.Or{sq.Eq{"d.locked_until": nil}, sq.Lt{"d.locked_until": now}}).
+		OrderBy("d.next_attempt_at").
+		Limit(limit)
+
+	sql, args, err := wr.builder.Update("webhook_deliveries").
+		Set("locked_until", now.Add(lease)).
+		From("webhooks").
+		Where("webhooks.id = webhook_deliveries.webhook_id").
+		Where(claimable("webhook_deliveries.id", due, "d")).
+		Suffix("RETURNING " + claimedDeliveryColumns).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := wr.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []entities.WebhookJob
+	for rows.Next() {
+		var job entities.WebhookJob
+		d := &job.Delivery
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt, &job.URL, &job.Secret)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// UpdateDelivery saves the outcome of an attempt and releases the lease.
+func (wr *WebhookRepository) UpdateDelivery(ctx context.Context, tx pgx.Tx, d entities.WebhookDelivery) error {
+	sql, args, err := wr.builder.Update("webhook_deliveries").
+		Set("status", d.Status).
+		Set("attempts", d.Attempts).
+		Set("next_attempt_at", d.NextAttemptAt).
+		Set("locked_until", nil).
+		Set("response_status", d.ResponseStatus).
+		Set("last_error", d.LastError).
+		Set("delivered_at", d.DeliveredAt).
+		Where(sq.Eq{"id": d.ID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func (wr *WebhookRepository) queryWebhooks(ctx context.Context, q querier, sql string,
+	args []any) ([]entities.Webhook, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []entities.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func scanWebhook(row pgx.Row) (entities.Webhook, error) {
+	var (
+		w      entities.Webhook
+		events []string
+	)
+	err := row.Scan(&w.ID, &w.ChatID, &w.URL, &w.Secret, &events, &w.CreatedBy, &w.CreatedAt, &w.Enabled,
+		&w.ConsecutiveFailures, &w.DisabledAt)
+	if err != nil {
+		return entities.Webhook{}, err
+	}
+
+	w.Events = make([]entities.WebhookEvent, 0, len(events))
+	for _, e := range events {
+		w.Events = append(w.Events, entities.WebhookEvent(e))
+	}
+
+	return w, nil
+}
+
+func scanDelivery(row pgx.Row) (entities.WebhookDelivery, error) {
+	var d entities.WebhookDelivery
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+
+	return d, err
+}
+
+func eventNames(events []entities.WebhookEvent) []string {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, string(e))
+	}
+
+	return names
+}
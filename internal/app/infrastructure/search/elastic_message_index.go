@@ -0,0 +1,453 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	// documentTimeLayout stores message times in UTC, which the index's
+	// date_nanos fields parse by default.
+	documentTimeLayout = time.RFC3339Nano
+
+	highlightFragmentSize = 120
+	highlightFragments    = 2
+	fragmentDelimiter     = " … "
+	maxErrorBody          = 1024
+)
+
+// ChatMembership tells which chats a user can search in.
+type ChatMembership interface {
+	ReadChatIDsByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// ElasticMessageIndex keeps messages in an Elasticsearch index and searches
+// them there. It talks to the REST API directly. The index name is an alias
+// for the current version of the index, so a rebuilt version can replace it
+// in one step.
+type ElasticMessageIndex struct {
+	baseURL string
+	index   string
+	chats   ChatMembership
+	client  *http.Client
+}
+
+// NewElasticMessageIndex creates an index client for the cluster at baseURL,
+// e.g. http://elasticsearch:9200.
+func NewElasticMessageIndex(baseURL, index string, chats ChatMembership) *ElasticMessageIndex {
+	return &ElasticMessageIndex{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		index:   index,
+		chats:   chats,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type messageSource struct {
+	ChatID    string `json:"chat_id"`
+	UserID    string `json:"user_id"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+// EnsureIndex creates the first version of the index unless the index
+// already exists. An index created before versioning has the alias' name
+// and stores times in another format; it must be rebuilt with a reindex,
+// which replaces it.
+func (emi *ElasticMessageIndex) EnsureIndex(ctx context.Context) error {
+	resp, err := emi.do(ctx, http.MethodHead, "/"+emi.index, "", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("elasticsearch HEAD /%s: %s", emi.index, resp.Status)
+	}
+
+	return emi.createIndex(ctx, emi.index+"-v1", true)
+}
+
+// CreateVersion creates a new, empty version of the index and returns its
+// name. Searches keep using the current version until ActivateVersion.
+func (emi *ElasticMessageIndex) CreateVersion(ctx context.Context) (string, error) {
+	version := fmt.Sprintf("%s-v%d", emi.index, time.Now().UnixMilli())
+	if err := emi.createIndex(ctx, version, false); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
+
+// IndexVersion adds or replaces the documents in the given version.
+func (emi *ElasticMessageIndex) IndexVersion(ctx context.Context, version string,
+	docs []entities.MessageDocument) error {
+	return emi.indexInto(ctx, version, docs)
+}
+
+type aliasesResponse map[string]struct {
+	Aliases map[string]json.RawMessage `json:"aliases"`
+}
+
+// ActivateVersion points the alias at the given version and drops the
+// versions it pointed at before. The switch is atomic, so searches see
+// either the old version or the new one.
+func (emi *ElasticMessageIndex) ActivateVersion(ctx context.Context, version string) error {
+	resp, err := emi.do(ctx, http.MethodGet, "/"+emi.index+"/_alias", "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	current := aliasesResponse{}
+	if resp.StatusCode != http.StatusNotFound {
+		if resp.StatusCode != http.StatusOK {
+			return responseError(resp)
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&current); err != nil {
+			return fmt.Errorf("decode aliases: %w", err)
+		}
+	}
+
+	var (
+		actions []any
+		old     []string
+	)
+	for name := range current {
+		switch name {
+		case version:
+			// Already active, e.g. when a failed activation is retried.
+		case emi.index:
+			// An index from before versioning is in the alias' way.
+			actions = append(actions, map[string]any{"remove_index": map[string]string{"index": name}})
+		default:
+			actions = append(actions, map[string]any{"remove": map[string]string{"index": name, "alias": emi.index}})
+			old = append(old, name)
+		}
+	}
+	actions = append(actions, map[string]any{"add": map[string]string{"index": version, "alias": emi.index}})
+
+	body, err := json.Marshal(map[string]any{"actions": actions})
+	if err != nil {
+		return err
+	}
+
+	swapResp, err := emi.do(ctx, http.MethodPost, "/_aliases", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer swapResp.Body.Close()
+
+	if swapResp.StatusCode != http.StatusOK {
+		return responseError(swapResp)
+	}
+
+	for _, name := range old {
+		if err = emi.deleteIndex(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (emi *ElasticMessageIndex) deleteIndex(ctx context.Context, name string) error {
+	resp, err := emi.do(ctx, http.MethodDelete, "/"+name, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// createIndex creates the named index, which with alias set also becomes
+// the one the alias points at.
+func (emi *ElasticMessageIndex) createIndex(ctx context.Context, name string, alias bool) error {
+	settings := map[string]any{
+		"mappings": map[string]any{
+			"dynamic": "strict",
+			"properties": map[string]any{
+				"chat_id":    map[string]any{"type": "keyword"},
+				"user_id":    map[string]any{"type": "keyword"},
+				"content":    map[string]any{"type": "text"},
+				"created_at": map[string]any{"type": "date_nanos"},
+			},
+		},
+	}
+	if alias {
+		settings["aliases"] = map[string]any{emi.index: map[string]any{}}
+	}
+
+	body, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	resp, err := emi.do(ctx, http.MethodPut, "/"+name, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Another instance may have created it in the meantime.
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusBadRequest &&
+		strings.Contains(readError(resp), "resource_already_exists_exception") {
+		return nil
+	}
+
+	return fmt.Errorf("elasticsearch PUT /%s: %s", name, resp.Status)
+}
+
+// Index adds or replaces the documents.
+func (emi *ElasticMessageIndex) Index(ctx context.Context, docs []entities.MessageDocument) error {
+	return emi.indexInto(ctx, emi.index, docs)
+}
+
+func (emi *ElasticMessageIndex) indexInto(ctx context.Context, index string, docs []entities.MessageDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]any{"index": map[string]string{"_index": index, "_id": doc.ID.String()}}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+
+		source := messageSource{
+			ChatID:    doc.ChatID.String(),
+			UserID:    doc.UserID.String(),
+			Content:   doc.Content,
+			CreatedAt: doc.CreatedAt.UTC().Format(documentTimeLayout),
+		}
+		if err := enc.Encode(source); err != nil {
+			return err
+		}
+	}
+
+	return emi.bulk(ctx, &body)
+}
+
+// Delete removes the documents. Unknown IDs are ignored.
+func (emi *ElasticMessageIndex) Delete(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, id := range ids {
+		action := map[string]any{"delete": map[string]string{"_index": emi.index, "_id": id.String()}}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+	}
+
+	return emi.bulk(ctx, &body)
+}
+
+type bulkResponse struct {
+	Errors bool                                `json:"errors"`
+	Items  []map[string]bulkResponseItemResult `json:"items"`
+}
+
+type bulkResponseItemResult struct {
+	ID     string          `json:"_id"`
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error"`
+}
+
+func (emi *ElasticMessageIndex) bulk(ctx context.Context, body io.Reader) error {
+	resp, err := emi.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	var result bulkResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	for _, item := range result.Items {
+		for action, res := range item {
+			if res.Status >= http.StatusMultipleChoices && res.Status != http.StatusNotFound {
+				return fmt.Errorf("elasticsearch bulk %s %s: %d: %s", action, res.ID, res.Status, res.Error)
+			}
+		}
+	}
+
+	return nil
+}
+
+type searchResponse struct {
+	Hits struct {
+		Hits []struct {
+			ID        string              `json:"_id"`
+			Score     float64             `json:"_score"`
+			Source    messageSource       `json:"_source"`
+			Highlight map[string][]string `json:"highlight"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Search looks the text up with simple_query_string syntax, which like
+// Postgres' websearch syntax understands quoted phrases, | for OR and a
+// leading minus. Only chats the viewer participates in are searched.
+func (emi *ElasticMessageIndex) Search(ctx context.Context,
+	query entities.MessageSearchQuery) ([]entities.MessageSearchHit, error) {
+	chatIDs, err := emi.chats.ReadChatIDsByUser(ctx, query.ViewerID)
+	if err != nil {
+		return nil, fmt.Errorf("read viewer's chats: %w", err)
+	}
+
+	chats := make([]string, 0, len(chatIDs))
+	for _, id := range chatIDs {
+		if query.ChatID == nil || *query.ChatID == id {
+			chats = append(chats, id.String())
+		}
+	}
+	if len(chats) == 0 {
+		return nil, nil
+	}
+
+	filters := []any{map[string]any{"terms": map[string]any{"chat_id": chats}}}
+	if query.AuthorID != nil {
+		filters = append(filters, map[string]any{"term": map[string]any{"user_id": query.AuthorID.String()}})
+	}
+	if query.From != nil || query.To != nil {
+		bounds := map[string]string{}
+		if query.From != nil {
+			bounds["gte"] = query.From.UTC().Format(documentTimeLayout)
+		}
+		if query.To != nil {
+			bounds["lt"] = query.To.UTC().Format(documentTimeLayout)
+		}
+		filters = append(filters, map[string]any{"range": map[string]any{"created_at": bounds}})
+	}
+
+	request := map[string]any{
+		"from": query.Offset,
+		"size": query.Limit,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"must": map[string]any{
+					"simple_query_string": map[string]any{
+						"query":            query.Text,
+						"fields":           []string{"content"},
+						"default_operator": "and",
+					},
+				},
+				"filter": filters,
+			},
+		},
+		"sort":    []any{"_score", map[string]string{"created_at": "desc"}},
+		"_source": []string{"chat_id"},
+		"highlight": map[string]any{
+			"pre_tags":  []string{entities.SearchHighlightStart},
+			"post_tags": []string{entities.SearchHighlightStop},
+			"fields": map[string]any{
+				"content": map[string]any{
+					"fragment_size":       highlightFragmentSize,
+					"number_of_fragments": highlightFragments,
+					"no_match_size":       highlightFragmentSize,
+				},
+			},
+		},
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := emi.do(ctx, http.MethodPost, "/"+emi.index+"/_search", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var result searchResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode search response: %w", err)
+	}
+
+	hits := make([]entities.MessageSearchHit, 0, len(result.Hits.Hits))
+	for _, h := range result.Hits.Hits {
+		id, err := uuid.Parse(h.ID)
+		if err != nil {
+			return nil, fmt.Errorf("parse message ID %q: %w", h.ID, err)
+		}
+		chatID, err := uuid.Parse(h.Source.ChatID)
+		if err != nil {
+			return nil, fmt.Errorf("parse chat ID %q: %w", h.Source.ChatID, err)
+		}
+
+		hits = append(hits, entities.MessageSearchHit{
+			MessageID: id,
+			ChatID:    chatID,
+			Rank:      h.Score,
+			Snippet:   strings.Join(h.Highlight["content"], fragmentDelimiter),
+		})
+	}
+
+	return hits, nil
+}
+
+func (emi *ElasticMessageIndex) do(ctx context.Context, method, path, contentType string,
+	body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, emi.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := emi.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch %s %s: %w", method, path, err)
+	}
+
+	return resp, nil
+}
+
+func readError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return string(body)
+}
+
+func responseError(resp *http.Response) error {
+	return fmt.Errorf("elasticsearch %s %s: %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status,
+		readError(resp))
+}
@@ -0,0 +1,131 @@
+// Package webhook posts webhook deliveries to the URLs chats subscribed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+const (
+	userAgent = "renderview-webhooks/1"
+	// maxDrainedBody is how much of a response is read so the connection can
+	// be reused. The body itself is ignored.
+	maxDrainedBody = 4 << 10
+)
+
+var ErrForbiddenTarget = errors.New("webhook target address is not allowed")
+
+// HTTPSender posts a delivery's payload as JSON with these headers:
+//
+//	X-Webhook-Event:     the event, e.g. message.created
+//	X-Webhook-Delivery:  the delivery ID, the same across retries
+//	X-Webhook-Timestamp: Unix seconds when the attempt was made
+//	X-Webhook-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// The HMAC is keyed with the webhook's secret. Receivers should compare it
+// in constant time and reject old timestamps to stop replays. Redirects are
+// not followed, and anything but a 2xx response is a failure; only its
+// status is kept.
+//
+// Connections are only made to public addresses. The check runs on the
+// address actually dialed, so a name resolving to an internal address,
+// whether at creation time or later, is refused as well.
+type HTTPSender struct {
+	client *http.Client
+}
+
+func NewHTTPSender(timeout time.Duration) *HTTPSender {
+	return newHTTPSender(timeout, checkTarget)
+}
+
+func newHTTPSender(timeout time.Duration,
+	control func(network, address string, c syscall.RawConn) error) *HTTPSender {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+
+	return &HTTPSender{
+		client: &http.Client{
+			Timeout: timeout,
+			// No proxy: the target check has to see the receiver's address.
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// checkTarget is a net.Dialer Control function refusing connections to
+// addresses webhooks may not be delivered to.
+func checkTarget(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, address)
+	}
+	if !entities.WebhookTargetAllowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, addrPort.Addr())
+	}
+
+	return nil
+}
+
+// Send makes one attempt at the delivery and returns the response status,
+// or 0 when no response came.
+func (hs *HTTPSender) Send(ctx context.Context, job entities.WebhookJob) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	payload := job.Delivery.Payload
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Webhook-Event", string(job.Delivery.Event))
+	req.Header.Set("X-Webhook-Delivery", job.Delivery.ID.String())
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(job.Secret, timestamp, payload))
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Webhook-Signature value for a payload sent at
+// timestamp.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+func TestCheckTarget(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"127.0.0.1:80", false},
+		{"10.1.2.3:443", false},
+		{"172.16.0.1:443", false},
+		{"192.168.1.1:8080", false},
+		{"169.254.169.254:80", false},
+		{"0.0.0.0:80", false},
+		{"224.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"[::]:80", false},
+		{"[fe80::1]:80", false},
+		{"[fd00::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"[::ffff:10.0.0.1]:80", false},
+		{"not an address", false},
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := checkTarget("tcp", tt.address, nil)
+			if tt.allowed && err != nil {
+				t.Fatalf("checkTarget() error = %v, want nil", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbiddenTarget) {
+				t.Fatalf("checkTarget() error = %v, want %v", err, ErrForbiddenTarget)
+			}
+		})
+	}
+}
+
+func TestSendRefusesLoopback(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	status, err := NewHTTPSender(time.Second).Send(context.Background(), testJob(srv.URL))
+	if !errors.Is(err, ErrForbiddenTarget) {
+		t.Fatalf("Send() error = %v, want %v", err, ErrForbiddenTarget)
+	}
+	if status != 0 {
+		t.Fatalf("Send() status = %d, want 0", status)
+	}
+	if hits.Load() != 0 {
+		t.Fatal("request reached the server")
+	}
+}
+
+func TestSendSignsPayload(t *testing.T) {
+	job := testJob("")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := Sign(job.Secret, r.Header.Get("X-Webhook-Timestamp"), body)
+		if got := r.Header.Get("X-Webhook-Signature"); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if got := r.Header.Get("X-Webhook-Delivery"); got != job.Delivery.ID.String() {
+			t.Errorf("delivery = %q, want %q", got, job.Delivery.ID)
+		}
+		if got := r.Header.Get("X-Webhook-Event"); got != string(job.Delivery.Event) {
+			t.Errorf("event = %q, want %q", got, job.Delivery.Event)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	job.URL = srv.URL
+
+	status, err := newHTTPSender(time.Second, nil).Send(context.Background(), job)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Send() = %d, %v; want %d, nil", status, err, http.StatusNoContent)
+	}
+}
+
+func TestSendKeepsOnlyStatusOfFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("internal secret"))
+	}))
+	defer srv.Close()
+
+	status, err := newHTTPSender(time.Second, nil).Send(context.Background(), testJob(srv.URL))
+	if status != http.StatusInternalServerError {
+		t.Fatalf("Send() status = %d, want %d", status, http.StatusInternalServerError)
+	}
+	if err == nil || strings.Contains(err.Error(), "internal secret") {
+		t.Fatalf("Send() error = %v, want an error without the response body", err)
+	}
+}
+
+func testJob(url string) entities.WebhookJob {
+	delivery := entities.NewWebhookDelivery(uuid.New(), uuid.New(), entities.WebhookMessageCreated,
+		[]byte(`{"event":"message.created"}`), time.Now())
+
+	return entities.WebhookJob{Delivery: delivery, URL: url, Secret: "secret"}
+}
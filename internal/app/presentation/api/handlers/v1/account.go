@@ -1,9 +1,9 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"regexp"
 
@@ -11,6 +11,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/renderview-inc/backend/internal/app/application/dtos"
 	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 	"github.com/renderview-inc/backend/internal/app/domain/entities"
 )
 
@@ -18,22 +20,25 @@ type UserAccountHandler struct {
 	accountService *services.UserAccountService
 	passwordHasher services.BcryptPasswordHasher
 	validate       *validator.Validate
+	logger         logger.Logger
 }
 
-func NewUserAccountHandler(accountService *services.UserAccountService, passwordHasher services.BcryptPasswordHasher) *UserAccountHandler {
+func NewUserAccountHandler(accountService *services.UserAccountService, passwordHasher services.BcryptPasswordHasher,
+	logger logger.Logger) *UserAccountHandler {
 	validate := validator.New()
 
 	if err := validate.RegisterValidation("matches", func(fl validator.FieldLevel) bool {
 		tagRe := regexp.MustCompile(`^[a-zA-Z0-9_.]{3,12}$`)
 		return tagRe.MatchString(fl.Field().String())
 	}); err != nil {
-		log.Printf("Failed to register validation: %v", err)
+		logger.Error(context.Background(), "failed to register validation", option.Error(err))
 	}
 
 	return &UserAccountHandler{
 		accountService: accountService,
 		passwordHasher: passwordHasher,
 		validate:       validate,
+		logger:         logger,
 	}
 }
 
@@ -57,6 +62,7 @@ func (uah *UserAccountHandler) HandleRegister(w http.ResponseWriter, r *http.Req
 
 	hashedPassword, err := uah.passwordHasher.HashPassword(registerDto.Credentials.Password)
 	if err != nil {
+		uah.logger.Error(r.Context(), "failed to process password", option.Error(err))
 		http.Error(w, "Failed to process password", http.StatusInternalServerError)
 		return
 	}
@@ -73,6 +79,7 @@ func (uah *UserAccountHandler) HandleRegister(w http.ResponseWriter, r *http.Req
 
 	if err := uah.accountService.Register(r.Context(), userAccount); err != nil {
 		// TODO: handle different error types
+		uah.logger.Error(r.Context(), "failed to register user", option.Error(err))
 		http.Error(w, fmt.Sprintf("Failed to register user: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
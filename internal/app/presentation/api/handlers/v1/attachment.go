@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+const (
+	// multipartOverhead leaves room for the form fields and part headers on
+	// top of the file itself.
+	multipartOverhead = 1 << 20
+	multipartMemory   = 8 << 20
+)
+
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+	logger            logger.Logger
+}
+
+func NewAttachmentHandler(attachmentService *services.AttachmentService, logger logger.Logger) AttachmentHandler {
+	return AttachmentHandler{
+		attachmentService: attachmentService,
+		logger:            logger,
+	}
+}
+
+// HandleUpload expects a multipart form with a chat_id field and a file part.
+func (ah *AttachmentHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, ah.attachmentService.MaxSize()+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, services.ErrAttachmentTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+
+	chatID, err := uuid.Parse(r.FormValue("chat_id"))
+	if err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := ah.attachmentService.Upload(r.Context(), userID, chatID, header.Filename, header.Size, file)
+	if err != nil {
+		writeServiceError(w, r, ah.logger, "failed to upload attachment", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(attachment); err != nil {
+		ah.logger.Error(r.Context(), "failed to encode attachment", option.Error(err))
+		return
+	}
+}
+
+func (ah *AttachmentHandler) HandleDownload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "invalid ID; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := ah.attachmentService.Open(r.Context(), userID, id)
+	if err != nil {
+		writeServiceError(w, r, ah.logger, "failed to open attachment", err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": attachment.FileName,
+	}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", strconv.Quote(attachment.Checksum))
+
+	if _, err = io.Copy(w, content); err != nil {
+		ah.logger.Warn(r.Context(), "failed to stream attachment",
+			option.Any("attachment_id", id.String()),
+			option.Error(err),
+		)
+	}
+}
+
+func (ah *AttachmentHandler) HandleThumbnail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "invalid ID; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := ah.attachmentService.OpenThumbnail(r.Context(), userID, id)
+	if err != nil {
+		writeServiceError(w, r, ah.logger, "failed to open thumbnail", err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", strconv.Quote(attachment.Checksum+"-thumb"))
+
+	if _, err = io.Copy(w, content); err != nil {
+		ah.logger.Warn(r.Context(), "failed to stream thumbnail",
+			option.Any("attachment_id", id.String()),
+			option.Error(err),
+		)
+	}
+}
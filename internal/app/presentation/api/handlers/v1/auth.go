@@ -8,15 +8,19 @@ import (
 
 	"github.com/renderview-inc/backend/internal/app/application/dtos"
 	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 )
 
 type AuthHandler struct {
 	authService *services.AuthService
+	logger      logger.Logger
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, logger logger.Logger) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
+		logger:      logger,
 	}
 }
 
@@ -29,6 +33,7 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 
 	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
+		h.logger.Error(r.Context(), "failed to parse IP address", option.Error(err))
 		http.Error(w, fmt.Sprintf("Failed to parse IP address: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
@@ -44,6 +49,7 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	tokens, err := h.authService.Login(r.Context(), fullLoginInfo)
 	if err != nil {
 		// TODO: handle different error types
+		h.logger.Error(r.Context(), "failed to login", option.Error(err))
 		http.Error(w, fmt.Sprintf("Failed to login: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
@@ -51,6 +57,7 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if err := json.NewEncoder(w).Encode(tokens); err != nil {
+		h.logger.Error(r.Context(), "failed to encode response", option.Error(err))
 		http.Error(w, fmt.Sprintf("Failed to encode response: %s", err.Error()), http.StatusInternalServerError)
 	}
 }
@@ -64,6 +71,7 @@ func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.authService.Logout(r.Context(), tokensDto); err != nil {
 		// TODO: handle different error types
+		h.logger.Error(r.Context(), "failed to logout", option.Error(err))
 		http.Error(w, fmt.Sprintf("Failed to logout: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
@@ -81,6 +89,7 @@ func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 	tokens, err := h.authService.Refresh(r.Context(), tokensDto.RefreshToken)
 	if err != nil {
 		// TODO: handle different error types
+		h.logger.Error(r.Context(), "failed to refresh token", option.Error(err))
 		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
 		return
 	}
@@ -88,6 +97,7 @@ func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if err := json.NewEncoder(w).Encode(tokens); err != nil {
+		h.logger.Error(r.Context(), "failed to encode response", option.Error(err))
 		http.Error(w, fmt.Sprintf("Failed to encode response: %s", err.Error()), http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+type BotHandler struct {
+	botService *services.BotService
+	logger     logger.Logger
+}
+
+func NewBotHandler(botService *services.BotService, logger logger.Logger) BotHandler {
+	return BotHandler{
+		botService: botService,
+		logger:     logger,
+	}
+}
+
+func (bh *BotHandler) HandleCreateBot(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.BotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	bot, err := bh.botService.Create(r.Context(), userID, req)
+	if err != nil {
+		writeServiceError(w, r, bh.logger, "failed to create bot", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(bot); err != nil {
+		bh.logger.Error(r.Context(), "failed to encode bot", option.Error(err))
+		return
+	}
+}
+
+func (bh *BotHandler) HandleListBots(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	bots, err := bh.botService.List(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, r, bh.logger, "failed to list bots", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(dtos.BotsResponse{Bots: bots}); err != nil {
+		bh.logger.Error(r.Context(), "failed to encode bots", option.Error(err))
+		http.Error(w, "failed to encode bots", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (bh *BotHandler) HandleCreateKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.BotKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	key, err := bh.botService.CreateKey(r.Context(), userID, req)
+	if err != nil {
+		writeServiceError(w, r, bh.logger, "failed to issue API key", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(key); err != nil {
+		bh.logger.Error(r.Context(), "failed to encode API key", option.Error(err))
+		return
+	}
+}
+
+func (bh *BotHandler) HandleListKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	botID, err := uuid.Parse(r.URL.Query().Get("bot_id"))
+	if err != nil {
+		http.Error(w, "invalid bot_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	keys, err := bh.botService.ListKeys(r.Context(), userID, botID)
+	if err != nil {
+		writeServiceError(w, r, bh.logger, "failed to list API keys", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(dtos.BotKeysResponse{Keys: keys}); err != nil {
+		bh.logger.Error(r.Context(), "failed to encode API keys", option.Error(err))
+		http.Error(w, "failed to encode API keys", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (bh *BotHandler) HandleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.BotKeyID
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := bh.botService.RevokeKey(r.Context(), userID, req.ID); err != nil {
+		writeServiceError(w, r, bh.logger, "failed to revoke API key", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -3,56 +3,72 @@ package v1
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/renderview-inc/backend/internal/app/application/dtos"
 	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 )
 
 type ChatHandler struct {
 	chatService *services.ChatService
+	logger      logger.Logger
 }
 
-func NewChatHandler(chatService *services.ChatService) ChatHandler {
+func NewChatHandler(chatService *services.ChatService, logger logger.Logger) ChatHandler {
 	return ChatHandler{
 		chatService: chatService,
+		logger:      logger,
 	}
 }
 
 func (ch *ChatHandler) HandleCreateChat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	var chat dtos.ChatRequest
 	if err := json.NewDecoder(r.Body).Decode(&chat); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	resp, err := ch.chatService.Create(r.Context(), chat)
+	resp, err := ch.chatService.Create(r.Context(), userID, chat)
 
 	if err != nil {
-		http.Error(w, "failed to create chat", http.StatusInternalServerError)
+		writeServiceError(w, r, ch.logger, "failed to create chat", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode response after creating", option.Error(err))
 		http.Error(w, "failed to encode response after creating", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (ch *ChatHandler) HandleAddParticipant(w http.ResponseWriter, r *http.Request) {
-    var participation dtos.ChatParticipation
-    if err := json.NewDecoder(r.Body).Decode(&participation); err != nil {
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
 
-    if err := ch.chatService.AddParticipant(r.Context(), participation); err != nil {
-        http.Error(w, "failed to add participant", http.StatusInternalServerError)
-        return
-    }
+	var participation dtos.ChatParticipation
+	if err := json.NewDecoder(r.Body).Decode(&participation); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.chatService.AddParticipant(r.Context(), userID, participation); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to add participant", err)
+		return
+	}
 
-    w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusOK)
 }
 
 func (ch *ChatHandler) HandleGetChatInfoByTag(w http.ResponseWriter, r *http.Request) {
@@ -60,19 +76,25 @@ func (ch *ChatHandler) HandleGetChatInfoByTag(w http.ResponseWriter, r *http.Req
 
 	chat, err := ch.chatService.GetByTag(r.Context(), tag)
 	if err != nil {
-		http.Error(w, "failed to retrieve chat info", http.StatusInternalServerError)
+		writeServiceError(w, r, ch.logger, "failed to retrieve chat info", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 
 	if err = json.NewEncoder(w).Encode(chat); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode chat info", option.Error(err))
 		http.Error(w, "failed to encode chat info", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (ch *ChatHandler) HandleGetChatInfoByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	id := r.URL.Query().Get("id")
 
 	parsedID, err := uuid.Parse(id)
@@ -81,47 +103,70 @@ func (ch *ChatHandler) HandleGetChatInfoByID(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	chat, err := ch.chatService.GetByID(r.Context(), parsedID)
+	chat, err := ch.chatService.GetByID(r.Context(), userID, parsedID)
 	if err != nil {
-		http.Error(w, "failed to retrieve chat info", http.StatusInternalServerError)
+		writeServiceError(w, r, ch.logger, "failed to retrieve chat info", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 
 	if err = json.NewEncoder(w).Encode(chat); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode chat info", option.Error(err))
 		http.Error(w, "failed to encode chat info", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (ch *ChatHandler) HandleGetChatsWithLastMessages(w http.ResponseWriter, r *http.Request) {
-    result, err := ch.chatService.GetChatsWithLastMessages(r.Context())
-    if err != nil {
-        http.Error(w, "failed to get chats with last messages", http.StatusInternalServerError)
-        return
-    }
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	var archived bool
+	if v := r.URL.Query().Get("archived"); v != "" {
+		if archived, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid archived; must be boolean", http.StatusBadRequest)
+			return
+		}
+	}
 
-	info := dtos.ChatLastMessagesResponse{
-		Info: result,
+	info, err := ch.chatService.GetChatsWithLastMessages(r.Context(), userID, archived, limit, offset)
+	if err != nil {
+		ch.logger.Error(r.Context(), "failed to get chats with last messages", option.Error(err))
+		http.Error(w, "failed to get chats with last messages", http.StatusInternalServerError)
+		return
 	}
 
-    w.Header().Set("Content-Type", "application/json")
-    if err := json.NewEncoder(w).Encode(info); err != nil {
-        http.Error(w, "failed to encode response", http.StatusInternalServerError)
-        return
-    }
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode response", option.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
 }
 
 func (ch *ChatHandler) HandleUpdateChat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	var chat dtos.ChatRequest
 	if err := json.NewDecoder(r.Body).Decode(&chat); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := ch.chatService.Update(r.Context(), chat); err != nil {
-		http.Error(w, "failed to update chat", http.StatusInternalServerError)
+	if err := ch.chatService.Update(r.Context(), userID, chat); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to update chat", err)
 		return
 	}
 
@@ -129,6 +174,11 @@ func (ch *ChatHandler) HandleUpdateChat(w http.ResponseWriter, r *http.Request)
 }
 
 func (ch *ChatHandler) HandleDeleteChat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	id := r.URL.Query().Get("id")
 
 	parsedID, err := uuid.Parse(id)
@@ -137,8 +187,8 @@ func (ch *ChatHandler) HandleDeleteChat(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := ch.chatService.Delete(r.Context(), parsedID); err != nil {
-		http.Error(w, "failed to delete chat", http.StatusInternalServerError)
+	if err := ch.chatService.Delete(r.Context(), userID, parsedID); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to delete chat", err)
 		return
 	}
 
@@ -146,16 +196,199 @@ func (ch *ChatHandler) HandleDeleteChat(w http.ResponseWriter, r *http.Request)
 }
 
 func (ch *ChatHandler) HandleRemoveParticipant(w http.ResponseWriter, r *http.Request) {
-    var participation dtos.ChatParticipation
-    if err := json.NewDecoder(r.Body).Decode(&participation); err != nil {
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-
-    if err := ch.chatService.RemoveParticipant(r.Context(), participation); err != nil {
-        http.Error(w, "failed to remove participant", http.StatusInternalServerError)
-        return
-    }
-
-    w.WriteHeader(http.StatusOK)
-}
\ No newline at end of file
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var participation dtos.ChatParticipation
+	if err := json.NewDecoder(r.Body).Decode(&participation); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.chatService.RemoveParticipant(r.Context(), userID, participation); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to remove participant", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ChatHandler) HandleChangeParticipantRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var participation dtos.ChatParticipation
+	if err := json.NewDecoder(r.Body).Decode(&participation); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.chatService.ChangeRole(r.Context(), userID, participation); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to change participant role", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ChatHandler) HandleTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var participation dtos.ChatParticipation
+	if err := json.NewDecoder(r.Body).Decode(&participation); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.chatService.TransferOwnership(r.Context(), userID, participation); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to transfer ownership", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ChatHandler) HandleOpenDirectChat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.DirectChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	chat, err := ch.chatService.OpenDirect(r.Context(), userID, req.UserID)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to open direct chat", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(chat); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode direct chat", option.Error(err))
+		http.Error(w, "failed to encode direct chat", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ch *ChatHandler) HandlePinMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.PinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.chatService.Pin(r.Context(), userID, req); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to pin message", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ChatHandler) HandleUnpinMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.PinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.chatService.Unpin(r.Context(), userID, req); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to unpin message", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ChatHandler) HandleGetPinnedMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	chatID, err := uuid.Parse(r.URL.Query().Get("chat_id"))
+	if err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	pins, err := ch.chatService.GetPinned(r.Context(), userID, chatID)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to get pinned messages", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(pins); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode pinned messages", option.Error(err))
+		http.Error(w, "failed to encode pinned messages", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ch *ChatHandler) HandleGetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	chatID, err := uuid.Parse(r.URL.Query().Get("chat_id"))
+	if err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := ch.chatService.GetPreferences(r.Context(), userID, chatID)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to get chat preferences", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(prefs); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode chat preferences", option.Error(err))
+		http.Error(w, "failed to encode chat preferences", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ch *ChatHandler) HandleUpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ChatPreferences
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.chatService.UpdatePreferences(r.Context(), userID, req); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to update chat preferences", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+type ChatInviteHandler struct {
+	inviteService *services.ChatInviteService
+	logger        logger.Logger
+}
+
+func NewChatInviteHandler(inviteService *services.ChatInviteService, logger logger.Logger) ChatInviteHandler {
+	return ChatInviteHandler{
+		inviteService: inviteService,
+		logger:        logger,
+	}
+}
+
+func (cih *ChatInviteHandler) HandleCreateInvite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ChatInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	invite, err := cih.inviteService.Create(r.Context(), userID, req)
+	if err != nil {
+		writeServiceError(w, r, cih.logger, "failed to create invite", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(invite); err != nil {
+		cih.logger.Error(r.Context(), "failed to encode invite", option.Error(err))
+		return
+	}
+}
+
+func (cih *ChatInviteHandler) HandleListInvites(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	chatID, err := uuid.Parse(r.URL.Query().Get("chat_id"))
+	if err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	invites, err := cih.inviteService.ListActive(r.Context(), userID, chatID)
+	if err != nil {
+		writeServiceError(w, r, cih.logger, "failed to list invites", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(dtos.ChatInvitesResponse{Invites: invites}); err != nil {
+		cih.logger.Error(r.Context(), "failed to encode invites", option.Error(err))
+		http.Error(w, "failed to encode invites", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (cih *ChatInviteHandler) HandleRevokeInvite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		http.Error(w, "code is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := cih.inviteService.Revoke(r.Context(), userID, code); err != nil {
+		writeServiceError(w, r, cih.logger, "failed to revoke invite", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (cih *ChatInviteHandler) HandleJoinChat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var join dtos.JoinChat
+	if err := json.NewDecoder(r.Body).Decode(&join); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	chat, err := cih.inviteService.Join(r.Context(), userID, join.Code)
+	if err != nil {
+		writeServiceError(w, r, cih.logger, "failed to join chat", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(chat); err != nil {
+		cih.logger.Error(r.Context(), "failed to encode chat", option.Error(err))
+		http.Error(w, "failed to encode chat", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+type CommandHandler struct {
+	commandService *services.CommandService
+	logger         logger.Logger
+}
+
+func NewCommandHandler(commandService *services.CommandService, logger logger.Logger) CommandHandler {
+	return CommandHandler{
+		commandService: commandService,
+		logger:         logger,
+	}
+}
+
+func (ch *CommandHandler) HandleRegisterCommand(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ChatCommandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cmd, err := ch.commandService.Register(r.Context(), userID, req)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to register command", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(cmd); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode command", option.Error(err))
+		return
+	}
+}
+
+func (ch *CommandHandler) HandleListCommands(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	chatID, err := uuid.Parse(r.URL.Query().Get("chat_id"))
+	if err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	commands, err := ch.commandService.List(r.Context(), userID, chatID)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to list commands", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(dtos.ChatCommandsResponse{Commands: commands}); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode commands", option.Error(err))
+		http.Error(w, "failed to encode commands", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ch *CommandHandler) HandleUnregisterCommand(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ChatCommandID
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.commandService.Unregister(r.Context(), userID, req); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to unregister command", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *CommandHandler) HandleGetReplies(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	chatID, err := uuid.Parse(r.URL.Query().Get("chat_id"))
+	if err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	replies, err := ch.commandService.GetReplies(r.Context(), userID, chatID)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to get command replies", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(dtos.CommandRepliesResponse{Replies: replies}); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode command replies", option.Error(err))
+		http.Error(w, "failed to encode command replies", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleClaimInvocations is polled by bots for the commands sent to them.
+func (ch *CommandHandler) HandleClaimInvocations(w http.ResponseWriter, r *http.Request) {
+	botID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, _, err := pageParams(r)
+	if err != nil {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	invocations, err := ch.commandService.ClaimInvocations(r.Context(), botID, limit)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to claim command invocations", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(dtos.CommandInvocationsResponse{Invocations: invocations}); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode command invocations", option.Error(err))
+		http.Error(w, "failed to encode command invocations", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ch *CommandHandler) HandleRespond(w http.ResponseWriter, r *http.Request) {
+	botID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.CommandResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.commandService.Respond(r.Context(), botID, req); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to answer command", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
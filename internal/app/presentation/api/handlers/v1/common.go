@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/middleware"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+// callerID returns the authenticated user's ID, answering 401 if there is none.
+func callerID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// pageParams reads the optional limit and offset query parameters. Zero
+// values are left for the service to replace with its defaults.
+func pageParams(r *http.Request) (int, int, error) {
+	var limit, offset int
+	var err error
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return 0, 0, err
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// optionalUUID reads a query parameter as a UUID, returning nil when it is
+// absent.
+func optionalUUID(r *http.Request, name string) (*uuid.UUID, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+// optionalTime reads a query parameter as an RFC 3339 timestamp, returning
+// nil when it is absent.
+func optionalTime(r *http.Request, name string) (*time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// writeServiceError answers with the status matching a known service error
+// and its text. Unknown errors are logged and reported as internal with msg.
+func writeServiceError(w http.ResponseWriter, r *http.Request, logger logger.Logger, msg string, err error) {
+	status := serviceErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		logger.Error(r.Context(), msg, option.Error(err))
+		http.Error(w, msg, status)
+		return
+	}
+
+	http.Error(w, err.Error(), status)
+}
+
+func serviceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrChatNotFound), errors.Is(err, services.ErrNotChatParticipant),
+		errors.Is(err, services.ErrInviteNotFound), errors.Is(err, services.ErrNoAccountFound),
+		errors.Is(err, services.ErrMessageNotFound), errors.Is(err, services.ErrAttachmentNotFound),
+		errors.Is(err, services.ErrThumbnailNotFound), errors.Is(err, services.ErrWebhookNotFound),
+		errors.Is(err, services.ErrBotNotFound), errors.Is(err, services.ErrBotKeyNotFound),
+		errors.Is(err, services.ErrCommandNotFound), errors.Is(err, services.ErrInvocationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInviteExpired), errors.Is(err, services.ErrInvocationExpired):
+		return http.StatusGone
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrAttachmentTypeNotAllowed):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, services.ErrChatPermissionDenied), errors.Is(err, services.ErrBlockedByUser):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrChatTagTaken), errors.Is(err, services.ErrBotTagTaken),
+		errors.Is(err, services.ErrAlreadyChatParticipant),
+		errors.Is(err, services.ErrDirectChatRestricted), errors.Is(err, services.ErrMessageDeleted),
+		errors.Is(err, services.ErrTooManyPins), errors.Is(err, services.ErrCommandTaken):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidChatRole), errors.Is(err, services.ErrInvalidInviteSettings),
+		errors.Is(err, services.ErrInvalidChatTag), errors.Is(err, services.ErrDirectChatWithSelf),
+		errors.Is(err, services.ErrPresenceBatchTooLarge), errors.Is(err, services.ErrInvalidLastSeenSetting),
+		errors.Is(err, services.ErrEmptyMessage), errors.Is(err, services.ErrInvalidReply),
+		errors.Is(err, services.ErrInvalidReaction), errors.Is(err, services.ErrEmptyAttachment),
+		errors.Is(err, services.ErrAttachmentSizeMismatch), errors.Is(err, services.ErrInvalidAttachment),
+		errors.Is(err, services.ErrInvalidImage), errors.Is(err, services.ErrInvalidSearchQuery),
+		errors.Is(err, services.ErrInvalidSearchRange), errors.Is(err, services.ErrInvalidUserSearch),
+		errors.Is(err, services.ErrContactWithSelf), errors.Is(err, services.ErrBlockSelf),
+		errors.Is(err, services.ErrInvalidNotifyLevel), errors.Is(err, services.ErrInvalidDeviceToken),
+		errors.Is(err, services.ErrInvalidPushPlatform), errors.Is(err, services.ErrInvalidWebhookURL),
+		errors.Is(err, services.ErrInvalidWebhookEvents), errors.Is(err, services.ErrInvalidBotTag),
+		errors.Is(err, services.ErrInvalidBotName), errors.Is(err, services.ErrInvalidBotKeySettings),
+		errors.Is(err, services.ErrInvalidCommandName), errors.Is(err, services.ErrInvalidCommandDesc),
+		errors.Is(err, services.ErrCommandBotNotInChat):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
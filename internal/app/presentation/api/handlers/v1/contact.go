@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+type ContactHandler struct {
+	contactService *services.ContactService
+	logger         logger.Logger
+}
+
+func NewContactHandler(contactService *services.ContactService, logger logger.Logger) ContactHandler {
+	return ContactHandler{
+		contactService: contactService,
+		logger:         logger,
+	}
+}
+
+func (ch *ContactHandler) HandleAddContact(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.contactService.AddContact(r.Context(), userID, req.UserID); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to add contact", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ContactHandler) HandleRemoveContact(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.contactService.RemoveContact(r.Context(), userID, req.UserID); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to remove contact", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ContactHandler) HandleListContacts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	contacts, err := ch.contactService.ListContacts(r.Context(), userID, limit, offset)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to list contacts", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(contacts); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode contacts", option.Error(err))
+		http.Error(w, "failed to encode contacts", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ch *ContactHandler) HandleBlock(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.contactService.Block(r.Context(), userID, req.UserID); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to block user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ContactHandler) HandleUnblock(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.contactService.Unblock(r.Context(), userID, req.UserID); err != nil {
+		writeServiceError(w, r, ch.logger, "failed to unblock user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ch *ContactHandler) HandleListBlocked(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	blocked, err := ch.contactService.ListBlocked(r.Context(), userID, limit, offset)
+	if err != nil {
+		writeServiceError(w, r, ch.logger, "failed to list blocked users", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(blocked); err != nil {
+		ch.logger.Error(r.Context(), "failed to encode blocked users", option.Error(err))
+		http.Error(w, "failed to encode blocked users", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+)
+
+type DeviceHandler struct {
+	deviceService *services.DeviceService
+	logger        logger.Logger
+}
+
+func NewDeviceHandler(deviceService *services.DeviceService, logger logger.Logger) DeviceHandler {
+	return DeviceHandler{
+		deviceService: deviceService,
+		logger:        logger,
+	}
+}
+
+func (dh *DeviceHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.DeviceRegistration
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := dh.deviceService.Register(r.Context(), userID, req); err != nil {
+		writeServiceError(w, r, dh.logger, "failed to register device", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (dh *DeviceHandler) HandleUnregister(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.DeviceRegistration
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := dh.deviceService.Unregister(r.Context(), userID, req); err != nil {
+		writeServiceError(w, r, dh.logger, "failed to unregister device", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
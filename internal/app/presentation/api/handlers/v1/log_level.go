@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+)
+
+type LogLevelHandler struct {
+	levels *logger.LevelRegistry
+}
+
+func NewLogLevelHandler(levels *logger.LevelRegistry) LogLevelHandler {
+	return LogLevelHandler{
+		levels: levels,
+	}
+}
+
+func (lh *LogLevelHandler) HandleGetLevels(w http.ResponseWriter, r *http.Request) {
+	resp := dtos.LogLevelsResponse{
+		Levels: lh.levels.Levels(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode log levels", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (lh *LogLevelHandler) HandleSetLevel(w http.ResponseWriter, r *http.Request) {
+	var level dtos.LogLevel
+	if err := json.NewDecoder(r.Body).Decode(&level); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := lh.levels.SetLevel(level.Logger, level.Level); err != nil {
+		if errors.Is(err, logger.ErrUnknownLogger) {
+			http.Error(w, "unknown logger", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "invalid log level", http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -7,27 +7,48 @@ import (
 	"github.com/google/uuid"
 	"github.com/renderview-inc/backend/internal/app/application/dtos"
 	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
 )
 
 type MessageHandler struct {
 	messageService *services.MessageService
+	logger         logger.Logger
 }
 
-func NewMessageHandler(messageService *services.MessageService) MessageHandler {
+func NewMessageHandler(messageService *services.MessageService, logger logger.Logger) MessageHandler {
 	return MessageHandler{
 		messageService: messageService,
+		logger:         logger,
 	}
 }
 
 func (mh *MessageHandler) HandleCreateMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	var msg dtos.Message
 	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := mh.messageService.Create(r.Context(), msg); err != nil {
-		http.Error(w, "failed to create message", http.StatusInternalServerError)
+	result, err := mh.messageService.Create(r.Context(), userID, msg)
+	if err != nil {
+		writeServiceError(w, r, mh.logger, "failed to create message", err)
+		return
+	}
+
+	// A command isn't stored as a message; the caller gets what came of it.
+	if result != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if err = json.NewEncoder(w).Encode(result); err != nil {
+			mh.logger.Error(r.Context(), "failed to encode command result", option.Error(err))
+			http.Error(w, "failed to encode command result", http.StatusInternalServerError)
+			return
+		}
 		return
 	}
 
@@ -35,6 +56,11 @@ func (mh *MessageHandler) HandleCreateMessage(w http.ResponseWriter, r *http.Req
 }
 
 func (mh *MessageHandler) HandleGetMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	id := r.URL.Query().Get("id")
 
 	parsedUUID, err := uuid.Parse(id)
@@ -43,48 +69,60 @@ func (mh *MessageHandler) HandleGetMessage(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	msg, err := mh.messageService.GetByID(r.Context(), parsedUUID)
+	msg, err := mh.messageService.GetByID(r.Context(), userID, parsedUUID)
 	if err != nil {
-		http.Error(w, "failed to retrieve message", http.StatusInternalServerError)
+		writeServiceError(w, r, mh.logger, "failed to retrieve message", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err = json.NewEncoder(w).Encode(msg); err != nil {
+		mh.logger.Error(r.Context(), "failed to encode message", option.Error(err))
 		http.Error(w, "failed to encode message", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (mh *MessageHandler) HandleGetLastMessageByChatTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	chatTag := r.URL.Query().Get("chat_tag")
 	if chatTag == "" {
 		http.Error(w, "chat_tag is required", http.StatusBadRequest)
 		return
 	}
 
-	msg, err := mh.messageService.GetLastByChatTag(r.Context(), chatTag)
+	msg, err := mh.messageService.GetLastByChatTag(r.Context(), userID, chatTag)
 	if err != nil {
-		http.Error(w, "failed to get last message", http.StatusInternalServerError)
+		writeServiceError(w, r, mh.logger, "failed to get last message", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(msg); err != nil {
+		mh.logger.Error(r.Context(), "failed to encode message", option.Error(err))
 		http.Error(w, "failed to encode message", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (mh *MessageHandler) HandleUpdateMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	var msg dtos.Message
 	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := mh.messageService.Update(r.Context(), msg); err != nil {
-		http.Error(w, "failed to update message", http.StatusInternalServerError)
+	if err := mh.messageService.Update(r.Context(), userID, msg); err != nil {
+		writeServiceError(w, r, mh.logger, "failed to update message", err)
 		return
 	}
 
@@ -92,6 +130,11 @@ func (mh *MessageHandler) HandleUpdateMessage(w http.ResponseWriter, r *http.Req
 }
 
 func (mh *MessageHandler) HandleDeleteMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	id := r.URL.Query().Get("id")
 
 	parsedUUID, err := uuid.Parse(id)
@@ -100,10 +143,200 @@ func (mh *MessageHandler) HandleDeleteMessage(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err := mh.messageService.Delete(r.Context(), parsedUUID); err != nil {
-		http.Error(w, "failed to delete message", http.StatusInternalServerError)
+	if err := mh.messageService.Delete(r.Context(), userID, parsedUUID); err != nil {
+		writeServiceError(w, r, mh.logger, "failed to delete message", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (mh *MessageHandler) HandleMarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var marker dtos.ReadMarker
+	if err := json.NewDecoder(r.Body).Decode(&marker); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := mh.messageService.MarkRead(r.Context(), userID, marker.MessageID); err != nil {
+		writeServiceError(w, r, mh.logger, "failed to mark message as read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (mh *MessageHandler) HandleGetRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	parsedUUID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "invalid ID; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := mh.messageService.GetRevisions(r.Context(), userID, parsedUUID)
+	if err != nil {
+		writeServiceError(w, r, mh.logger, "failed to get message revisions", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(revisions); err != nil {
+		mh.logger.Error(r.Context(), "failed to encode message revisions", option.Error(err))
+		http.Error(w, "failed to encode message revisions", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (mh *MessageHandler) HandleGetThread(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	parsedUUID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "invalid ID; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	thread, err := mh.messageService.GetThread(r.Context(), userID, parsedUUID, limit, offset)
+	if err != nil {
+		writeServiceError(w, r, mh.logger, "failed to get thread", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(thread); err != nil {
+		mh.logger.Error(r.Context(), "failed to encode thread", option.Error(err))
+		http.Error(w, "failed to encode thread", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (mh *MessageHandler) HandleGetMentions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	mentions, err := mh.messageService.GetMentions(r.Context(), userID, limit, offset)
+	if err != nil {
+		writeServiceError(w, r, mh.logger, "failed to get mentions", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(mentions); err != nil {
+		mh.logger.Error(r.Context(), "failed to encode mentions", option.Error(err))
+		http.Error(w, "failed to encode mentions", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (mh *MessageHandler) HandleAddReaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := mh.messageService.React(r.Context(), userID, req); err != nil {
+		writeServiceError(w, r, mh.logger, "failed to add reaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (mh *MessageHandler) HandleRemoveReaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := mh.messageService.Unreact(r.Context(), userID, req); err != nil {
+		writeServiceError(w, r, mh.logger, "failed to remove reaction", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// HandleSearch expects q and optionally chat_id, user_id, from and to
+// (RFC 3339) query parameters besides paging.
+func (mh *MessageHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	req := dtos.MessageSearchRequest{Query: r.URL.Query().Get("q")}
+
+	var err error
+	if req.ChatID, err = optionalUUID(r, "chat_id"); err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+	if req.UserID, err = optionalUUID(r, "user_id"); err != nil {
+		http.Error(w, "invalid user_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+	if req.From, err = optionalTime(r, "from"); err != nil {
+		http.Error(w, "invalid from; must be RFC 3339", http.StatusBadRequest)
+		return
+	}
+	if req.To, err = optionalTime(r, "to"); err != nil {
+		http.Error(w, "invalid to; must be RFC 3339", http.StatusBadRequest)
+		return
+	}
+	if req.Limit, req.Offset, err = pageParams(r); err != nil {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	results, err := mh.messageService.Search(r.Context(), userID, req)
+	if err != nil {
+		writeServiceError(w, r, mh.logger, "failed to search messages", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(results); err != nil {
+		mh.logger.Error(r.Context(), "failed to encode search results", option.Error(err))
+		http.Error(w, "failed to encode search results", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+	"github.com/renderview-inc/backend/internal/app/domain/entities"
+)
+
+type PresenceHandler struct {
+	presenceService *services.PresenceService
+	logger          logger.Logger
+}
+
+func NewPresenceHandler(presenceService *services.PresenceService, logger logger.Logger) PresenceHandler {
+	return PresenceHandler{
+		presenceService: presenceService,
+		logger:          logger,
+	}
+}
+
+func (ph *PresenceHandler) HandleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := ph.presenceService.Heartbeat(r.Context(), userID); err != nil {
+		writeServiceError(w, r, ph.logger, "failed to record heartbeat", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ph *PresenceHandler) HandleGoOffline(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := ph.presenceService.GoOffline(r.Context(), userID); err != nil {
+		writeServiceError(w, r, ph.logger, "failed to go offline", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetPresence expects a comma-separated user_ids query parameter.
+func (ph *PresenceHandler) HandleGetPresence(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var userIDs []uuid.UUID
+	for _, raw := range strings.Split(r.URL.Query().Get("user_ids"), ",") {
+		if raw == "" {
+			continue
+		}
+
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			http.Error(w, "invalid user_ids; must be comma-separated UUIDs", http.StatusBadRequest)
+			return
+		}
+		userIDs = append(userIDs, id)
+	}
+	if len(userIDs) == 0 {
+		http.Error(w, "user_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	presence, err := ph.presenceService.GetPresence(r.Context(), userID, userIDs)
+	if err != nil {
+		writeServiceError(w, r, ph.logger, "failed to get presence", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(presence); err != nil {
+		ph.logger.Error(r.Context(), "failed to encode presence", option.Error(err))
+		http.Error(w, "failed to encode presence", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ph *PresenceHandler) HandleSetPrivacy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var privacy dtos.PresencePrivacy
+	if err := json.NewDecoder(r.Body).Decode(&privacy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	visibility := entities.LastSeenVisibility(privacy.LastSeen)
+	if err := ph.presenceService.SetLastSeenVisibility(r.Context(), userID, visibility); err != nil {
+		writeServiceError(w, r, ph.logger, "failed to update privacy settings", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ph *PresenceHandler) HandleSetTyping(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var typing dtos.Typing
+	if err := json.NewDecoder(r.Body).Decode(&typing); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ph.presenceService.SetTyping(r.Context(), userID, typing); err != nil {
+		writeServiceError(w, r, ph.logger, "failed to update typing state", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ph *PresenceHandler) HandleGetTyping(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	chatID, err := uuid.Parse(r.URL.Query().Get("chat_id"))
+	if err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	typing, err := ph.presenceService.GetTyping(r.Context(), userID, chatID)
+	if err != nil {
+		writeServiceError(w, r, ph.logger, "failed to get typing users", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(typing); err != nil {
+		ph.logger.Error(r.Context(), "failed to encode typing users", option.Error(err))
+		http.Error(w, "failed to encode typing users", http.StatusInternalServerError)
+		return
+	}
+}
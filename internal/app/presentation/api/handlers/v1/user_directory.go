@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+type UserDirectoryHandler struct {
+	directoryService *services.UserDirectoryService
+	logger           logger.Logger
+}
+
+func NewUserDirectoryHandler(directoryService *services.UserDirectoryService, logger logger.Logger) UserDirectoryHandler {
+	return UserDirectoryHandler{
+		directoryService: directoryService,
+		logger:           logger,
+	}
+}
+
+// HandleSearch expects a q query parameter besides paging.
+func (udh *UserDirectoryHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	users, err := udh.directoryService.Search(r.Context(), userID, r.URL.Query().Get("q"), limit, offset)
+	if err != nil {
+		writeServiceError(w, r, udh.logger, "failed to search users", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(users); err != nil {
+		udh.logger.Error(r.Context(), "failed to encode users", option.Error(err))
+		http.Error(w, "failed to encode users", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (udh *UserDirectoryHandler) HandleSetDiscoverability(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.Discoverability
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := udh.directoryService.SetDiscoverable(r.Context(), userID, req.Discoverable); err != nil {
+		writeServiceError(w, r, udh.logger, "failed to change discoverability", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
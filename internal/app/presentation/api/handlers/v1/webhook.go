@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/renderview-inc/backend/internal/app/application/dtos"
+	"github.com/renderview-inc/backend/internal/app/application/services"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger"
+	"github.com/renderview-inc/backend/internal/app/application/services/logger/option"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+	logger         logger.Logger
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService, logger logger.Logger) WebhookHandler {
+	return WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+func (wh *WebhookHandler) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := wh.webhookService.Create(r.Context(), userID, req)
+	if err != nil {
+		writeServiceError(w, r, wh.logger, "failed to create webhook", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		wh.logger.Error(r.Context(), "failed to encode webhook", option.Error(err))
+		return
+	}
+}
+
+func (wh *WebhookHandler) HandleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	chatID, err := uuid.Parse(r.URL.Query().Get("chat_id"))
+	if err != nil {
+		http.Error(w, "invalid chat_id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	webhooks, err := wh.webhookService.List(r.Context(), userID, chatID)
+	if err != nil {
+		writeServiceError(w, r, wh.logger, "failed to list webhooks", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(dtos.WebhooksResponse{Webhooks: webhooks}); err != nil {
+		wh.logger.Error(r.Context(), "failed to encode webhooks", option.Error(err))
+		http.Error(w, "failed to encode webhooks", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (wh *WebhookHandler) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := wh.webhookService.Update(r.Context(), userID, req)
+	if err != nil {
+		writeServiceError(w, r, wh.logger, "failed to update webhook", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		wh.logger.Error(r.Context(), "failed to encode webhook", option.Error(err))
+		http.Error(w, "failed to encode webhook", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (wh *WebhookHandler) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.WebhookID
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := wh.webhookService.Delete(r.Context(), userID, req.ID); err != nil {
+		writeServiceError(w, r, wh.logger, "failed to delete webhook", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wh *WebhookHandler) HandleGetDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "invalid id; must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := wh.webhookService.GetDeliveries(r.Context(), userID, id, limit, offset)
+	if err != nil {
+		writeServiceError(w, r, wh.logger, "failed to get webhook deliveries", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(deliveries); err != nil {
+		wh.logger.Error(r.Context(), "failed to encode webhook deliveries", option.Error(err))
+		http.Error(w, "failed to encode webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,105 @@
+package imaging
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const (
+	base83Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+	blurhashComponentsX = 4
+	blurhashComponentsY = 3
+	maxQuantisedAC      = 82
+	acQuantSteps        = 18
+)
+
+// blurhash encodes img as a BlurHash string: a DC colour followed by a few
+// cosine components, which clients decode into a blurred placeholder. img
+// should already be small, as every pixel is visited once per component.
+func blurhash(img image.Image) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	factors := make([][3]float64, 0, blurhashComponentsX*blurhashComponentsY)
+	for j := 0; j < blurhashComponentsY; j++ {
+		for i := 0; i < blurhashComponentsX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+
+					r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+					factor[0] += basis * srgbToLinear(r>>8)
+					factor[1] += basis * srgbToLinear(g>>8)
+					factor[2] += basis * srgbToLinear(b>>8)
+				}
+			}
+
+			scale := 1 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	encodeBase83(&hash, (blurhashComponentsX-1)+(blurhashComponentsY-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+
+	var actualMax float64
+	for _, f := range ac {
+		actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+	}
+	quantisedMax := int(math.Max(0, math.Min(maxQuantisedAC, math.Floor(actualMax*166-0.5))))
+	maximumValue := float64(quantisedMax+1) / 166
+	encodeBase83(&hash, quantisedMax, 1)
+
+	encodeBase83(&hash, linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4)
+	for _, f := range ac {
+		encodeBase83(&hash, quantiseAC(f[0], maximumValue)*19*19+
+			quantiseAC(f[1], maximumValue)*19+
+			quantiseAC(f[2], maximumValue), 2)
+	}
+
+	return hash.String()
+}
+
+func quantiseAC(value, maximumValue float64) int {
+	v := value / maximumValue
+	signPow := math.Copysign(math.Sqrt(math.Abs(v)), v)
+
+	return int(math.Max(0, math.Min(acQuantSteps, math.Floor(signPow*9+9.5))))
+}
+
+func srgbToLinear(value uint32) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func encodeBase83(sb *strings.Builder, value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		digit := value / int(math.Pow(83, float64(i))) % 83
+		sb.WriteByte(base83Alphabet[digit])
+	}
+}
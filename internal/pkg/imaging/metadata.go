@@ -0,0 +1,267 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	jpegMarkerPrefix = 0xFF
+	jpegSOI          = 0xD8
+	jpegEOI          = 0xD9
+	jpegSOS          = 0xDA
+	jpegRST0         = 0xD0
+	jpegRST7         = 0xD7
+	jpegTEM          = 0x01
+	jpegAPP1         = 0xE1
+	jpegAPP2         = 0xE2
+	jpegAPP13        = 0xED
+	jpegCOM          = 0xFE
+
+	exifOrientationTag = 0x0112
+	exifTypeShort      = 3
+	ifdEntrySize       = 12
+
+	pngSignatureLen  = 8
+	pngChunkOverhead = 12
+
+	riffHeaderLen     = 12
+	riffChunkHeader   = 8
+	webpVP8XExifFlag  = 0x08
+	webpVP8XXMPFlag   = 0x04
+	orientationNormal = 1
+	orientationMax    = 8
+)
+
+// stripJPEG drops APP1 (EXIF, XMP), APP13 (IPTC), comment and MPF segments
+// and everything after the primary image's EOI, where phones append
+// secondary images with EXIF of their own. The entropy-coded data is copied
+// as is, so the image is not re-encoded.
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != jpegMarkerPrefix || data[1] != jpegSOI {
+		return nil, ErrInvalidImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+
+	for i := 2; i < len(data); {
+		if data[i] != jpegMarkerPrefix {
+			return nil, ErrInvalidImage
+		}
+		// Markers may be preceded by any number of fill bytes.
+		for i+1 < len(data) && data[i+1] == jpegMarkerPrefix {
+			i++
+		}
+		if i+1 >= len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		marker := data[i+1]
+		switch {
+		case marker == jpegEOI:
+			return append(out, data[i:i+2]...), nil
+		case marker == jpegTEM || (marker >= jpegRST0 && marker <= jpegRST7):
+			out = append(out, data[i:i+2]...)
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) || end < i+4 {
+			return nil, ErrInvalidImage
+		}
+
+		if marker == jpegSOS {
+			scanEnd := jpegScanEnd(data, end)
+			out = append(out, data[i:scanEnd]...)
+			i = scanEnd
+			continue
+		}
+		if !jpegMetadataSegment(marker, data[i+4:end]) {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	return nil, ErrInvalidImage
+}
+
+func jpegMetadataSegment(marker byte, payload []byte) bool {
+	switch marker {
+	case jpegAPP1, jpegAPP13, jpegCOM:
+		return true
+	case jpegAPP2:
+		// The MPF index points at the secondary images after EOI.
+		return bytes.HasPrefix(payload, []byte("MPF\x00"))
+	default:
+		return false
+	}
+}
+
+// jpegScanEnd returns where the entropy-coded data starting at i ends: at
+// the first marker other than a stuffed 0xFF or a restart marker, or at the
+// end of the data.
+func jpegScanEnd(data []byte, i int) int {
+	for ; i+1 < len(data); i++ {
+		if data[i] != jpegMarkerPrefix {
+			continue
+		}
+		next := data[i+1]
+		if next != 0 && next != jpegMarkerPrefix && (next < jpegRST0 || next > jpegRST7) {
+			return i
+		}
+	}
+
+	return len(data)
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 when there is
+// none or it can't be read.
+func jpegOrientation(data []byte) int {
+	for i := 2; i+4 <= len(data) && data[i] == jpegMarkerPrefix; {
+		marker := data[i+1]
+		if marker == jpegSOS || marker == jpegEOI {
+			break
+		}
+		// A segment length counts its own two bytes, so it is at least 2.
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) || end < i+4 {
+			break
+		}
+		if marker == jpegAPP1 {
+			if o := exifOrientation(data[i+4 : end]); o != orientationNormal {
+				return o
+			}
+		}
+		i = end
+	}
+
+	return orientationNormal
+}
+
+// exifOrientation reads the orientation tag from IFD0 of an APP1 EXIF
+// payload.
+func exifOrientation(payload []byte) int {
+	const exifHeader = "Exif\x00\x00"
+	if !bytes.HasPrefix(payload, []byte(exifHeader)) {
+		return orientationNormal
+	}
+	tiff := payload[len(exifHeader):]
+	if len(tiff) < 8 {
+		return orientationNormal
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return orientationNormal
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < count; e++ {
+		entry := ifd + 2 + e*ifdEntrySize
+		if entry+ifdEntrySize > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag || order.Uint16(tiff[entry+2:]) != exifTypeShort {
+			continue
+		}
+		o := int(order.Uint16(tiff[entry+8:]))
+		if o < orientationNormal || o > orientationMax {
+			return orientationNormal
+		}
+		return o
+	}
+
+	return orientationNormal
+}
+
+// stripPNG drops the eXIf chunk and the textual and timestamp chunks, which
+// is where editors put XMP and camera details.
+func stripPNG(data []byte) ([]byte, error) {
+	if len(data) < pngSignatureLen || string(data[:pngSignatureLen]) != "\x89PNG\r\n\x1a\n" {
+		return nil, ErrInvalidImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:pngSignatureLen]...)
+
+	for i := pngSignatureLen; i < len(data); {
+		if i+pngChunkOverhead > len(data) {
+			return nil, ErrInvalidImage
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + pngChunkOverhead + length
+		if end > len(data) || end < i {
+			return nil, ErrInvalidImage
+		}
+
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, data[i:end]...)
+		}
+		if string(data[i+4:i+8]) == "IEND" {
+			return out, nil
+		}
+		i = end
+	}
+
+	return nil, ErrInvalidImage
+}
+
+// stripWebP drops the EXIF and XMP chunks of an extended WebP and clears
+// their flags in the VP8X header.
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < riffHeaderLen || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrInvalidImage
+	}
+	riffEnd := riffChunkHeader + int(binary.LittleEndian.Uint32(data[4:]))
+	if riffEnd > len(data) || riffEnd < riffHeaderLen {
+		return nil, ErrInvalidImage
+	}
+	data = data[:riffEnd]
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:riffHeaderLen]...)
+
+	for i := riffHeaderLen; i < len(data); {
+		if i+riffChunkHeader > len(data) {
+			return nil, ErrInvalidImage
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + riffChunkHeader + size + size&1
+		if end > len(data) || end < i {
+			return nil, ErrInvalidImage
+		}
+
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if size > 0 {
+				out[start+riffChunkHeader] &^= webpVP8XExifFlag | webpVP8XXMPFlag
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-riffChunkHeader))
+
+	return out, nil
+}
@@ -0,0 +1,300 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestStripMetadataRejectsMalformedInput(t *testing.T) {
+	validJPEG := encodeJPEG(t, 8, 4)
+	validPNG := encodePNG(t, 8, 4)
+
+	tests := []struct {
+		name     string
+		mimeType string
+		data     []byte
+	}{
+		{"jpeg empty", "image/jpeg", nil},
+		{"jpeg not a jpeg", "image/jpeg", []byte("GIF89a")},
+		{"jpeg soi only", "image/jpeg", []byte{0xFF, 0xD8}},
+		{"jpeg segment length 0", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x00}},
+		{"jpeg segment length 1", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01}},
+		{"jpeg segment length past end", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x10, 0x00}},
+		{"jpeg truncated segment header", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00}},
+		{"jpeg missing marker", "image/jpeg", []byte{0xFF, 0xD8, 0x00, 0x00}},
+		{"jpeg fill bytes only", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xFF, 0xFF}},
+		{"jpeg truncated before scan", "image/jpeg", validJPEG[:20]},
+		{"png empty", "image/png", nil},
+		{"png signature only", "image/png", validPNG[:pngSignatureLen]},
+		{"png truncated chunk header", "image/png", validPNG[:pngSignatureLen+6]},
+		{"png chunk length past end", "image/png", withUint32(validPNG, pngSignatureLen, 0xFFFFFFFF, binary.BigEndian)},
+		{"png without iend", "image/png", validPNG[:len(validPNG)-pngChunkOverhead]},
+		{"webp empty", "image/webp", nil},
+		{"webp not riff", "image/webp", []byte("RIFX\x04\x00\x00\x00WEBP")},
+		{"webp not webp", "image/webp", []byte("RIFF\x04\x00\x00\x00WAVE")},
+		{"webp riff size past end", "image/webp", []byte("RIFF\xFF\x00\x00\x00WEBP")},
+		{"webp riff size too small", "image/webp", []byte("RIFF\x00\x00\x00\x00WEBP")},
+		{"webp truncated chunk header", "image/webp", buildWebP([]byte("VP8X\x0A\x00"))},
+		{"webp chunk size past end", "image/webp", buildWebP(riffChunk("VP8 ", make([]byte, 4))[:10])},
+	}
+
+	p := NewProcessor(64)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.StripMetadata(tt.data, tt.mimeType)
+			if !errors.Is(err, ErrInvalidImage) {
+				t.Fatalf("StripMetadata() error = %v, want %v", err, ErrInvalidImage)
+			}
+		})
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no segments", []byte{0xFF, 0xD8}, orientationNormal},
+		{"segment length 0", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x00}, orientationNormal},
+		{"segment length 1", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01}, orientationNormal},
+		{"segment length past end", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF}, orientationNormal},
+		{"empty exif", withAPP1([]byte{0xFF, 0xD8, 0xFF, 0xD9}, []byte("Exif\x00\x00")), orientationNormal},
+		{"ifd offset past end", withAPP1([]byte{0xFF, 0xD8, 0xFF, 0xD9},
+			[]byte("Exif\x00\x00II\x2A\x00\xFF\xFF\x00\x00")), orientationNormal},
+		{"little endian", withAPP1([]byte{0xFF, 0xD8, 0xFF, 0xD9}, exifWithOrientation(binary.LittleEndian, 6)), 6},
+		{"big endian", withAPP1([]byte{0xFF, 0xD8, 0xFF, 0xD9}, exifWithOrientation(binary.BigEndian, 3)), 3},
+		{"out of range", withAPP1([]byte{0xFF, 0xD8, 0xFF, 0xD9}, exifWithOrientation(binary.BigEndian, 9)),
+			orientationNormal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Fatalf("jpegOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripMetadataRemovesJPEGMetadata(t *testing.T) {
+	data := withAPP1(encodeJPEG(t, 8, 4), exifWithOrientation(binary.BigEndian, orientationNormal))
+	data = withSegment(data, jpegCOM, []byte("taken by someone"))
+
+	out, err := NewProcessor(64).StripMetadata(data, "image/jpeg")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if bytes.Contains(out, []byte("Exif")) || bytes.Contains(out, []byte("taken by")) {
+		t.Fatal("metadata was left in the image")
+	}
+	if _, err = jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("stripped image doesn't decode: %v", err)
+	}
+}
+
+func TestStripMetadataRemovesJPEGTrailer(t *testing.T) {
+	// Phones put an MPF index in APP2 and append secondary images, each with
+	// EXIF of its own, after the primary image's EOI.
+	primary := withSegment(encodeJPEG(t, 8, 4), jpegAPP2, []byte("MPF\x00MM\x00\x2A\x00\x00\x00\x08"))
+	secondary := withAPP1(encodeJPEG(t, 4, 2), []byte("Exif\x00\x00GPSLatitude 52.52N"))
+	data := append(append([]byte{}, primary...), secondary...)
+
+	out, err := NewProcessor(64).StripMetadata(data, "image/jpeg")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	for _, leak := range []string{"MPF\x00", "Exif", "GPSLatitude"} {
+		if bytes.Contains(out, []byte(leak)) {
+			t.Fatalf("%q was left in the image", leak)
+		}
+	}
+	if !bytes.HasSuffix(out, []byte{jpegMarkerPrefix, jpegEOI}) || len(out) >= len(primary) {
+		t.Fatal("image wasn't cut at the primary EOI")
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("stripped image doesn't decode: %v", err)
+	}
+	if cfg.Width != 8 || cfg.Height != 4 {
+		t.Fatalf("size = %dx%d, want the primary image's 8x4", cfg.Width, cfg.Height)
+	}
+}
+
+func TestStripMetadataAppliesJPEGOrientation(t *testing.T) {
+	data := withAPP1(encodeJPEG(t, 8, 4), exifWithOrientation(binary.BigEndian, 6))
+
+	out, err := NewProcessor(64).StripMetadata(data, "image/jpeg")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("stripped image doesn't decode: %v", err)
+	}
+	if cfg.Width != 4 || cfg.Height != 8 {
+		t.Fatalf("size = %dx%d, want 4x8", cfg.Width, cfg.Height)
+	}
+	if bytes.Contains(out, []byte("Exif")) {
+		t.Fatal("EXIF was left in the image")
+	}
+}
+
+func TestStripMetadataRemovesPNGMetadata(t *testing.T) {
+	data := encodePNG(t, 8, 4)
+	// Insert a tEXt chunk right after IHDR.
+	ihdrEnd := pngSignatureLen + pngChunkOverhead + int(binary.BigEndian.Uint32(data[pngSignatureLen:]))
+	text := pngChunk("tEXt", []byte("Author\x00someone"))
+	data = append(append(append([]byte{}, data[:ihdrEnd]...), text...), data[ihdrEnd:]...)
+
+	out, err := NewProcessor(64).StripMetadata(data, "image/png")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if bytes.Contains(out, []byte("tEXt")) {
+		t.Fatal("tEXt chunk was left in the image")
+	}
+	if _, err = png.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("stripped image doesn't decode: %v", err)
+	}
+}
+
+func TestStripMetadataRemovesWebPMetadata(t *testing.T) {
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpVP8XExifFlag | webpVP8XXMPFlag
+	data := buildWebP(
+		riffChunk("VP8X", vp8x),
+		riffChunk("VP8L", []byte{0x2F, 0x00, 0x00, 0x00, 0x00}),
+		riffChunk("EXIF", []byte("MM\x00\x2A")),
+		riffChunk("XMP ", []byte("<x/>")),
+	)
+
+	out, err := NewProcessor(64).StripMetadata(data, "image/webp")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if bytes.Contains(out, []byte("EXIF")) || bytes.Contains(out, []byte("XMP ")) {
+		t.Fatal("metadata chunks were left in the image")
+	}
+	if flags := out[riffHeaderLen+riffChunkHeader]; flags&(webpVP8XExifFlag|webpVP8XXMPFlag) != 0 {
+		t.Fatalf("VP8X flags = %#x, want metadata flags cleared", flags)
+	}
+	if size := int(binary.LittleEndian.Uint32(out[4:])); size != len(out)-riffChunkHeader {
+		t.Fatalf("RIFF size = %d, want %d", size, len(out)-riffChunkHeader)
+	}
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(w, h), nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(w, h)); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 30), G: uint8(y * 60), B: 128, A: 255})
+		}
+	}
+
+	return img
+}
+
+// withAPP1 inserts an APP1 segment with the payload right after SOI.
+func withAPP1(jpegData, payload []byte) []byte {
+	return withSegment(jpegData, jpegAPP1, payload)
+}
+
+func withSegment(jpegData []byte, marker byte, payload []byte) []byte {
+	segment := []byte{jpegMarkerPrefix, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+
+	return append(out, jpegData[2:]...)
+}
+
+// exifWithOrientation builds an EXIF payload whose IFD0 holds only the
+// orientation tag.
+func exifWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+ifdEntrySize+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	entry := tiff[10:]
+	order.PutUint16(entry, exifOrientationTag)
+	order.PutUint16(entry[2:], exifTypeShort)
+	order.PutUint32(entry[4:], 1)
+	order.PutUint16(entry[8:], orientation)
+
+	return append([]byte("Exif\x00\x00"), tiff...)
+}
+
+func pngChunk(kind string, payload []byte) []byte {
+	chunk := make([]byte, 8, pngChunkOverhead+len(payload))
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)))
+	copy(chunk[4:], kind)
+	chunk = append(chunk, payload...)
+
+	// The CRC isn't checked when stripping.
+	return append(chunk, 0, 0, 0, 0)
+}
+
+func riffChunk(kind string, payload []byte) []byte {
+	chunk := make([]byte, riffChunkHeader, riffChunkHeader+len(payload)+1)
+	copy(chunk, kind)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+
+	return chunk
+}
+
+func buildWebP(chunks ...[]byte) []byte {
+	out := []byte("RIFF\x00\x00\x00\x00WEBP")
+	for _, c := range chunks {
+		out = append(out, c...)
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-riffChunkHeader))
+
+	return out
+}
+
+func withUint32(data []byte, offset int, v uint32, order binary.ByteOrder) []byte {
+	out := append([]byte{}, data...)
+	order.PutUint32(out[offset:], v)
+
+	return out
+}
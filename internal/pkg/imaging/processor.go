@@ -0,0 +1,205 @@
+// Package imaging prepares uploaded images: it strips identifying metadata
+// and renders thumbnails and placeholders.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Registered for image.Decode.
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// maxPixels guards against decompression bombs: a small file declaring
+	// huge dimensions.
+	maxPixels = 50_000_000
+
+	originalQuality  = 92
+	thumbnailQuality = 80
+	// placeholderSize is the side of the image the placeholder is computed
+	// from; a blurred preview needs no more detail.
+	placeholderSize = 32
+)
+
+var (
+	ErrInvalidImage     = errors.New("file is not a valid image")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+	ErrUnsupportedImage = errors.New("image format is not supported")
+)
+
+// Preview describes an image and carries its thumbnail as JPEG.
+type Preview struct {
+	Width       int
+	Height      int
+	Thumbnail   []byte
+	Placeholder string
+}
+
+type Processor struct {
+	thumbnailSize int
+}
+
+// NewProcessor creates a processor whose thumbnails fit in a square of
+// thumbnailSize pixels.
+func NewProcessor(thumbnailSize int) *Processor {
+	return &Processor{thumbnailSize: thumbnailSize}
+}
+
+// StripMetadata removes EXIF, XMP and similar metadata from an image of the
+// given MIME type. A JPEG whose EXIF orientation isn't upright is re-encoded
+// with the rotation applied, since the tag that described it is gone;
+// otherwise the pixel data is left untouched.
+func (p *Processor) StripMetadata(data []byte, mimeType string) ([]byte, error) {
+	switch mimeType {
+	case "image/jpeg":
+		if orientation := jpegOrientation(data); orientation != orientationNormal {
+			return reencodeJPEG(data, orientation)
+		}
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	case "image/gif", "image/bmp":
+		if _, err := decodeConfig(data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	default:
+		return nil, ErrUnsupportedImage
+	}
+}
+
+// Preview decodes the image and renders its thumbnail and placeholder.
+func (p *Processor) Preview(data []byte) (Preview, error) {
+	if _, err := decodeConfig(data); err != nil {
+		return Preview{}, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Preview{}, ErrInvalidImage
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	thumbWidth, thumbHeight := fit(width, height, p.thumbnailSize)
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	// JPEG has no alpha, so transparent areas are flattened onto white.
+	draw.Draw(thumb, thumb.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(thumb, thumb.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return Preview{}, err
+	}
+
+	placeholderWidth, placeholderHeight := fit(thumbWidth, thumbHeight, placeholderSize)
+	small := image.NewRGBA(image.Rect(0, 0, placeholderWidth, placeholderHeight))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), thumb, thumb.Bounds(), draw.Src, nil)
+
+	return Preview{
+		Width:       width,
+		Height:      height,
+		Thumbnail:   buf.Bytes(),
+		Placeholder: blurhash(small),
+	}, nil
+}
+
+func decodeConfig(data []byte) (image.Config, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return image.Config{}, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return image.Config{}, ErrImageTooLarge
+	}
+
+	return cfg, nil
+}
+
+// reencodeJPEG decodes the JPEG, applies the EXIF orientation and encodes
+// it again, which leaves every metadata segment behind.
+func reencodeJPEG(data []byte, orientation int) ([]byte, error) {
+	if _, err := decodeConfig(data); err != nil {
+		return nil, err
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, orient(img, orientation), &jpeg.Options{Quality: originalQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// orient returns img transformed so that it displays upright given its EXIF
+// orientation (2-8).
+func orient(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
+
+// fit scales width and height down to fit in a size square, keeping the
+// aspect ratio. Images that already fit are kept as they are.
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+
+	return max(1, width*size/height), size
+}
@@ -2,6 +2,7 @@ package txhelper
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -32,3 +33,21 @@ func (txh *TxHelper) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, e
 
 	return tx, nil
 }
+
+// WithTx runs fn in a transaction, committing it if fn succeeds and rolling
+// it back otherwise.
+func (txh *TxHelper) WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := txh.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("rollback transaction: %w (after: %w)", rbErr, err)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,20 @@
+ALTER TABLE chat_participants
+    ADD COLUMN IF NOT EXISTS "role" VARCHAR(16) NOT NULL DEFAULT 'member'
+        CHECK ("role" IN ('owner', 'admin', 'member', 'read_only'));
+
+UPDATE chat_participants cp
+SET "role" = 'owner'
+FROM chats c
+WHERE c.id = cp.chat_id AND c.owner_id = cp.user_id;
+
+CREATE TABLE IF NOT EXISTS chat_role_changes (
+    id UUID PRIMARY KEY,
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES user_accounts(id),
+    actor_id UUID NOT NULL REFERENCES user_accounts(id),
+    old_role VARCHAR(16),
+    new_role VARCHAR(16),
+    changed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS chat_role_changes_chat_id_idx ON chat_role_changes(chat_id, changed_at);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS chat_invites (
+    id UUID PRIMARY KEY,
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    created_by UUID NOT NULL REFERENCES user_accounts(id),
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    max_uses INT CHECK (max_uses > 0),
+    uses INT NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS chat_invites_chat_id_idx ON chat_invites(chat_id);
+
+CREATE TABLE IF NOT EXISTS chat_invite_events (
+    id UUID PRIMARY KEY,
+    invite_id UUID NOT NULL REFERENCES chat_invites(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES user_accounts(id),
+    event VARCHAR(16) NOT NULL CHECK (event IN ('created', 'used', 'revoked')),
+    occurred_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS chat_invite_events_invite_id_idx ON chat_invite_events(invite_id);
@@ -0,0 +1,11 @@
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'group'
+        CHECK (kind IN ('group', 'direct'));
+
+CREATE TABLE IF NOT EXISTS direct_chats (
+    chat_id UUID PRIMARY KEY REFERENCES chats(id) ON DELETE CASCADE,
+    user_low UUID NOT NULL REFERENCES user_accounts(id),
+    user_high UUID NOT NULL REFERENCES user_accounts(id),
+    UNIQUE (user_low, user_high),
+    CHECK (user_low < user_high)
+);
@@ -0,0 +1,10 @@
+ALTER TABLE messages
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+CREATE INDEX IF NOT EXISTS messages_chat_tag_created_at_idx ON messages(chat_tag, created_at DESC);
+
+ALTER TABLE chat_participants
+    ADD COLUMN IF NOT EXISTS last_read_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS last_read_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS chat_participants_user_id_idx ON chat_participants(user_id);
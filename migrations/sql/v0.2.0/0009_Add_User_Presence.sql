@@ -0,0 +1,4 @@
+ALTER TABLE user_accounts
+    ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS last_seen_visibility VARCHAR(16) NOT NULL DEFAULT 'everyone'
+        CHECK (last_seen_visibility IN ('everyone', 'nobody'));
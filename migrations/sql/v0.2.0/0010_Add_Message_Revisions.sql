@@ -0,0 +1,12 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS message_revisions (
+    id UUID PRIMARY KEY,
+    message_id UUID NOT NULL REFERENCES messages(id),
+    content TEXT NOT NULL,
+    edited_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS message_revisions_message_id_idx ON message_revisions(message_id, edited_at);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS message_reactions (
+    message_id UUID NOT NULL REFERENCES messages(id),
+    user_id UUID NOT NULL REFERENCES user_accounts(id),
+    emoji VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (message_id, user_id, emoji)
+);
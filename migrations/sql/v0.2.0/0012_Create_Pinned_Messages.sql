@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS pinned_messages (
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    pinned_by UUID NOT NULL REFERENCES user_accounts(id),
+    pinned_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS pinned_messages_chat_id_pinned_at_idx ON pinned_messages(chat_id, pinned_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY,
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    uploader_id UUID NOT NULL REFERENCES user_accounts(id),
+    message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    file_name TEXT NOT NULL,
+    mime_type VARCHAR(127) NOT NULL,
+    size BIGINT NOT NULL CHECK (size > 0),
+    checksum CHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS attachments_message_id_idx ON attachments(message_id);
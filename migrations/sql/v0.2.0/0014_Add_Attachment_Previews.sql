@@ -0,0 +1,11 @@
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS width INT CHECK (width > 0),
+    ADD COLUMN IF NOT EXISTS height INT CHECK (height > 0),
+    ADD COLUMN IF NOT EXISTS placeholder TEXT,
+    ADD COLUMN IF NOT EXISTS thumbnail_key TEXT,
+    ADD COLUMN IF NOT EXISTS thumbnail_status VARCHAR(16)
+        CHECK (thumbnail_status IN ('pending', 'ready', 'failed')),
+    ADD COLUMN IF NOT EXISTS thumbnail_locked_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS attachments_thumbnail_pending_idx ON attachments(created_at)
+    WHERE thumbnail_status = 'pending';
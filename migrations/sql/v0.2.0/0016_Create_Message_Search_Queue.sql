@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS message_search_queue (
+    message_id UUID PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    queued_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS message_search_queue_queued_at_idx ON message_search_queue(queued_at);
@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE user_accounts
+    ADD COLUMN IF NOT EXISTS discoverable BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE INDEX IF NOT EXISTS user_accounts_tag_trgm_idx ON user_accounts USING GIN (lower(tag) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS user_accounts_name_trgm_idx ON user_accounts USING GIN (lower(name) gin_trgm_ops);
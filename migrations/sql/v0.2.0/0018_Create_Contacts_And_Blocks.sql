@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS user_contacts (
+    user_id UUID NOT NULL REFERENCES user_accounts(id),
+    contact_id UUID NOT NULL REFERENCES user_accounts(id),
+    added_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, contact_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_blocks (
+    user_id UUID NOT NULL REFERENCES user_accounts(id),
+    blocked_id UUID NOT NULL REFERENCES user_accounts(id),
+    blocked_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_blocks_blocked_id_idx ON user_blocks(blocked_id);
@@ -0,0 +1,5 @@
+ALTER TABLE chat_participants
+    ADD COLUMN IF NOT EXISTS muted_until TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS notify_level VARCHAR(16) NOT NULL DEFAULT 'all'
+        CHECK (notify_level IN ('all', 'mentions')),
+    ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS message_mentions (
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    "offset" INT NOT NULL,
+    "length" INT NOT NULL,
+    user_id UUID NOT NULL REFERENCES user_accounts(id),
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (message_id, "offset")
+);
+
+CREATE INDEX IF NOT EXISTS message_mentions_user_id_created_at_idx ON message_mentions(user_id, created_at DESC);
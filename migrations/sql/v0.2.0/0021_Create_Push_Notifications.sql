@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS device_tokens (
+    token TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES user_accounts(id),
+    platform VARCHAR(16) NOT NULL CHECK (platform IN ('fcm', 'apns')),
+    registered_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS device_tokens_user_id_idx ON device_tokens(user_id);
+
+CREATE TABLE IF NOT EXISTS push_queue (
+    message_id UUID PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    queued_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS push_queue_queued_at_idx ON push_queue(queued_at);
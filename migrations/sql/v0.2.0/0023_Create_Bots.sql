@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS bots (
+    user_id UUID PRIMARY KEY REFERENCES user_accounts(id),
+    owner_id UUID NOT NULL REFERENCES user_accounts(id),
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS bots_owner_id_idx ON bots(owner_id);
+
+CREATE TABLE IF NOT EXISTS bot_api_keys (
+    id UUID PRIMARY KEY,
+    bot_id UUID NOT NULL REFERENCES bots(user_id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    key_hash TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS bot_api_keys_bot_id_idx ON bot_api_keys(bot_id);
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS chat_commands (
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,
+    bot_id UUID NOT NULL REFERENCES bots(user_id) ON DELETE CASCADE,
+    description VARCHAR(256) NOT NULL DEFAULT '',
+    created_by UUID NOT NULL REFERENCES user_accounts(id),
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS command_invocations (
+    id UUID PRIMARY KEY,
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    bot_id UUID NOT NULL REFERENCES bots(user_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES user_accounts(id),
+    command VARCHAR(32) NOT NULL,
+    args TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    claimed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS command_invocations_unclaimed_idx ON command_invocations(bot_id, created_at)
+    WHERE claimed_at IS NULL;
+CREATE INDEX IF NOT EXISTS command_invocations_user_id_idx ON command_invocations(user_id, chat_id);
+
+CREATE TABLE IF NOT EXISTS command_replies (
+    id UUID PRIMARY KEY,
+    invocation_id UUID NOT NULL REFERENCES command_invocations(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS command_replies_invocation_id_idx ON command_replies(invocation_id);
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS attachments_unsent_idx ON attachments(created_at)
+    WHERE message_id IS NULL;
@@ -0,0 +1,11 @@
+ALTER TABLE push_queue
+    ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS tokens TEXT[];
+
+UPDATE push_queue SET next_attempt_at = queued_at WHERE next_attempt_at IS NULL;
+
+ALTER TABLE push_queue ALTER COLUMN next_attempt_at SET NOT NULL;
+
+DROP INDEX IF EXISTS push_queue_queued_at_idx;
+CREATE INDEX IF NOT EXISTS push_queue_next_attempt_at_idx ON push_queue(next_attempt_at);
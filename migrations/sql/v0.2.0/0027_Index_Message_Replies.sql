@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS messages_reply_to_idx ON messages(reply_to)
+    WHERE reply_to IS NOT NULL;
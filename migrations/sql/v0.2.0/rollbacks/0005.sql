@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS chat_role_changes_chat_id_idx;
+DROP TABLE IF EXISTS chat_role_changes;
+ALTER TABLE chat_participants DROP COLUMN IF EXISTS "role";
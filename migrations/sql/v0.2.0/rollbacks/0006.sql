@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS chat_invite_events_invite_id_idx;
+DROP TABLE IF EXISTS chat_invite_events;
+DROP INDEX IF EXISTS chat_invites_chat_id_idx;
+DROP TABLE IF EXISTS chat_invites;
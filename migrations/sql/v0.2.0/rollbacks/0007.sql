@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS direct_chats;
+ALTER TABLE chats DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS chat_participants_user_id_idx;
+ALTER TABLE chat_participants
+    DROP COLUMN IF EXISTS last_read_at,
+    DROP COLUMN IF EXISTS last_read_message_id;
+DROP INDEX IF EXISTS messages_chat_tag_created_at_idx;
+ALTER TABLE messages
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
@@ -0,0 +1,3 @@
+ALTER TABLE user_accounts
+    DROP COLUMN IF EXISTS last_seen_visibility,
+    DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS attachments_thumbnail_pending_idx;
+
+ALTER TABLE attachments
+    DROP COLUMN IF EXISTS thumbnail_locked_until,
+    DROP COLUMN IF EXISTS thumbnail_status,
+    DROP COLUMN IF EXISTS thumbnail_key,
+    DROP COLUMN IF EXISTS placeholder,
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS width;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS user_accounts_name_trgm_idx;
+DROP INDEX IF EXISTS user_accounts_tag_trgm_idx;
+
+ALTER TABLE user_accounts
+    DROP COLUMN IF EXISTS discoverable;
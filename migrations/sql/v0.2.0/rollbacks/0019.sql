@@ -0,0 +1,4 @@
+ALTER TABLE chat_participants
+    DROP COLUMN IF EXISTS archived,
+    DROP COLUMN IF EXISTS notify_level,
+    DROP COLUMN IF EXISTS muted_until;
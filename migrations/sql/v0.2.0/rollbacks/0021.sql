@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS push_queue;
+DROP TABLE IF EXISTS device_tokens;
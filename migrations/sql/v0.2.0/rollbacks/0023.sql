@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bot_api_keys;
+DROP TABLE IF EXISTS bots;
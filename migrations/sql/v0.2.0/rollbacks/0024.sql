@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS command_replies;
+DROP TABLE IF EXISTS command_invocations;
+DROP TABLE IF EXISTS chat_commands;
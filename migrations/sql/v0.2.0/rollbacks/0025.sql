@@ -0,0 +1 @@
+DROP INDEX IF EXISTS attachments_unsent_idx;
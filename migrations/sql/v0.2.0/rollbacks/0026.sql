@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS push_queue_next_attempt_at_idx;
+CREATE INDEX IF NOT EXISTS push_queue_queued_at_idx ON push_queue(queued_at);
+
+ALTER TABLE push_queue
+    DROP COLUMN IF EXISTS tokens,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts;
@@ -2,8 +2,15 @@ package config
 
 type LogConfig struct {
 	Logger struct {
-		Level    string `mapstructure:"level"`
-		LogsDir  string `mapstructure:"logs-dir"`
-		LogsFile string `mapstructure:"logs-file"`
+		Level    string            `mapstructure:"level"`
+		LogsDir  string            `mapstructure:"logs-dir"`
+		LogsFile string            `mapstructure:"logs-file"`
+		Levels   map[string]string `mapstructure:"levels"`
+		Rotation struct {
+			MaxSizeMB  int  `mapstructure:"max-size-mb"`
+			Daily      bool `mapstructure:"daily"`
+			MaxBackups int  `mapstructure:"max-backups"`
+			Compress   bool `mapstructure:"compress"`
+		} `mapstructure:"rotation"`
 	} `mapstructure:"logger"`
 }